@@ -20,6 +20,10 @@ func Start() {
 	e.POST("/api/v1/auth/login", auth.Login)
 	e.POST("/api/v1/auth/verify", auth.Verify)
 
+	// Playback URLs for external players; the token is the credential
+	e.GET("/api/v1/play/:token", Play)
+	e.HEAD("/api/v1/play/:token", Play)
+
 	// Static assets
 	e.Static("/api/v1/images", "./assets")
 
@@ -40,6 +44,7 @@ func Start() {
 	// Config
 	v1.GET("/user/config", GetUserConfig)
 	v1.POST("/user/config", UpdateUserConfig)
+	v1.GET("/user/debrid", GetDebridAccount)
 
 	// Discovery
 	v1.GET("/discover/search", Search)
@@ -49,17 +54,45 @@ func Start() {
 
 	// Real Debrid
 	v1.POST("/rd/unrestrict", Unrestrict)
+	v1.POST("/rd/device/start", StartRealDebridLink)
+	v1.GET("/rd/device/poll", PollRealDebridLink)
+	v1.GET("/debrid/cleanup", PreviewCleanup)
+	v1.POST("/debrid/cleanup", RunCleanup)
 
 	// Torrent scraping
 	v1.GET("/stream/scrape", ScrapeStreams)
+	v1.GET("/stream/scrape/events", ScrapeStreamEvents)
 
 	// Torrent Resolve
 	v1.POST("/stream/resolve", ResolveStream)
+	v1.POST("/stream/play-best", PlayBest)
+	v1.GET("/stream/proxy", ProxyStream)
+	v1.HEAD("/stream/proxy", ProxyStream)
+	v1.GET("/stream/usage", GetStreamUsage)
+	v1.GET("/playback-tokens", ListPlaybackTokens)
+	v1.POST("/playback-tokens", CreatePlaybackToken)
+	v1.DELETE("/playback-tokens/:id", RevokePlaybackToken)
+	v1.GET("/stream/pending", ListPending)
+	v1.GET("/stream/pending/events", PendingEvents)
+	v1.DELETE("/stream/pending/:id", DismissPending)
 
 	// Profiles
 	v1.GET("/profiles", ListProfiles)
 	v1.POST("/profiles", CreateProfile)
 
+	// Stream ranking & filtering
+	v1.GET("/ranking/presets", ListRankingPresets)
+	v1.GET("/profiles/:id/ranking", GetRankingProfile)
+	v1.PUT("/profiles/:id/ranking", UpdateRankingProfile)
+	v1.GET("/profiles/:id/filters", GetStreamFilters)
+	v1.PUT("/profiles/:id/filters", UpdateStreamFilters)
+
+	// Subtitles
+	v1.GET("/subtitles/search", SearchSubtitles)
+	v1.GET("/subtitles/:id", GetSubtitle)
+	v1.GET("/profiles/:id/subtitles", GetSubtitleLanguages)
+	v1.PUT("/profiles/:id/subtitles", UpdateSubtitleLanguages)
+
 	// Favorites
 	favorites := v1.Group("/favorites")
 	favorites.POST("", AddFavorite)
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Cleaner deletes torrents Rivulet added once they're old or watched
+var Cleaner *services.TorrentCleaner
+
+// startTorrentCleaner creates the cleanup job and starts its schedule
+func startTorrentCleaner() {
+	Cleaner = services.NewTorrentCleaner(func(accountID uuid.UUID) (providers.DebridService, string, error) {
+		keys, err := getUserKeys(accountID)
+		if err != nil {
+			return nil, "", err
+		}
+		return debridFor(keys)
+	})
+	Cleaner.Interval = envDuration("DEBRID_CLEANUP_INTERVAL", services.DefaultCleanupInterval)
+	go Cleaner.Run(context.Background())
+}
+
+// GET /debrid/cleanup
+// Dry run: lists the torrents the next cleanup would delete
+func PreviewCleanup(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	plan, err := Cleaner.Plan(userID)
+	if err != nil {
+		return cleanupError(c, err)
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// POST /debrid/cleanup
+// Runs the cleanup now instead of waiting for the schedule
+func RunCleanup(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	deleted, err := Cleaner.Clean(c.Request().Context(), userID)
+	if err != nil {
+		return cleanupError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"deleted": deleted})
+}
+
+func cleanupError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, providers.ErrDebridNotConfigured):
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	case errors.Is(err, services.ErrCleanupUnsupported):
+		return c.JSON(http.StatusNotImplemented, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "cleanup failed"})
+	}
+}
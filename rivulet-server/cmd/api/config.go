@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"rivulet_server/internal/db"
 	"rivulet_server/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -11,8 +12,16 @@ import (
 
 type ConfigRequest struct {
 	RealDebridKey string `json:"real_debrid_key"`
+	AllDebridKey  string `json:"alldebrid_key"`
+	PremiumizeKey string `json:"premiumize_key"`
+	TorBoxKey     string `json:"torbox_key"`
+	DebridService string `json:"debrid_service"` // "realdebrid", "alldebrid", "premiumize", "torbox"
 	TMDbKey       string `json:"tmdb_key"`
 	MDBListKey    string `json:"mdblist_key"`
+
+	// Debrid torrent cleanup, left unchanged when omitted
+	TorrentRetentionDays  *int  `json:"torrent_retention_days,omitempty"` // 0 = never delete for age
+	DeleteWatchedTorrents *bool `json:"delete_watched_torrents,omitempty"`
 }
 
 // POST /user/config
@@ -30,9 +39,32 @@ func UpdateUserConfig(c echo.Context) error {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
 	}
 
+	if req.DebridService != "" {
+		if _, ok := DebridServices[req.DebridService]; !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown debrid service"})
+		}
+	}
+
 	// Update fields if provided (allow partial updates)
 	if req.RealDebridKey != "" {
 		account.RealDebridKey = req.RealDebridKey
+		// A pasted API token replaces an OAuth link
+		account.RDClientID = ""
+		account.RDClientSecret = ""
+		account.RDRefreshToken = ""
+		account.RDTokenExpiresAt = time.Time{}
+	}
+	if req.AllDebridKey != "" {
+		account.AllDebridKey = req.AllDebridKey
+	}
+	if req.PremiumizeKey != "" {
+		account.PremiumizeKey = req.PremiumizeKey
+	}
+	if req.TorBoxKey != "" {
+		account.TorBoxKey = req.TorBoxKey
+	}
+	if req.DebridService != "" {
+		account.DebridService = req.DebridService
 	}
 	if req.TMDbKey != "" {
 		account.TMDbKey = req.TMDbKey
@@ -40,6 +72,15 @@ func UpdateUserConfig(c echo.Context) error {
 	if req.MDBListKey != "" {
 		account.MDBListKey = req.MDBListKey
 	}
+	if req.TorrentRetentionDays != nil {
+		if *req.TorrentRetentionDays < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "torrent_retention_days must not be negative"})
+		}
+		account.TorrentRetentionDays = *req.TorrentRetentionDays
+	}
+	if req.DeleteWatchedTorrents != nil {
+		account.DeleteWatchedTorrents = *req.DeleteWatchedTorrents
+	}
 
 	if err := db.DB.Save(&account).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save config"})
@@ -53,12 +94,21 @@ func UpdateUserConfig(c echo.Context) error {
 func GetUserConfig(c echo.Context) error {
 	userID := c.Get("user_id").(uuid.UUID)
 	var account models.Account
-	db.DB.Select("real_debrid_key, tm_db_key, mdb_list_key").First(&account, userID)
+	db.DB.Select("real_debrid_key, all_debrid_key, premiumize_key, tor_box_key, debrid_service, tm_db_key, mdb_list_key, rd_refresh_token, torrent_retention_days, delete_watched_torrents").First(&account, userID)
 
-	return c.JSON(http.StatusOK, map[string]string{
+	return c.JSON(http.StatusOK, map[string]interface{}{
 		"real_debrid_key": maskKey(account.RealDebridKey),
+		"alldebrid_key":   maskKey(account.AllDebridKey),
+		"premiumize_key":  maskKey(account.PremiumizeKey),
+		"torbox_key":      maskKey(account.TorBoxKey),
+		"debrid_service":  account.DebridService,
 		"tmdb_key":        maskKey(account.TMDbKey),
 		"mdblist_key":     maskKey(account.MDBListKey),
+
+		"real_debrid_linked": account.RDRefreshToken != "", // Linked through /rd/device instead of a pasted token
+
+		"torrent_retention_days":  account.TorrentRetentionDays,
+		"delete_watched_torrents": account.DeleteWatchedTorrents,
 	})
 }
 
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/services"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// DebridServices holds every supported debrid backend, keyed by service name
+var DebridServices = map[string]providers.DebridService{}
+
+// debridOrder is the preference used when an account hasn't picked a service
+var debridOrder = []string{"realdebrid", "alldebrid", "premiumize", "torbox"}
+
+// debridFor returns the account's debrid service and the key to use with it
+func debridFor(keys *UserKeys) (providers.DebridService, string, error) {
+	if keys.Debrid == "" || keys.DebridKey == "" {
+		return nil, "", providers.ErrDebridNotConfigured
+	}
+	service, ok := DebridServices[keys.Debrid]
+	if !ok {
+		return nil, "", providers.ErrDebridNotConfigured
+	}
+	return service, keys.DebridKey, nil
+}
+
+// pickDebrid chooses the account's service: the explicit choice if it has a key,
+// otherwise the first service with a key
+func pickDebrid(preferred string, keys map[string]string) (string, string) {
+	if preferred != "" && keys[preferred] != "" {
+		return preferred, keys[preferred]
+	}
+	for _, name := range debridOrder {
+		if keys[name] != "" {
+			return name, keys[name]
+		}
+	}
+	return "", ""
+}
+
+// premiumGate stops resolve and scrape calls when the debrid subscription has
+// expired, answering 402 with code "premium_expired". When premium is about to
+// run out the request goes ahead with an X-Debrid-Warning header.
+// It returns true when the response has already been written.
+func premiumGate(c echo.Context, debrid providers.DebridService, userID uuid.UUID, token string) (bool, error) {
+	warning, err := services.CheckPremium(c.Request().Context(), debrid, userID, token)
+	if errors.Is(err, services.ErrPremiumExpired) {
+		return true, c.JSON(http.StatusPaymentRequired, map[string]string{
+			"error":   "your " + debrid.Name() + " premium has expired",
+			"code":    "premium_expired",
+			"service": debrid.Name(),
+		})
+	}
+	if warning != "" {
+		c.Response().Header().Set("X-Debrid-Warning", warning)
+	}
+	return false, nil
+}
+
+// DebridAccountResponse is the /user/debrid payload
+type DebridAccountResponse struct {
+	*providers.DebridAccount
+	Warning string `json:"warning,omitempty"` // Set when premium has expired or is about to
+}
+
+// GET /user/debrid
+// Subscription state of the account's debrid service
+func GetDebridAccount(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	keys, err := getUserKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
+	}
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	}
+
+	account, err := services.DebridAccountInfo(c.Request().Context(), debrid, userID, token)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to reach " + debrid.Name()})
+	}
+	return c.JSON(http.StatusOK, DebridAccountResponse{DebridAccount: account, Warning: services.PremiumWarning(account)})
+}
@@ -1,20 +1,31 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"regexp"
+	"os"
 	"rivulet_server/internal/db"
+	"rivulet_server/internal/matching"
 	"rivulet_server/internal/models"
 	"rivulet_server/internal/providers"
+	"rivulet_server/internal/providers/alldebrid"
 	"rivulet_server/internal/providers/mdblist"
+	"rivulet_server/internal/providers/premiumize"
 	"rivulet_server/internal/providers/realdebrid"
+	"rivulet_server/internal/providers/stremio"
 	"rivulet_server/internal/providers/tmdb"
+	"rivulet_server/internal/providers/torbox"
 	"rivulet_server/internal/providers/torrentio"
+	"rivulet_server/internal/providers/torznab"
+	"rivulet_server/internal/ranking"
+	"rivulet_server/internal/services"
 	"strings"
 
-	"sort"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -32,10 +43,89 @@ func InitProviders() {
 	RdClient = realdebrid.NewClient()
 	TmdbClient = tmdb.NewClient()
 
+	// Accounts linked through OAuth get their token refreshed when RD rejects it
+	RDAuth = services.NewRealDebridAuth(RdClient)
+	RdClient.Refresher = RDAuth.RefreshExpired
+
+	// Debrid backends, picked per account
+	for _, service := range []providers.DebridService{RdClient, alldebrid.NewClient(), premiumize.NewClient(), torbox.NewClient()} {
+		DebridServices[service.Name()] = service
+	}
+
+	startPendingTracker()
+	startTorrentCleaner()
+	startStreamProxy()
+	registerSubtitleProviders()
+	registerMetadataProviders()
+	services.LinkTTL = envDuration("LINK_CACHE_TTL", services.DefaultLinkTTL)
+	PlayBestBudget = envDuration("PLAY_BEST_BUDGET", PlayBestBudget)
+	if n, err := strconv.Atoi(os.Getenv("PLAY_BEST_ATTEMPTS")); err == nil && n > 0 {
+		PlayBestAttempts = n
+	}
+
 	// Initialize scrapers
-	ScraperManager = providers.NewManager(
-		torrentio.NewClient(),
-	)
+	scrapers := []providers.Scraper{torrentio.NewClient()}
+
+	// Extra Stremio addons: STREMIO_ADDONS=https://a/manifest.json,https://b/manifest.json
+	for _, manifestURL := range strings.Split(os.Getenv("STREMIO_ADDONS"), ",") {
+		manifestURL = strings.TrimSpace(manifestURL)
+		if manifestURL == "" {
+			continue
+		}
+		scrapers = append(scrapers, stremio.NewClient(manifestURL))
+	}
+
+	// Torznab indexers (Jackett/Prowlarr): TORZNAB_INDEXERS=https://host/torznab|apikey,Name|https://other/api|apikey
+	for _, entry := range strings.Split(os.Getenv("TORZNAB_INDEXERS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "|")
+		label := ""
+		if len(parts) == 3 {
+			label, parts = strings.TrimSpace(parts[0]), parts[1:]
+		}
+		apiKey := ""
+		if len(parts) > 1 {
+			apiKey = parts[1]
+		}
+		client := torznab.NewClient(parts[0], apiKey)
+		client.Label = label
+		scrapers = append(scrapers, client)
+	}
+
+	ScraperManager = providers.NewManager(scrapers...)
+	ScraperManager.Timeout = envDuration("SCRAPE_TIMEOUT", providers.DefaultTimeout)
+	ScraperManager.ProviderTimeout = envDuration("SCRAPE_PROVIDER_TIMEOUT", providers.DefaultProviderTimeout)
+
+	// Scrape cache: SCRAPE_CACHE_STORE=memory (default), postgres or off
+	ScraperManager.CacheTTL = envDuration("SCRAPE_CACHE_TTL", providers.DefaultCacheTTL)
+	ScraperManager.CacheStale = envDuration("SCRAPE_CACHE_STALE", providers.DefaultCacheStale)
+	maxAge := ScraperManager.CacheTTL + ScraperManager.CacheStale
+	switch os.Getenv("SCRAPE_CACHE_STORE") {
+	case "off":
+		ScraperManager.Cache = nil
+	case "postgres":
+		ScraperManager.Cache = services.NewPostgresScrapeCache(maxAge)
+		services.PruneScrapeCache(maxAge)
+	default:
+		ScraperManager.Cache = providers.NewMemoryCache(maxAge)
+	}
+}
+
+// envDuration reads a duration like "8s" from the environment, falling back to def
+func envDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using %s", key, raw, def)
+		return def
+	}
+	return d
 }
 
 // --- Handlers ---
@@ -45,65 +135,26 @@ type ResolveRequest struct {
 	Season    int    `json:"season,omitempty"`
 	Episode   int    `json:"episode,omitempty"`
 	FileIndex *int   `json:"file_index,omitempty"`
-}
-
-// Helper to find the file ID for a specific episode
-func findFileID(files []realdebrid.File, season, episode int, fileIndex *int) (string, error) {
-	// 1. If it's a movie (Season 0), return the largest file
-	if season == 0 || episode == 0 {
-		var largestFile realdebrid.File
-		for _, f := range files {
-			if f.Bytes > largestFile.Bytes {
-				largestFile = f
-			}
-		}
-		if largestFile.ID == 0 { // Check if ID is set (assuming 0 is invalid or check nil)
-			return "", fmt.Errorf("no files found")
-		}
-		return fmt.Sprintf("%d", largestFile.ID), nil
-	}
-
-	// 2. If it's a TV Show, return the file at the specified index
-	if fileIndex != nil {
-		targetIdx := *fileIndex + 1
-
-		for _, f := range files {
-			if f.ID == targetIdx {
-				return fmt.Sprintf("%d", f.ID), nil
-			}
-		}
+	// Absolute episode number, for anime packs numbered without seasons
+	AbsoluteEpisode int `json:"absolute_episode,omitempty"`
 
-		// Sanity check bounds
-		if targetIdx >= 0 && targetIdx < len(files) {
-			candidate := files[targetIdx]
-			lower := strings.ToLower(candidate.Path)
-			if strings.HasSuffix(lower, ".mkv") ||
-				strings.HasSuffix(lower, ".mp4") ||
-				strings.HasSuffix(lower, ".avi") {
-				return fmt.Sprintf("%d", candidate.ID), nil
-			}
-		}
-	}
-
-	// If all else fails, fallback to regex approach
-	pattern := fmt.Sprintf(`(?i)(S0?%d\s?E0?%d\b|\b%dx0?%d\b)`, season, episode, season, episode)
-	re := regexp.MustCompile(pattern)
+	// Shown in the pending list if the torrent is still downloading
+	ExternalID string `json:"external_id,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
 
+// matchFile picks the file to play from a debrid torrent's file list
+func matchFile(files []providers.DebridFile, req ResolveRequest) (*matching.Result, error) {
+	candidates := make([]matching.File, 0, len(files))
 	for _, f := range files {
-		// Only look at video files (mkv, mp4, avi) to avoid matching "S01E01.nfo"
-		lowerPath := strings.ToLower(f.Path)
-		if !strings.HasSuffix(lowerPath, ".mkv") &&
-			!strings.HasSuffix(lowerPath, ".mp4") &&
-			!strings.HasSuffix(lowerPath, ".avi") {
-			continue
-		}
-
-		if re.MatchString(f.Path) {
-			return fmt.Sprintf("%d", f.ID), nil
-		}
+		candidates = append(candidates, matching.File{ID: f.ID, Path: f.Path, Bytes: f.Bytes})
 	}
-
-	return "", fmt.Errorf("episode S%02dE%02d not found in torrent", season, episode)
+	return matching.Match(candidates, matching.Target{
+		Season:    req.Season,
+		Episode:   req.Episode,
+		Absolute:  req.AbsoluteEpisode,
+		FileIndex: req.FileIndex,
+	})
 }
 
 // POST /stream/resolve
@@ -113,8 +164,12 @@ func ResolveStream(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
-	if keys.RD == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "RealDebrid API key not configured"})
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	}
+	if handled, err := premiumGate(c, debrid, userID, token); handled {
+		return err
 	}
 
 	var req ResolveRequest
@@ -122,224 +177,234 @@ func ResolveStream(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
 	}
 
-	// 1. Add Magnet
-	torrentID, err := RdClient.AddMagnet(keys.RD, req.Magnet)
+	r := &resolver{AccountID: userID, Debrid: debrid, Token: token}
+	res, err := r.resolve(c.Request().Context(), req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed adding to cloud: " + err.Error()})
+		var resErr *ResolveError
+		if errors.As(err, &resErr) {
+			return c.JSON(resErr.HTTPStatus, resErr)
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
-
-	// 2. Check Info
-	info, err := RdClient.GetTorrentInfo(keys.RD, torrentID)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed checking info"})
+	if res.Status == "downloading" {
+		trackPending(c, userID, debrid, req, res)
 	}
+	return c.JSON(http.StatusOK, res)
+}
 
-	// 3. Select Specific File (The "Stremio" Logic)
-	targetFileID, err := findFileID(info.Files, req.Season, req.Episode, req.FileIndex)
+// trackPending polls a torrent that's still downloading so the profile hears
+// when it's ready. Without a profile there is nobody to notify.
+func trackPending(c echo.Context, userID uuid.UUID, debrid providers.DebridService, req ResolveRequest, res *Resolution) {
+	profile, err := getActiveProfile(c, userID)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
-	}
+		return
+	}
+	Pending.Track(models.PendingTorrent{
+		AccountID:  userID,
+		ProfileID:  profile.ID,
+		Service:    debrid.Name(),
+		TorrentID:  res.TorrentID,
+		Hash:       res.hash,
+		Magnet:     req.Magnet,
+		ExternalID: req.ExternalID,
+		Title:      req.Title,
+		Season:     req.Season,
+		Episode:    req.Episode,
+		FileIndex:  req.FileIndex,
+		Progress:   res.progress,
+	})
+}
 
-	// 4. Send Selection to RD (If needed)
-	// If status is "waiting_files_selection", we MUST select this specific file to start the process/get the link.
-	if info.Status == "waiting_files_selection" {
-		err = RdClient.SelectFiles(keys.RD, torrentID, targetFileID)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed selecting file"})
-		}
-		// Refresh info to get the links
-		info, _ = RdClient.GetTorrentInfo(keys.RD, torrentID)
+// imdbFromExternal returns the IMDb ID in "imdb:tt123" or "tt123", or ""
+func imdbFromExternal(externalID string) string {
+	id := strings.TrimPrefix(externalID, "imdb:")
+	if !strings.HasPrefix(id, "tt") {
+		return ""
 	}
+	return id
+}
 
-	// 5. Find the Unrestrict Link
-
-	if info.Status == "downloaded" {
-		targetLink := ""
-		if len(info.Links) == 1 {
-			targetLink = info.Links[0]
-		} else {
-			if len(info.Links) > 0 {
-				targetLink = info.Links[0]
-			}
+// fileSelected reports whether the file is part of the torrent's download
+func fileSelected(files []providers.DebridFile, fileID int) bool {
+	for _, f := range files {
+		if f.ID == fileID {
+			return f.Selected
 		}
+	}
+	return false
+}
 
-		if targetLink != "" {
-			unrestricted, err := RdClient.UnrestrictLink(keys.RD, targetLink)
-			if err == nil {
-				// Helper to parse string ID back to int for frontend consistency
-				// User note: RD IDs are 1-based, but we deal with 0-based indexes.
-				// We must return ID - 1 so that when it comes back, we add 1 to get the ID again.
-				fIdx, _ := strconv.Atoi(targetFileID)
-				return c.JSON(http.StatusOK, map[string]interface{}{
-					"status":     "cached",
-					"url":        unrestricted.Download,
-					"file_id":    torrentID,
-					"file_index": fIdx - 1,
-				})
-			}
+// fileLink returns the download link paired with a file, or "" when the
+// service didn't return one for it
+func fileLink(files []providers.DebridFile, fileID int) string {
+	for _, f := range files {
+		if f.ID == fileID {
+			return f.Link
 		}
 	}
-
-	return c.JSON(http.StatusOK, map[string]string{
-		"status":  "downloading",
-		"message": fmt.Sprintf("Episode S%02dE%02d added to cloud.", req.Season, req.Episode),
-		"file_id": torrentID,
-	})
+	return ""
 }
 
-func getQualityRank(quality string) int {
-	q := strings.ToLower(quality)
-	if strings.Contains(q, "4k") || strings.Contains(q, "2160p") {
-		return 40
-	}
-	if strings.Contains(q, "1080p") {
-		return 30
-	}
-	if strings.Contains(q, "720p") {
-		return 20
-	}
-	if strings.Contains(q, "480p") {
-		return 10
-	}
-	return 0 // Unknown
+// ScrapeResponse is the /stream/scrape payload: streams plus provider and ranking metadata
+type ScrapeResponse struct {
+	*providers.ScrapeResult
+	RankingPreset string               `json:"ranking_preset"`
+	Filter        ranking.FilterReport `json:"filter"`
 }
 
-// GET /stream/scrape?external_id=imdb:tt123&type=movie&season=1&episode=1
-func ScrapeStreams(c echo.Context) error {
-	userID := c.Get("user_id").(uuid.UUID)
-	keys, err := getUserKeys(userID)
-	if err != nil {
-		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
-	}
-	if keys.RD == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "RealDebrid API key not configured"})
-	}
+// scrapeQuery holds the parsed /stream/scrape parameters
+type scrapeQuery struct {
+	MediaType string
+	ImdbID    string
+	Season    int
+	Episode   int
+	Runtime   int  // Minutes, used by size rules
+	Refresh   bool // ?refresh=true skips the scrape cache
+}
 
-	externalID := c.QueryParam("external_id") // e.g. "imdb:tt1375666"
-	mediaType := c.QueryParam("type")         // "movie" or "show"
+// parseScrapeQuery reads the scrape parameters, resolving non-IMDb IDs through TMDB or MDBList
+func parseScrapeQuery(c echo.Context, keys *UserKeys) (scrapeQuery, error) {
+	externalID := c.QueryParam("external_id")         // e.g. "imdb:tt1375666"
+	q := scrapeQuery{MediaType: c.QueryParam("type")} // "movie" or "show"
 
-	if externalID == "" || mediaType == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "missing params"})
+	if externalID == "" || q.MediaType == "" {
+		return q, fmt.Errorf("missing params")
 	}
 
 	// Parse ID
-	imdbID := externalID
+	q.ImdbID = externalID
 	isImdb := false
 	if len(externalID) > 5 && externalID[:5] == "imdb:" {
-		imdbID = externalID[5:]
+		q.ImdbID = externalID[5:]
 		isImdb = true
 	} else if len(externalID) > 2 && externalID[:2] == "tt" {
 		isImdb = true
 	}
 
-	// If NOT a clear IMDB ID, try to resolve via MDBList
-	if !isImdb && keys.MDBList != "" {
-		details, err := MdbClient.GetDetails(keys.MDBList, externalID, mediaType)
-		if err == nil && details.ImdbID != "" {
-			imdbID = details.ImdbID
-		} else {
-			// Log fallback or error?
-			// fmt.Println("Failed to resolve ID:", externalID, err)
+	// If NOT a clear IMDB ID, try to resolve it through the metadata providers (TMDB, then MDBList)
+	if !isImdb {
+		ids := metadataFor(keys).LookupIDs(c.Request().Context(), providers.ParseMediaID(externalID), q.MediaType)
+		if ids.Imdb != "" {
+			q.ImdbID = ids.Imdb
 		}
 	}
 
 	// Parse Season/Episode
-	season, _ := strconv.Atoi(c.QueryParam("season"))
-	episode, _ := strconv.Atoi(c.QueryParam("episode"))
+	q.Season, _ = strconv.Atoi(c.QueryParam("season"))
+	q.Episode, _ = strconv.Atoi(c.QueryParam("episode"))
+	q.Refresh, _ = strconv.ParseBool(c.QueryParam("refresh"))
+
+	q.Runtime, _ = strconv.Atoi(c.QueryParam("runtime"))
+	if q.Runtime <= 0 {
+		q.Runtime = ranking.DefaultMovieRuntime
+		if q.Season > 0 {
+			q.Runtime = ranking.DefaultEpisodeRuntime
+		}
+	}
 
-	// 1. Fetch Streams (Concurrent)
-	streams := ScraperManager.ScrapeAll(mediaType, imdbID, keys.RD, season, episode)
+	return q, nil
+}
 
-	if len(streams) == 0 {
-		return c.JSON(http.StatusOK, []interface{}{})
+// scrapeSettings are the active profile's ranking and filter rules (defaults without a profile)
+type scrapeSettings struct {
+	Preset  string
+	Ranking models.RankingRules
+	Filter  models.FilterRules
+}
+
+func loadScrapeSettings(c echo.Context, userID uuid.UUID) scrapeSettings {
+	rules, _ := ranking.PresetRules(ranking.DefaultPreset)
+	settings := scrapeSettings{
+		Preset:  ranking.DefaultPreset,
+		Ranking: rules,
+	}
+	if profile, err := getActiveProfile(c, userID); err == nil {
+		rp := getRankingProfile(profile.ID)
+		settings.Preset = rp.Preset
+		settings.Ranking = rp.Rules
+		settings.Filter = getStreamFilter(profile.ID).Rules
 	}
+	return settings
+}
 
-	// 3. Smart Sort: Quality > Seeds > Size
-	sort.Slice(streams, func(i, j int) bool {
-		// A. Quality Rank (4k > 1080p)
-		rankI := getQualityRank(streams[i].Quality)
-		rankJ := getQualityRank(streams[j].Quality)
-		if rankI != rankJ {
-			return rankI > rankJ
-		}
+// finishScrape filters, annotates and ranks merged scrape results
+func finishScrape(ctx context.Context, result *providers.ScrapeResult, settings scrapeSettings, q scrapeQuery, debrid providers.DebridService, token string) ScrapeResponse {
+	response := ScrapeResponse{ScrapeResult: result, RankingPreset: settings.Preset}
 
-		// B. Seeders (High availability beats low)
-		if streams[i].Seeds != streams[j].Seeds {
-			return streams[i].Seeds > streams[j].Seeds
-		}
+	// 1. Drop streams that break the profile's hard limits
+	result.Streams, response.Filter = ranking.Filter(settings.Filter, result.Streams)
+	if len(result.Streams) == 0 {
+		return response
+	}
 
-		// C. Size (Bigger usually means better bitrate within same resolution)
-		return streams[i].Size > streams[j].Size
-	})
+	// 2. Flag streams the debrid service can play instantly
+	services.AnnotateAvailability(ctx, debrid, token, result.Streams)
 
-	return c.JSON(http.StatusOK, streams)
+	// 3. Rank with the profile's rules
+	ranking.Sort(settings.Ranking, result.Streams, q.Runtime)
+
+	return response
 }
 
-func Search(c echo.Context) error {
+// GET /stream/scrape?external_id=imdb:tt123&type=movie&season=1&episode=1&runtime=45&refresh=true
+func ScrapeStreams(c echo.Context) error {
 	userID := c.Get("user_id").(uuid.UUID)
 	keys, err := getUserKeys(userID)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
-	if keys.TMDB == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "TMDB API key not configured"})
-	}
-
-	query := c.QueryParam("q")
-	if query == "" {
-		results, err := TmdbClient.GetTrending(keys.TMDB)
-		if err != nil {
-			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
-		}
-		return c.JSON(http.StatusOK, results)
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
 	}
 
-	results, err := TmdbClient.Search(keys.TMDB, query)
+	q, err := parseScrapeQuery(c, keys)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if handled, err := premiumGate(c, debrid, userID, token); handled {
+		return err
 	}
 
-	return c.JSON(http.StatusOK, results)
+	// Fetch Streams (Concurrent, bounded by the request context)
+	ctx := c.Request().Context()
+	result := ScraperManager.ScrapeAll(ctx, q.MediaType, q.ImdbID, token, q.Season, q.Episode, q.Refresh)
+
+	return c.JSON(http.StatusOK, finishScrape(ctx, result, loadScrapeSettings(c, userID), q, debrid, token))
 }
 
-func GetDetails(c echo.Context) error {
+func Search(c echo.Context) error {
 	userID := c.Get("user_id").(uuid.UUID)
 	keys, err := getUserKeys(userID)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
-	if keys.MDBList == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "MDBList API key not configured"})
-	}
-	if keys.TMDB == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "TMDB API key not configured"})
+
+	// An empty query lists what's trending
+	results, err := metadataFor(keys).SearchMedia(c.Request().Context(), c.QueryParam("q"))
+	if err != nil {
+		return metadataError(c, err)
 	}
 
-	id := c.Param("id")
-	mediaType := c.QueryParam("type")
+	return c.JSON(http.StatusOK, results)
+}
 
-	// Pass it to the client
-	details, err := MdbClient.GetDetails(keys.MDBList, id, mediaType)
+func GetDetails(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	keys, err := getUserKeys(userID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
 
-	if details.TmdbID != 0 {
-		// Determine type for TMDB call
-		tmType := "movie"
-		if mediaType == "tv" || mediaType == "show" || mediaType == "series" {
-			tmType = "tv"
-		}
-
-		// If type wasn't passed but MDBList returned "show", use that
-		if details.Type == "show" || details.Type == "series" {
-			tmType = "tv"
-		}
+	ids := providers.ParseMediaID(c.Param("id"))
+	if ids.Empty() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id format"})
+	}
 
-		logoURL, err := TmdbClient.GetLogo(keys.TMDB, details.TmdbID, tmType)
-		if err == nil {
-			details.Logo = logoURL
-		}
+	// TMDB details, with MDBList ratings merged in when the account has a key
+	details, err := metadataFor(keys).MediaDetails(c.Request().Context(), ids, c.QueryParam("type"))
+	if err != nil {
+		return metadataError(c, err)
 	}
 
 	return c.JSON(http.StatusOK, details)
@@ -350,46 +415,28 @@ func GetShowSeasons(c echo.Context) error {
 	// 1. Get Keys
 	userID := c.Get("user_id").(uuid.UUID)
 	keys, err := getUserKeys(userID)
-	if err != nil || keys.TMDB == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "TMDB API key not configured"})
-	}
-
-	// 2. Parse ID
-	idParam := c.Param("id") // Expecting TMDB ID (e.g., "85937")
-	tmdbID, err := strconv.Atoi(idParam)
 	if err != nil {
-		// If it's "tm85937", strip prefix
-		if len(idParam) > 2 && idParam[:2] == "tm" {
-			tmdbID, _ = strconv.Atoi(idParam[2:])
-		} else {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id format"})
-		}
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
 
-	// 3. Fetch Show Details (contains Season List)
-	show, err := TmdbClient.GetTVShowDetails(keys.TMDB, tmdbID)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	// 2. Parse ID, expecting a TMDB ID (e.g., "85937" or "tm85937")
+	ids := providers.ParseMediaID(c.Param("id"))
+	if ids.Empty() {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id format"})
 	}
 
-	// 4. Return just the seasons list
-	// Normalize posters here if Client doesn't do it for the summary list
-	for i := range show.Seasons {
-		if show.Seasons[i].PosterPath != "" && !strings.HasPrefix(show.Seasons[i].PosterPath, "http") {
-			show.Seasons[i].PosterPath = "https://image.tmdb.org/t/p/w500" + show.Seasons[i].PosterPath
-		}
+	// 3. Fetch the season list
+	seasons, err := metadataFor(keys).ShowSeasons(c.Request().Context(), ids)
+	if err != nil {
+		return metadataError(c, err)
 	}
 
 	// Set has next season
-	for i := range show.Seasons {
-		if i < len(show.Seasons)-1 {
-			show.Seasons[i].HasNextSeason = true
-		} else {
-			show.Seasons[i].HasNextSeason = false
-		}
+	for i := range seasons {
+		seasons[i].HasNextSeason = i < len(seasons)-1
 	}
 
-	return c.JSON(http.StatusOK, show.Seasons)
+	return c.JSON(http.StatusOK, seasons)
 }
 
 // GET /discover/tv/:id/season/:num
@@ -397,36 +444,25 @@ func GetSeasonEpisodes(c echo.Context) error {
 	// 1. Get Keys
 	userID := c.Get("user_id").(uuid.UUID)
 	keys, err := getUserKeys(userID)
-	if err != nil || keys.TMDB == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "TMDB API key not configured"})
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
 
 	// 2. Parse Params
-	idParam := c.Param("id")
-	seasonParam := c.Param("num")
-
-	tmdbID, _ := strconv.Atoi(idParam)
-	if len(idParam) > 2 && idParam[:2] == "tm" {
-		tmdbID, _ = strconv.Atoi(idParam[2:])
-	}
-
-	seasonNum, err := strconv.Atoi(seasonParam)
+	ids := providers.ParseMediaID(c.Param("id"))
+	seasonNum, err := strconv.Atoi(c.Param("num"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid season number"})
 	}
 
 	// 3. Fetch Season Details
-	season, err := TmdbClient.GetSeasonDetails(keys.TMDB, tmdbID, seasonNum)
+	season, err := metadataFor(keys).SeasonEpisodes(c.Request().Context(), ids, seasonNum)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return metadataError(c, err)
 	}
 
 	for i := range season.Episodes {
-		if i < len(season.Episodes)-1 {
-			season.Episodes[i].IsSeasonFinale = false
-		} else {
-			season.Episodes[i].IsSeasonFinale = true
-		}
+		season.Episodes[i].IsSeasonFinale = i == len(season.Episodes)-1
 	}
 
 	return c.JSON(http.StatusOK, season)
@@ -439,22 +475,41 @@ func Unrestrict(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
-	if keys.RD == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "RealDebrid API key not configured"})
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
 	}
 
 	type Request struct {
 		Link string `json:"link"`
+		// Optional: lets the result be cached for later resolves of the same file
+		Hash   string `json:"hash,omitempty"`
+		FileID int    `json:"file_id,omitempty"`
 	}
 	var req Request
 	if err := c.Bind(&req); err != nil {
 		return err
 	}
+	ctx := c.Request().Context()
 
-	link, err := RdClient.UnrestrictLink(keys.RD, req.Link)
+	if cached := services.CachedLinkBySource(ctx, userID, debrid.Name(), req.Link); cached != nil {
+		return c.JSON(http.StatusOK, providers.DebridLink{Filename: cached.Filename, Bytes: cached.Bytes, Download: cached.Download})
+	}
+
+	link, err := debrid.UnrestrictFile(ctx, token, req.Link)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
+	services.StoreLink(models.UnrestrictedLink{
+		AccountID: userID,
+		Service:   debrid.Name(),
+		Hash:      req.Hash,
+		FileID:    req.FileID,
+		Source:    req.Link,
+		Filename:  link.Filename,
+		Bytes:     link.Bytes,
+		Download:  link.Download,
+	})
 
 	return c.JSON(http.StatusOK, link)
 }
@@ -470,18 +525,36 @@ type UserKeys struct {
 	RD      string
 	TMDB    string
 	MDBList string
+
+	// The account's debrid service and its key
+	Debrid    string
+	DebridKey string
 }
 
 func getUserKeys(userID uuid.UUID) (*UserKeys, error) {
 	var acc models.Account
 	// Select only the needed columns for speed
-	err := db.DB.Select("real_debrid_key, tm_db_key, mdb_list_key").First(&acc, userID).Error
+	err := db.DB.Select("id, real_debrid_key, rd_refresh_token, rd_token_expires_at, all_debrid_key, premiumize_key, tor_box_key, debrid_service, tm_db_key, mdb_list_key").First(&acc, userID).Error
 	if err != nil {
 		return nil, err
 	}
-	return &UserKeys{
+	if services.NeedsRefresh(&acc) {
+		if token, err := RDAuth.Refresh(context.Background(), acc.ID, acc.RealDebridKey); err == nil {
+			acc.RealDebridKey = token
+		} else {
+			log.Printf("⚠️ Refreshing Real-Debrid token failed: %v", err)
+		}
+	}
+	keys := &UserKeys{
 		RD:      acc.RealDebridKey,
 		TMDB:    acc.TMDbKey,
 		MDBList: acc.MDBListKey,
-	}, nil
+	}
+	keys.Debrid, keys.DebridKey = pickDebrid(acc.DebridService, map[string]string{
+		"realdebrid": acc.RealDebridKey,
+		"alldebrid":  acc.AllDebridKey,
+		"premiumize": acc.PremiumizeKey,
+		"torbox":     acc.TorBoxKey,
+	})
+	return keys, nil
 }
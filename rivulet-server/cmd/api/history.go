@@ -5,8 +5,7 @@ import (
 	"net/http"
 	"rivulet_server/internal/db"
 	"rivulet_server/internal/models"
-	"rivulet_server/internal/providers/mdblist"
-	"rivulet_server/internal/providers/tmdb"
+	"rivulet_server/internal/providers"
 	"strconv"
 	"time"
 
@@ -250,8 +249,15 @@ func GetHistory(c echo.Context, filterSame bool) error {
 	}
 
 	var results []HistoryResult
-	cachedDetails := make(map[string]*mdblist.MediaDetail)
-	cachedSeasons := make(map[string]*tmdb.SeasonDetails)
+	cachedDetails := make(map[string]*providers.MediaDetails)
+	cachedSeasons := make(map[string]*providers.SeasonDetails)
+
+	keys, err := getUserKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "no keys found"})
+	}
+	metadata := metadataFor(keys)
+	ctx := c.Request().Context()
 
 	for _, p := range progress {
 		var res HistoryResult
@@ -268,7 +274,7 @@ func GetHistory(c echo.Context, filterSame bool) error {
 
 		// 1. Try Local DB First
 		foundLocal := false
-		var tmdbID int
+		ids := providers.MediaIDs{Imdb: p.ImdbID}
 
 		if p.Type == "movie" {
 			var movie models.Movie
@@ -286,25 +292,24 @@ func GetHistory(c echo.Context, filterSame bool) error {
 				res.SeriesName = series.Title
 				res.PosterPath = getImagePath(series.ID, "Series", "poster")
 				res.BackdropPath = getImagePath(series.ID, "Series", "backdrop")
+				// JSON numbers decode as float64
+				if f, ok := series.ExternalIDs["tmdb"].(float64); ok {
+					ids.Tmdb = int(f)
+				}
 			}
 		}
 
-		keys, err := getUserKeys(userID)
-		if err != nil {
-			return c.JSON(http.StatusForbidden, map[string]string{"error": "no keys found"})
-		}
-		_, ok := cachedDetails[p.ImdbID]
-		if !ok {
-			details, err := MdbClient.GetDetails(keys.MDBList, p.ImdbID, p.Type)
-			if err != nil {
-				return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
-			}
-			cachedDetails[p.ImdbID] = details
-		}
-		details := cachedDetails[p.ImdbID]
-
 		// 2. If Not Found Locally, Use API
 		if !foundLocal {
+			details, ok := cachedDetails[p.ImdbID]
+			if !ok {
+				var err error
+				details, err = metadata.MediaOverview(ctx, ids, p.Type)
+				if err != nil {
+					return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+				}
+				cachedDetails[p.ImdbID] = details
+			}
 			res.PosterPath = details.Poster
 			res.BackdropPath = details.Backdrop
 			if p.Type == "movie" {
@@ -312,24 +317,26 @@ func GetHistory(c echo.Context, filterSame bool) error {
 			} else {
 				res.SeriesName = details.Title
 			}
+			ids = ids.Merge(details.IDs())
 		}
 
-		tmdbID = details.TmdbID
-
-		// Fetch Episode Title via TMDB
+		// Fetch Episode Title
 		// We need to fetch the season details
-		if p.Type == "show" && tmdbID != 0 {
-			sId := fmt.Sprintf("%d:%d", tmdbID, p.SeasonNumber)
+		if p.Type == "show" {
+			sId := fmt.Sprintf("%s:%d", p.ImdbID, p.SeasonNumber)
 			_, ok := cachedSeasons[sId]
 			if !ok {
-				sesason, err := TmdbClient.GetSeasonDetails(keys.TMDB, tmdbID, p.SeasonNumber)
+				season, err := metadata.SeasonEpisodes(ctx, ids, p.SeasonNumber)
 				if err == nil {
-					cachedSeasons[sId] = sesason
+					cachedSeasons[sId] = season
 				}
 			}
 			// Current Episode
-			ep := cachedSeasons[sId].Episodes[p.EpisodeNumber-1]
-			res.Title = ep.Name
+			if season := cachedSeasons[sId]; season != nil {
+				if ep := season.Episode(p.EpisodeNumber); ep != nil {
+					res.Title = ep.Name
+				}
+			}
 		}
 		results = append(results, res)
 	}
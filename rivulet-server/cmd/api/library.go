@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"rivulet_server/internal/db"
 	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
 	"rivulet_server/internal/services"
 	"strings"
 
@@ -39,11 +40,9 @@ func AddToLibrary(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
 	}
-	if keys.MDBList == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "MDBList API key not configured"})
-	}
-	if keys.TMDB == "" {
-		return c.JSON(http.StatusConflict, map[string]string{"error": "TMDB API key not configured"})
+	metadata := metadataFor(keys)
+	if !metadata.Available(providers.MetadataDetails) {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "metadata API key not configured"})
 	}
 
 	profile, err := getActiveProfile(c, userID)
@@ -60,7 +59,7 @@ func AddToLibrary(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
 	}
 
-	err = services.AddToLibrary(MdbClient, TmdbClient, keys.MDBList, keys.TMDB, req.ExternalID, req.MediaType, profile.ID)
+	err = services.AddToLibrary(c.Request().Context(), metadata, req.ExternalID, req.MediaType, profile.ID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
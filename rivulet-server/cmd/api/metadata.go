@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"rivulet_server/internal/providers"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Metadata providers by name, and which of them answer each field
+var (
+	MetadataProviders = map[string]providers.MetadataProvider{}
+	MetadataPlan      = providers.DefaultMetadataPlan
+)
+
+// registerMetadataProviders sets up the metadata sources. The provider order per
+// field can be changed with METADATA_PLAN, e.g. "details=tmdb,mdblist;images=tmdb".
+func registerMetadataProviders() {
+	for _, p := range []providers.MetadataProvider{TmdbClient, MdbClient} {
+		MetadataProviders[p.Name()] = p
+	}
+
+	plan, err := providers.ParseMetadataPlan(os.Getenv("METADATA_PLAN"))
+	if err != nil {
+		log.Printf("⚠️ Ignoring METADATA_PLAN: %v", err)
+		return
+	}
+	MetadataPlan = plan
+}
+
+// metadataFor returns a resolver using the account's metadata keys
+func metadataFor(keys *UserKeys) *providers.MetadataResolver {
+	enabled := map[string]string{}
+	if keys.TMDB != "" {
+		enabled["tmdb"] = keys.TMDB
+	}
+	if keys.MDBList != "" {
+		enabled["mdblist"] = keys.MDBList
+	}
+	return &providers.MetadataResolver{
+		Providers: MetadataProviders,
+		Plan:      MetadataPlan,
+		Keys:      enabled,
+	}
+}
+
+// metadataError maps resolver errors to a response
+func metadataError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, providers.ErrMetadataUnavailable):
+		return c.JSON(http.StatusConflict, map[string]string{"error": "metadata API key not configured"})
+	case errors.Is(err, providers.ErrMetadataNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
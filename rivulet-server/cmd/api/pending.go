@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/services"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Pending polls torrents that were still downloading when resolved
+var Pending *services.PendingTracker
+
+// startPendingTracker creates the tracker and starts its poll loop
+func startPendingTracker() {
+	Pending = services.NewPendingTracker(func(accountID uuid.UUID) (providers.DebridService, string, error) {
+		keys, err := getUserKeys(accountID)
+		if err != nil {
+			return nil, "", err
+		}
+		return debridFor(keys)
+	})
+	go Pending.Run(context.Background())
+}
+
+// GET /stream/pending
+// The active profile's downloading and recently finished torrents
+func ListPending(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, Pending.List(profile.ID))
+}
+
+// DELETE /stream/pending/:id
+// Stops tracking a torrent and hides it from the list. The torrent stays on the debrid account.
+func DismissPending(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid id"})
+	}
+	if !Pending.Dismiss(profile.ID, id) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GET /stream/pending/events
+// Server-sent events for the active profile: a "pending" event (PendingTorrent)
+// whenever a tracked torrent makes progress, becomes ready or fails
+func PendingEvents(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	updates, cancel := Pending.Subscribe(profile.ID)
+	defer cancel()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	// Comments keep proxies from closing an idle connection
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-updates:
+			if err := writeSSE(c, "pending", entry); err != nil {
+				return nil
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"rivulet_server/internal/auth"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/services"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// PlaybackTokenRequest describes the file a playback URL should always play
+type PlaybackTokenRequest struct {
+	Magnet    string `json:"magnet"`
+	FileIndex *int   `json:"file_index,omitempty"` // From the /stream/resolve response
+	Season    int    `json:"season,omitempty"`
+	Episode   int    `json:"episode,omitempty"`
+	Title     string `json:"title,omitempty"`
+}
+
+// PlaybackTokenResponse is a stored token with its URL
+type PlaybackTokenResponse struct {
+	models.PlaybackToken
+	Token string `json:"token"`
+	URL   string `json:"url"` // Absolute, ready for an external player
+}
+
+// POST /playback-tokens
+// Creates a permanent playback URL for the active profile
+func CreatePlaybackToken(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var req PlaybackTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	hash := services.MagnetHash(req.Magnet)
+	if hash == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "magnet with an info hash is required"})
+	}
+
+	entry := models.PlaybackToken{
+		AccountID: userID,
+		ProfileID: profile.ID,
+		Hash:      hash,
+		Magnet:    req.Magnet,
+		FileIndex: req.FileIndex,
+		Season:    req.Season,
+		Episode:   req.Episode,
+		Title:     req.Title,
+	}
+	if err := db.DB.Create(&entry).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create token"})
+	}
+
+	res, err := playbackResponse(c, entry)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to sign token"})
+	}
+	return c.JSON(http.StatusCreated, res)
+}
+
+// GET /playback-tokens
+// The active profile's playback URLs
+func ListPlaybackTokens(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	var entries []models.PlaybackToken
+	db.DB.Where("profile_id = ?", profile.ID).Order("created_at DESC").Find(&entries)
+
+	out := make([]PlaybackTokenResponse, 0, len(entries))
+	for _, entry := range entries {
+		res, err := playbackResponse(c, entry)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to sign token"})
+		}
+		out = append(out, res)
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// DELETE /playback-tokens/:id
+// Revokes a playback URL
+func RevokePlaybackToken(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	res := db.DB.Where("id = ? AND profile_id = ?", c.Param("id"), profile.ID).Delete(&models.PlaybackToken{})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GET /play/:token (public, the token is the credential)
+// Resolves the file again and redirects to a fresh download link,
+// or with ?proxy=true streams it through the server
+func Play(c echo.Context) error {
+	claims, err := auth.ParsePlaybackToken(c.Param("token"))
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+	}
+
+	var entry models.PlaybackToken
+	if err := db.DB.Where("id = ? AND profile_id = ?", claims.TokenID, claims.ProfileID).First(&entry).Error; err != nil {
+		// Revoked
+		return c.JSON(http.StatusGone, map[string]string{"error": "token revoked"})
+	}
+	var profile models.Profile
+	if err := db.DB.Where("id = ? AND account_id = ?", entry.ProfileID, entry.AccountID).First(&profile).Error; err != nil {
+		return c.JSON(http.StatusGone, map[string]string{"error": "profile no longer exists"})
+	}
+
+	keys, err := getUserKeys(entry.AccountID)
+	if err != nil {
+		return c.JSON(http.StatusGone, map[string]string{"error": "account no longer exists"})
+	}
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	}
+	if handled, err := premiumGate(c, debrid, entry.AccountID, token); handled {
+		return err
+	}
+
+	r := &resolver{AccountID: entry.AccountID, Debrid: debrid, Token: token}
+	res, err := r.resolve(c.Request().Context(), ResolveRequest{
+		Magnet:    entry.Magnet,
+		Season:    entry.Season,
+		Episode:   entry.Episode,
+		FileIndex: entry.FileIndex,
+	})
+	var resErr *ResolveError
+	if errors.As(err, &resErr) {
+		return c.JSON(resErr.HTTPStatus, resErr)
+	}
+	if res.Status != "cached" {
+		// Players retry on 503 with Retry-After
+		c.Response().Header().Set("Retry-After", "30")
+		return c.JSON(http.StatusServiceUnavailable, res)
+	}
+
+	now := time.Now()
+	db.DB.Model(&entry).Update("last_used_at", &now)
+
+	// ?proxy=true streams through the server, for players that can't follow the redirect
+	if c.QueryParam("proxy") == "true" && StreamProxy != nil {
+		return proxyTo(c, res.URL, entry.AccountID, entry.ProfileID)
+	}
+	return c.Redirect(http.StatusFound, res.URL)
+}
+
+// playbackResponse signs a token and builds its absolute URL
+func playbackResponse(c echo.Context, entry models.PlaybackToken) (PlaybackTokenResponse, error) {
+	token, err := auth.GeneratePlaybackToken(entry.ID, entry.ProfileID)
+	if err != nil {
+		return PlaybackTokenResponse{}, err
+	}
+	req := c.Request()
+	return PlaybackTokenResponse{
+		PlaybackToken: entry,
+		Token:         token,
+		URL:           c.Scheme() + "://" + req.Host + "/api/v1/play/" + token,
+	}, nil
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Play-best limits, overridable per request up to the caps
+var (
+	PlayBestAttempts = 5
+	PlayBestBudget   = 45 * time.Second
+)
+
+const (
+	maxPlayBestAttempts = 20
+	maxPlayBestBudget   = 2 * time.Minute
+)
+
+// PlayAttempt is a stream play-best tried and gave up on
+type PlayAttempt struct {
+	Title  string `json:"title"`
+	Hash   string `json:"hash"`
+	Source string `json:"source"`
+	Code   string `json:"code,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// PlayBestResponse is the /stream/play-best payload
+type PlayBestResponse struct {
+	*Resolution
+	Stream   *providers.Stream `json:"stream"`
+	Attempts []PlayAttempt     `json:"attempts"` // Failed attempts, in the order they were tried
+}
+
+// POST /stream/play-best?external_id=imdb:tt123&type=tv&season=1&episode=1&attempts=5&budget=45s
+// Scrapes like /stream/scrape, then resolves the ranked cached streams in order
+// until one is playable
+func PlayBest(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	keys, err := getUserKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
+	}
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	}
+
+	q, err := parseScrapeQuery(c, keys)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if handled, err := premiumGate(c, debrid, userID, token); handled {
+		return err
+	}
+
+	attempts := PlayBestAttempts
+	if raw := c.QueryParam("attempts"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid attempts"})
+		}
+		attempts = min(n, maxPlayBestAttempts)
+	}
+	budget := PlayBestBudget
+	if raw := c.QueryParam("budget"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid budget"})
+		}
+		budget = min(d, maxPlayBestBudget)
+	}
+
+	ctx := c.Request().Context()
+	result := ScraperManager.ScrapeAll(ctx, q.MediaType, q.ImdbID, token, q.Season, q.Episode, q.Refresh)
+	ranked := finishScrape(ctx, result, loadScrapeSettings(c, userID), q, debrid, token)
+
+	// The budget covers resolving; scraping has its own timeout
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	// Only streams known to be cached are tried: resolving anything else adds a
+	// torrent the account would keep downloading after we've moved on
+	candidates := make([]*providers.Stream, 0, len(ranked.Streams))
+	for _, s := range ranked.Streams {
+		if s.Cached {
+			candidates = append(candidates, s)
+		}
+	}
+
+	r := &resolver{AccountID: userID, Debrid: debrid, Token: token}
+	failed := []PlayAttempt{}
+	for _, s := range candidates {
+		if len(failed) == attempts || ctx.Err() != nil {
+			break
+		}
+		if s.Magnet == "" {
+			continue
+		}
+
+		res, err := r.resolve(ctx, ResolveRequest{
+			Magnet:     s.Magnet,
+			Season:     q.Season,
+			Episode:    q.Episode,
+			FileIndex:  s.FileIndex,
+			ExternalID: "imdb:" + q.ImdbID,
+		})
+		if err == nil && res.Status == "cached" {
+			return c.JSON(http.StatusOK, PlayBestResponse{Resolution: res, Stream: s, Attempts: failed})
+		}
+
+		// The cache hint was stale or the torrent is unusable; don't leave it behind
+		var resErr *ResolveError
+		errors.As(err, &resErr)
+		switch {
+		case err == nil && res.added:
+			dropTorrent(debrid, userID, token, res.TorrentID)
+		case resErr != nil && resErr.added && resErr.TorrentID != "":
+			dropTorrent(debrid, userID, token, resErr.TorrentID)
+		}
+
+		attempt := PlayAttempt{Title: s.Title, Hash: s.Hash, Source: s.Source}
+		switch {
+		case resErr != nil:
+			attempt.Code, attempt.Reason = resErr.Code, resErr.Message
+			if ctx.Err() != nil {
+				attempt.Code, attempt.Reason = "timeout", "time budget ran out"
+			}
+		case err != nil:
+			attempt.Reason = err.Error()
+		default:
+			attempt.Code, attempt.Reason = "not_cached", "not cached; the debrid service is downloading it"
+		}
+		failed = append(failed, attempt)
+	}
+
+	return c.JSON(http.StatusNotFound, map[string]interface{}{
+		"error":    "no stream could be played",
+		"code":     "no_playable_stream",
+		"attempts": failed,
+	})
+}
+
+// dropTorrent deletes a torrent a resolve added but won't use
+func dropTorrent(debrid providers.DebridService, accountID uuid.UUID, token, torrentID string) {
+	remover, ok := debrid.(providers.DebridTorrentRemover)
+	if !ok {
+		return
+	}
+	// The request context may be spent by now
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := remover.DeleteTorrent(ctx, token, torrentID)
+	if err != nil && !errors.Is(err, providers.ErrDebridTorrentNotFound) {
+		log.Printf("⚠️ Failed to delete unused %s torrent %s: %v", debrid.Name(), torrentID, err)
+		return
+	}
+	services.ForgetTorrent(accountID, debrid.Name(), torrentID)
+}
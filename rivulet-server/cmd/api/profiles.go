@@ -54,4 +54,13 @@ func CreateProfile(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusCreated, profile)
-}
\ No newline at end of file
+}
+
+// Helper to load the profile from the :id route param, scoped to the account
+func getOwnedProfile(c echo.Context, accountID uuid.UUID) (models.Profile, error) {
+	var profile models.Profile
+	if err := db.DB.Where("id = ? AND account_id = ?", c.Param("id"), accountID).First(&profile).Error; err != nil {
+		return profile, fmt.Errorf("profile not found or does not belong to account")
+	}
+	return profile, nil
+}
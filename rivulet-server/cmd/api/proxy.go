@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"rivulet_server/internal/services"
+	"rivulet_server/internal/streamproxy"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// StreamProxy streams debrid links through the server; nil unless STREAM_PROXY=on
+var StreamProxy *streamproxy.Proxy
+
+// startStreamProxy sets up the proxy when STREAM_PROXY=on, since it relays
+// every byte through the server. STREAM_PROXY_MAX_STREAMS limits concurrent
+// streams per account.
+func startStreamProxy() {
+	if os.Getenv("STREAM_PROXY") != "on" {
+		return
+	}
+	maxStreams := streamproxy.DefaultMaxStreams
+	if n, err := strconv.Atoi(os.Getenv("STREAM_PROXY_MAX_STREAMS")); err == nil && n >= 0 {
+		maxStreams = n
+	}
+	StreamProxy = streamproxy.New(maxStreams)
+	StreamProxy.OnBytes = services.RecordStreamBytes
+}
+
+// GET /stream/proxy?magnet=...&season=1&episode=2&file_index=3
+// Resolves like /stream/resolve, then streams the file through the server
+// with Range support, for clients that can't reach the debrid host
+func ProxyStream(c echo.Context) error {
+	if StreamProxy == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "stream proxy is disabled"})
+	}
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	keys, err := getUserKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
+	}
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	}
+	if handled, err := premiumGate(c, debrid, userID, token); handled {
+		return err
+	}
+
+	req := ResolveRequest{Magnet: c.QueryParam("magnet")}
+	req.Season, _ = strconv.Atoi(c.QueryParam("season"))
+	req.Episode, _ = strconv.Atoi(c.QueryParam("episode"))
+	if raw := c.QueryParam("file_index"); raw != "" {
+		idx, err := strconv.Atoi(raw)
+		if err != nil || idx < 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid file_index"})
+		}
+		req.FileIndex = &idx
+	}
+	if req.Magnet == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "magnet is required"})
+	}
+
+	r := &resolver{AccountID: userID, Debrid: debrid, Token: token}
+	res, err := r.resolve(c.Request().Context(), req)
+	var resErr *ResolveError
+	if errors.As(err, &resErr) {
+		return c.JSON(resErr.HTTPStatus, resErr)
+	}
+	if res.Status != "cached" {
+		c.Response().Header().Set("Retry-After", "30")
+		return c.JSON(http.StatusServiceUnavailable, res)
+	}
+	return proxyTo(c, res.URL, userID, profile.ID)
+}
+
+// GET /stream/usage?days=30
+// Bytes the active profile streamed through the proxy, per day
+func GetStreamUsage(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getActiveProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	days, err := strconv.Atoi(c.QueryParam("days"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+
+	usage := services.StreamUsageSince(profile.ID, time.Now().AddDate(0, 0, -days+1))
+	var total int64
+	for _, u := range usage {
+		total += u.Bytes
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"total_bytes": total,
+		"days":        usage,
+	})
+}
+
+// proxyTo streams a resolved link to the client
+func proxyTo(c echo.Context, url string, accountID, profileID uuid.UUID) error {
+	err := StreamProxy.Serve(c.Response(), c.Request(), url, accountID, profileID)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, streamproxy.ErrTooManyStreams):
+		return c.JSON(http.StatusTooManyRequests, map[string]string{
+			"error": "too many streams open on this account",
+			"code":  "stream_limit",
+		})
+	case errors.Is(err, streamproxy.ErrUpstream):
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "stream failed"})
+	}
+}
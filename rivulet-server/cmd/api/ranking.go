@@ -0,0 +1,153 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/ranking"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Helper to load the ranking rules for a profile, falling back to the default preset
+func getRankingProfile(profileID uuid.UUID) models.RankingProfile {
+	var rp models.RankingProfile
+	if err := db.DB.Where("profile_id = ?", profileID).First(&rp).Error; err != nil {
+		rules, _ := ranking.PresetRules(ranking.DefaultPreset)
+		return models.RankingProfile{
+			ProfileID: profileID,
+			Preset:    ranking.DefaultPreset,
+			Rules:     rules,
+		}
+	}
+	return rp
+}
+
+// GET /ranking/presets
+func ListRankingPresets(c echo.Context) error {
+	return c.JSON(http.StatusOK, ranking.Presets)
+}
+
+// GET /profiles/:id/ranking
+func GetRankingProfile(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getOwnedProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, getRankingProfile(profile.ID))
+}
+
+// PUT /profiles/:id/ranking
+// Body: {"preset": "bandwidth_saver"} to switch preset, or {"rules": {...}} for custom rules
+func UpdateRankingProfile(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getOwnedProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	var req struct {
+		Preset string          `json:"preset"`
+		Rules  json.RawMessage `json:"rules"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+
+	rp := getRankingProfile(profile.ID)
+	switch {
+	case len(req.Rules) > 0 && string(req.Rules) != "null":
+		rules, err := parseRankingRules(req.Rules)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		rp.Preset = "custom"
+		rp.Rules = rules
+	case req.Preset != "":
+		rules, ok := ranking.PresetRules(req.Preset)
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "unknown preset"})
+		}
+		rp.Preset = req.Preset
+		rp.Rules = rules
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "preset or rules is required"})
+	}
+
+	if err := db.DB.Save(&rp).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save ranking profile"})
+	}
+
+	return c.JSON(http.StatusOK, rp)
+}
+
+// parseRankingRules decodes custom rules, rejecting fields the scorer doesn't know
+// (usually a typo that would silently leave a weight at 0) and invalid values
+func parseRankingRules(raw json.RawMessage) (models.RankingRules, error) {
+	var rules models.RankingRules
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return rules, fmt.Errorf("invalid rules: %w", err)
+	}
+	if err := ranking.ValidateRules(rules); err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+// Helper to load the filter rules for a profile; profiles without saved rules filter nothing
+func getStreamFilter(profileID uuid.UUID) models.StreamFilter {
+	var sf models.StreamFilter
+	if err := db.DB.Where("profile_id = ?", profileID).First(&sf).Error; err != nil {
+		return models.StreamFilter{ProfileID: profileID}
+	}
+	return sf
+}
+
+// GET /profiles/:id/filters
+func GetStreamFilters(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getOwnedProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, getStreamFilter(profile.ID))
+}
+
+// PUT /profiles/:id/filters
+// Body: the full FilterRules object; omitted fields are cleared
+func UpdateStreamFilters(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getOwnedProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	var rules models.FilterRules
+	if err := c.Bind(&rules); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	if rules.MinSizeMB < 0 || rules.MaxSizeMB < 0 || rules.MinSeeds < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "limits must not be negative"})
+	}
+	if rules.MaxSizeMB > 0 && rules.MinSizeMB > rules.MaxSizeMB {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "min_size_mb is larger than max_size_mb"})
+	}
+
+	sf := getStreamFilter(profile.ID)
+	sf.Rules = rules
+
+	if err := db.DB.Save(&sf).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save filters"})
+	}
+
+	return c.JSON(http.StatusOK, sf)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRankingRules(t *testing.T) {
+	rules, err := parseRankingRules([]byte(`{"preferred_resolution": "1080p", "resolution_weight": 80, "seeds_weight": 10}`))
+	if err != nil {
+		t.Fatalf("valid rules: %v", err)
+	}
+	if rules.PreferredResolution != "1080p" || rules.ResolutionWeight != 80 || rules.SeedsWeight != 10 {
+		t.Errorf("rules = %+v", rules)
+	}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"resolution_wieght": 80}`, "unknown field"},
+		{`{"seeds_weight": -1}`, "seeds_weight"},
+		{`{"cached_weight": "high"}`, "invalid rules"},
+	}
+	for _, tt := range tests {
+		if _, err := parseRankingRules([]byte(tt.body)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+}
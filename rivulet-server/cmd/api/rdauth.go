@@ -0,0 +1,87 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers/realdebrid"
+	"rivulet_server/internal/services"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// RDAuth refreshes Real-Debrid OAuth tokens
+var RDAuth *services.RealDebridAuth
+
+// POST /rd/device/start
+// Starts linking Real-Debrid: show "Go to verification_url and enter user_code",
+// then call /rd/device/poll every interval seconds
+func StartRealDebridLink(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+
+	code, err := RdClient.StartDeviceAuth(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to reach real-debrid"})
+	}
+
+	err = db.DB.Model(&models.Account{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"rd_device_code":       code.DeviceCode,
+		"rd_device_expires_at": time.Now().Add(time.Duration(code.ExpiresIn) * time.Second),
+	}).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save device code"})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"user_code":               code.UserCode,
+		"verification_url":        code.VerificationURL,
+		"direct_verification_url": code.DirectVerificationURL,
+		"interval":                code.Interval,
+		"expires_in":              code.ExpiresIn,
+	})
+}
+
+// GET /rd/device/poll
+// Status of the linking flow: "pending", "linked" or "expired"
+func PollRealDebridLink(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	ctx := c.Request().Context()
+
+	var acc models.Account
+	if err := db.DB.Select("id, rd_device_code, rd_device_expires_at").First(&acc, userID).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
+	}
+	if acc.RDDeviceCode == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no linking in progress"})
+	}
+	if time.Now().After(acc.RDDeviceExpiresAt) {
+		clearDeviceCode(userID)
+		return c.JSON(http.StatusOK, map[string]string{"status": "expired"})
+	}
+
+	creds, err := RdClient.PollDeviceCredentials(ctx, acc.RDDeviceCode)
+	if errors.Is(err, realdebrid.ErrAuthorizationPending) {
+		return c.JSON(http.StatusOK, map[string]string{"status": "pending"})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to reach real-debrid"})
+	}
+
+	token, err := RdClient.ExchangeDeviceCode(ctx, *creds, acc.RDDeviceCode)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to get real-debrid token"})
+	}
+	if err := services.SaveRealDebridToken(userID, *creds, token); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save token"})
+	}
+	clearDeviceCode(userID)
+
+	return c.JSON(http.StatusOK, map[string]string{"status": "linked"})
+}
+
+func clearDeviceCode(userID uuid.UUID) {
+	db.DB.Model(&models.Account{}).Where("id = ?", userID).Update("rd_device_code", "")
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"rivulet_server/internal/matching"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// Resolution is a magnet turned into something playable, or queued on the debrid service
+type Resolution struct {
+	Status string `json:"status"` // "cached" or "downloading"
+	URL    string `json:"url,omitempty"`
+	// The debrid torrent ID; the name is kept for older clients
+	TorrentID string `json:"file_id"`
+	// 0-based; sending it back as file_index picks the same file again
+	FileIndex *int                `json:"file_index,omitempty"`
+	Match     *matching.Candidate `json:"match,omitempty"`
+	Message   string              `json:"message,omitempty"`
+
+	hash     string
+	progress int
+	added    bool // This resolve added the torrent rather than reusing one
+}
+
+// ResolveError explains why a magnet couldn't be resolved
+type ResolveError struct {
+	HTTPStatus int              `json:"-"`
+	Message    string           `json:"error"`
+	Code       string           `json:"code,omitempty"`
+	TorrentID  string           `json:"file_id,omitempty"`
+	Match      *matching.Result `json:"match,omitempty"` // For "ambiguous_file": the candidates to choose from
+	// For "ambiguous_link": how many links couldn't be paired with a file
+	UnmappedLinks int `json:"unmapped_links,omitempty"`
+
+	added bool // The failed resolve added the torrent, so it's left on the account
+}
+
+func (e *ResolveError) Error() string {
+	return e.Message
+}
+
+// resolver resolves magnets on one account's debrid service
+type resolver struct {
+	AccountID uuid.UUID
+	Debrid    providers.DebridService
+	Token     string
+}
+
+// resolve adds (or reuses) the torrent, picks the file and unrestricts it.
+// Errors are always *ResolveError.
+func (r *resolver) resolve(ctx context.Context, req ResolveRequest) (_ *Resolution, err error) {
+	debrid, token, userID := r.Debrid, r.Token, r.AccountID
+	hash := services.MagnetHash(req.Magnet)
+
+	added := false
+	defer func() {
+		var resErr *ResolveError
+		if added && errors.As(err, &resErr) {
+			resErr.added = true
+		}
+	}()
+
+	// 0. Played recently: the link unrestricted last time still works.
+	// A file_index the client picked has to agree with it.
+	if link := services.CachedLinkFor(ctx, userID, debrid.Name(), hash, req.Season, req.Episode); link != nil &&
+		(req.FileIndex == nil || link.FileID == *req.FileIndex+1) {
+		services.TouchTorrent(userID, debrid.Name(), link.TorrentID, imdbFromExternal(req.ExternalID), req.Season, req.Episode)
+		fileIndex := link.FileID - 1
+		return &Resolution{
+			Status:    "cached",
+			URL:       link.Download,
+			TorrentID: link.TorrentID,
+			FileIndex: &fileIndex,
+			Match: &matching.Candidate{
+				FileID: link.FileID,
+				Path:   link.Path,
+				Bytes:  link.Bytes,
+				Score:  1,
+				Reason: "resolved before",
+			},
+			hash: hash,
+		}, nil
+	}
+
+	// 1. Reuse a torrent already on the account when it can serve the file
+	var info *providers.DebridTorrent
+	var match *matching.Result
+	services.ExistingTorrent(ctx, debrid, userID, token, hash, func(id string) bool {
+		existing, err := debrid.TorrentStatus(ctx, token, id)
+		if err != nil || existing.Status == providers.DebridStatusError {
+			services.ForgetTorrent(userID, debrid.Name(), id)
+			return false
+		}
+		m, err := matchFile(existing.Files, req)
+		if err != nil {
+			return false
+		}
+		// Files can still be picked, or the one we want is already selected
+		if existing.Status == providers.DebridStatusWaitingFiles || fileSelected(existing.Files, m.FileID) {
+			info, match = existing, m
+			return true
+		}
+		return false
+	})
+
+	var torrentID string
+	if info != nil {
+		torrentID = info.ID
+		log.Printf("♻️ Reusing %s torrent %s for %s", debrid.Name(), torrentID, hash)
+	} else {
+		// 2. Add Magnet
+		torrentID, err = debrid.AddTorrent(ctx, token, req.Magnet)
+		if err != nil {
+			return nil, &ResolveError{HTTPStatus: http.StatusInternalServerError, Code: "add_failed", Message: "failed adding to cloud: " + err.Error()}
+		}
+		services.RememberTorrent(userID, debrid.Name(), hash, torrentID)
+		added = true
+
+		// 3. Check Info
+		info, err = debrid.TorrentStatus(ctx, token, torrentID)
+		if err != nil {
+			return nil, &ResolveError{HTTPStatus: http.StatusInternalServerError, Code: "info_failed", Message: "failed checking info", TorrentID: torrentID}
+		}
+		if info.Status == providers.DebridStatusError {
+			return nil, &ResolveError{HTTPStatus: http.StatusBadGateway, Code: "torrent_error", Message: "the debrid service can't download this torrent", TorrentID: torrentID}
+		}
+
+		// Select Specific File (The "Stremio" Logic)
+		match, err = matchFile(info.Files, req)
+		if err != nil {
+			return nil, &ResolveError{HTTPStatus: http.StatusNotFound, Code: "no_matching_file", Message: err.Error(), TorrentID: torrentID}
+		}
+	}
+
+	// Not sure which file is the right one: let the client ask.
+	// Sending back one of the alternatives' file_id - 1 as file_index settles it.
+	if !match.Confident() {
+		return nil, &ResolveError{
+			HTTPStatus: http.StatusConflict,
+			Message:    "several files could match: " + match.Reason,
+			Code:       "ambiguous_file",
+			TorrentID:  torrentID,
+			Match:      match,
+		}
+	}
+	targetFileID := match.FileID
+
+	// 4. Send Selection to the service (If needed)
+	// If status is "waiting_files_selection", we MUST select this specific file to start the process/get the link.
+	if info.Status == providers.DebridStatusWaitingFiles {
+		err = debrid.SelectTorrentFiles(ctx, token, torrentID, []int{targetFileID})
+		if err != nil {
+			return nil, &ResolveError{HTTPStatus: http.StatusInternalServerError, Code: "select_failed", Message: "failed selecting file", TorrentID: torrentID}
+		}
+		// Refresh info to get the links
+		if refreshed, err := debrid.TorrentStatus(ctx, token, torrentID); err == nil {
+			info = refreshed
+		}
+	}
+
+	// The file we want isn't part of the download (e.g. the service picked files itself).
+	// Try to change the selection; services that only allow picking once get a fresh copy of the torrent.
+	if len(info.Files) > 0 && !fileSelected(info.Files, targetFileID) {
+		if err := debrid.SelectTorrentFiles(ctx, token, torrentID, []int{targetFileID}); err != nil {
+			log.Printf("⚠️ Re-selecting file %d on %s failed (%v), adding the torrent again", targetFileID, torrentID, err)
+			superseded := torrentID
+			torrentID, err = debrid.AddTorrent(ctx, token, req.Magnet)
+			if err != nil {
+				return nil, &ResolveError{HTTPStatus: http.StatusInternalServerError, Code: "add_failed", Message: "failed adding to cloud: " + err.Error(), TorrentID: superseded}
+			}
+			services.RememberTorrent(userID, debrid.Name(), hash, torrentID)
+			if added {
+				// Nothing else uses the copy this resolve added a moment ago
+				dropTorrent(debrid, userID, token, superseded)
+			}
+			added = true
+
+			// Files can't be picked until the service has read the magnet.
+			// If it hasn't yet, a later resolve finds the torrent waiting and picks the file then.
+			fresh, err := debrid.TorrentStatus(ctx, token, torrentID)
+			if err != nil {
+				return nil, &ResolveError{HTTPStatus: http.StatusInternalServerError, Code: "info_failed", Message: "failed checking info", TorrentID: torrentID}
+			}
+			if fresh.Status != providers.DebridStatusWaitingFiles {
+				return &Resolution{
+					Status:    "downloading",
+					Message:   "Added to cloud again to pick the right file.",
+					TorrentID: torrentID,
+					hash:      hash,
+					progress:  fresh.Progress,
+					added:     added,
+				}, nil
+			}
+			if err := debrid.SelectTorrentFiles(ctx, token, torrentID, []int{targetFileID}); err != nil {
+				return nil, &ResolveError{HTTPStatus: http.StatusInternalServerError, Code: "select_failed", Message: "failed selecting file", TorrentID: torrentID}
+			}
+		}
+		if refreshed, err := debrid.TorrentStatus(ctx, token, torrentID); err == nil {
+			info = refreshed
+		}
+	}
+
+	// Keeps the torrent out of the cleanup until it's been watched or sits unused
+	services.TouchTorrent(userID, debrid.Name(), torrentID, imdbFromExternal(req.ExternalID), req.Season, req.Episode)
+
+	// 5. Find the Unrestrict Link
+
+	if info.Status == providers.DebridStatusDownloaded {
+		// Remember the hash so future scrapes can flag it as cached
+		services.RecordCachedHash(debrid.Name(), info.Hash, info.Files)
+
+		targetLink := fileLink(info.Files, targetFileID)
+		if targetLink == "" {
+			// Never fall back to another file's link: that plays the wrong episode
+			return nil, &ResolveError{
+				HTTPStatus:    http.StatusConflict,
+				Message:       fmt.Sprintf("cannot tell which download link belongs to file %d", targetFileID),
+				Code:          "ambiguous_link",
+				TorrentID:     torrentID,
+				UnmappedLinks: info.UnmappedLinks,
+			}
+		}
+
+		var download string
+		if link := services.CachedLink(ctx, userID, debrid.Name(), hash, targetFileID); link != nil {
+			download = link.Download
+		} else if unrestricted, err := debrid.UnrestrictFile(ctx, token, targetLink); err == nil {
+			download = unrestricted.Download
+			services.StoreLink(models.UnrestrictedLink{
+				AccountID: userID,
+				Service:   debrid.Name(),
+				Hash:      hash,
+				FileID:    targetFileID,
+				TorrentID: torrentID,
+				Source:    targetLink,
+				Season:    req.Season,
+				Episode:   req.Episode,
+				Path:      match.Path,
+				Filename:  unrestricted.Filename,
+				Bytes:     match.Bytes,
+				Download:  unrestricted.Download,
+			})
+		}
+		if download != "" {
+			// File IDs are 1-based, but we deal with 0-based indexes.
+			// We must return ID - 1 so that when it comes back, we add 1 to get the ID again.
+			fileIndex := targetFileID - 1
+			return &Resolution{
+				Status:    "cached",
+				URL:       download,
+				TorrentID: torrentID,
+				FileIndex: &fileIndex,
+				Match:     &match.Candidate,
+				hash:      hash,
+			}, nil
+		}
+	}
+
+	return &Resolution{
+		Status:    "downloading",
+		Message:   fmt.Sprintf("Episode S%02dE%02d added to cloud.", req.Season, req.Episode),
+		TorrentID: torrentID,
+		hash:      hash,
+		progress:  info.Progress,
+		added:     added,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/ranking"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// ProviderEvent is pushed when a provider returns streams.
+// Streams are filtered and scored with the profile's rules but not sorted
+// across providers, and hashes already sent by an earlier provider are left out.
+type ProviderEvent struct {
+	Provider providers.ProviderStatus `json:"provider"`
+	Streams  []*providers.Stream      `json:"streams"`
+	Filtered int                      `json:"filtered"`
+}
+
+// GET /stream/scrape/events?external_id=imdb:tt123&type=movie&season=1&episode=1
+// Server-sent events variant of /stream/scrape:
+//   - "provider": a provider finished successfully (ProviderEvent)
+//   - "status":   a provider failed, timed out or was skipped (ProviderStatus)
+//   - "done":     the final sorted, deduplicated snapshot (same payload as /stream/scrape)
+func ScrapeStreamEvents(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	keys, err := getUserKeys(userID)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
+	}
+	debrid, token, err := debridFor(keys)
+	if err != nil {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Debrid API key not configured"})
+	}
+
+	q, err := parseScrapeQuery(c, keys)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if handled, err := premiumGate(c, debrid, userID, token); handled {
+		return err
+	}
+	settings := loadScrapeSettings(c, userID)
+
+	return streamScrapeEvents(c, ScraperManager, q, settings, token, func(ctx context.Context, result *providers.ScrapeResult) any {
+		return finishScrape(ctx, result, settings, q, debrid, token)
+	})
+}
+
+// streamScrapeEvents runs the scrape and writes its events; finish builds the "done" payload
+func streamScrapeEvents(c echo.Context, manager *providers.Manager, q scrapeQuery, settings scrapeSettings, token string, finish func(context.Context, *providers.ScrapeResult) any) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ctx := c.Request().Context()
+	sent := make(map[string]bool)
+
+	onProvider := func(status providers.ProviderStatus, streams []*providers.Stream) {
+		if status.Status != providers.StatusOK {
+			writeSSE(c, "status", status)
+			return
+		}
+
+		var fresh []*providers.Stream
+		for _, s := range streams {
+			if !sent[s.Hash] {
+				fresh = append(fresh, s)
+			}
+		}
+
+		// A filtered copy doesn't count as sent: another provider's may pass
+		kept, report := ranking.Filter(settings.Filter, fresh)
+		for _, s := range kept {
+			sent[s.Hash] = true
+			ranking.Score(settings.Ranking, s, q.Runtime)
+		}
+		if kept == nil {
+			kept = []*providers.Stream{}
+		}
+		writeSSE(c, "provider", ProviderEvent{Provider: status, Streams: kept, Filtered: report.Filtered})
+	}
+
+	result := manager.ScrapeProgressive(ctx, q.MediaType, q.ImdbID, token, q.Season, q.Episode, q.Refresh, onProvider)
+	if ctx.Err() != nil {
+		// Client went away
+		return nil
+	}
+
+	writeSSE(c, "done", finish(ctx, result))
+	return nil
+}
+
+// writeSSE sends one server-sent event and flushes it to the client
+func writeSSE(c echo.Context, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
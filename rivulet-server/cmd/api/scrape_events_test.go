@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/ranking"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// delayedScraper answers after a fixed delay, so tests control the order providers finish in
+type delayedScraper struct {
+	name    string
+	delay   time.Duration
+	streams []*providers.Stream
+	err     error
+}
+
+func (s *delayedScraper) Name() string { return s.name }
+
+func (s *delayedScraper) Scrape(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int) ([]*providers.Stream, error) {
+	time.Sleep(s.delay)
+	return s.streams, s.err
+}
+
+type sseEvent struct {
+	name string
+	data string
+}
+
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	for _, block := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		event, data, ok := strings.Cut(block, "\n")
+		if !ok || !strings.HasPrefix(event, "event: ") || !strings.HasPrefix(data, "data: ") {
+			t.Fatalf("malformed event %q", block)
+		}
+		events = append(events, sseEvent{strings.TrimPrefix(event, "event: "), strings.TrimPrefix(data, "data: ")})
+	}
+	return events
+}
+
+func TestScrapeEvents(t *testing.T) {
+	manager := providers.NewManager(
+		&delayedScraper{name: "Unsupported", err: providers.ErrNotSupported},
+		&delayedScraper{name: "First", delay: 20 * time.Millisecond, streams: []*providers.Stream{
+			{Title: "Inception.2010.1080p.BluRay", Hash: "aaa"},
+			{Title: "Inception.2010.CAM", Hash: "bbb"},
+		}},
+		&delayedScraper{name: "Broken", delay: 40 * time.Millisecond, err: errors.New("boom")},
+		&delayedScraper{name: "Second", delay: 60 * time.Millisecond, streams: []*providers.Stream{
+			{Title: "Inception.2010.1080p.BluRay", Hash: "aaa"},
+			{Title: "Inception.2010.WEB-DL", Hash: "bbb"},
+			{Title: "Inception.2010.2160p.WEB-DL", Hash: "ccc"},
+		}},
+	)
+	rules, _ := ranking.PresetRules(ranking.DefaultPreset)
+	settings := scrapeSettings{Ranking: rules, Filter: models.FilterRules{ExcludedKeywords: []string{"cam"}}}
+	q := scrapeQuery{MediaType: "movie", ImdbID: "tt1375666", Runtime: ranking.DefaultMovieRuntime}
+
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/stream/scrape/events", nil), rec)
+	finish := func(ctx context.Context, result *providers.ScrapeResult) any {
+		return ScrapeResponse{ScrapeResult: result}
+	}
+	if err := streamScrapeEvents(c, manager, q, settings, "token", finish); err != nil {
+		t.Fatalf("streamScrapeEvents: %v", err)
+	}
+	if ct := rec.Header().Get(echo.HeaderContentType); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+
+	events := parseSSE(t, rec.Body.String())
+	var order []string
+	for _, e := range events {
+		order = append(order, e.name)
+	}
+	if strings.Join(order, ",") != "status,provider,status,provider,done" {
+		t.Fatalf("events = %v", order)
+	}
+
+	var skipped, broken providers.ProviderStatus
+	json.Unmarshal([]byte(events[0].data), &skipped)
+	json.Unmarshal([]byte(events[2].data), &broken)
+	if skipped.Name != "Unsupported" || skipped.Status != providers.StatusSkipped {
+		t.Errorf("first status = %+v", skipped)
+	}
+	if broken.Name != "Broken" || broken.Status != providers.StatusError {
+		t.Errorf("second status = %+v", broken)
+	}
+
+	hashes := func(e ProviderEvent) string {
+		var hs []string
+		for _, s := range e.Streams {
+			hs = append(hs, s.Hash)
+			if len(s.ScoreBreakdown) == 0 {
+				t.Errorf("%s sent unscored", s.Hash)
+			}
+		}
+		return strings.Join(hs, ",")
+	}
+	var first, second ProviderEvent
+	json.Unmarshal([]byte(events[1].data), &first)
+	json.Unmarshal([]byte(events[3].data), &second)
+	if first.Provider.Name != "First" || hashes(first) != "aaa" || first.Filtered != 1 {
+		t.Errorf("First sent %q, filtered %d", hashes(first), first.Filtered)
+	}
+	// aaa was already sent; bbb was only filtered, so Second's copy still goes out
+	if second.Provider.Name != "Second" || hashes(second) != "bbb,ccc" || second.Filtered != 0 {
+		t.Errorf("Second sent %q, filtered %d", hashes(second), second.Filtered)
+	}
+
+	var done providers.ScrapeResult
+	json.Unmarshal([]byte(events[4].data), &done)
+	if len(done.Streams) != 3 || done.Responded != 2 || done.Total != 3 || len(done.Providers) != 4 {
+		t.Errorf("done: %d streams, %d of %d responded, %d providers", len(done.Streams), done.Responded, done.Total, len(done.Providers))
+	}
+}
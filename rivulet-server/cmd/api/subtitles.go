@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"regexp"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/providers/opensubtitles"
+	"rivulet_server/internal/services"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Subtitle providers by name; empty when none is configured
+var SubtitleProviders = map[string]providers.SubtitleProvider{}
+
+// registerSubtitleProviders sets up providers from the environment: OPENSUBTITLES_API_KEY
+func registerSubtitleProviders() {
+	if key := os.Getenv("OPENSUBTITLES_API_KEY"); key != "" {
+		client := opensubtitles.NewClient(key)
+		SubtitleProviders[client.Name()] = client
+	}
+}
+
+// SubtitleResult is a search result with the ID and URL GET /subtitles/:id takes
+type SubtitleResult struct {
+	providers.Subtitle
+	ID  string `json:"id"` // "<provider>-<file id>"
+	URL string `json:"url"`
+}
+
+// GET /subtitles/search?external_id=imdb:tt123&type=show&season=1&episode=2&lang=en,fr&hash=8e245d9679d31e12&release=Show.S01E02.1080p.WEB-GRP
+// Languages default to the active profile's (X-Profile-ID); hash is the OpenSubtitles
+// hash of the video file and release the stream's name, both optional
+func SearchSubtitles(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	if len(SubtitleProviders) == 0 {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "no subtitle provider configured"})
+	}
+
+	q := providers.SubtitleQuery{
+		ImdbID:    imdbFromExternal(c.QueryParam("external_id")),
+		MovieHash: c.QueryParam("hash"),
+		Release:   c.QueryParam("release"),
+	}
+	// Other IDs are looked up like /stream/scrape does
+	if q.ImdbID == "" && c.QueryParam("external_id") != "" {
+		keys, err := getUserKeys(userID)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not found"})
+		}
+		ids := providers.ParseMediaID(c.QueryParam("external_id"))
+		q.ImdbID = metadataFor(keys).LookupIDs(c.Request().Context(), ids, c.QueryParam("type")).Imdb
+	}
+	if q.ImdbID == "" && q.MovieHash == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "an IMDb external_id or hash is required"})
+	}
+	q.Season, _ = strconv.Atoi(c.QueryParam("season"))
+	q.Episode, _ = strconv.Atoi(c.QueryParam("episode"))
+
+	if raw := c.QueryParam("lang"); raw != "" {
+		q.Languages = parseLanguages(strings.Split(raw, ","))
+	} else if c.Request().Header.Get("X-Profile-ID") != "" {
+		profile, err := getActiveProfile(c, userID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		q.Languages = profile.SubtitleLanguages
+	}
+
+	sources := make([]providers.SubtitleProvider, 0, len(SubtitleProviders))
+	for _, p := range SubtitleProviders {
+		sources = append(sources, p)
+	}
+	subs := services.SearchSubtitles(c.Request().Context(), sources, q)
+
+	out := make([]SubtitleResult, 0, len(subs))
+	for _, s := range subs {
+		id := s.Provider + "-" + s.ID
+		out = append(out, SubtitleResult{Subtitle: s, ID: id, URL: "/api/v1/subtitles/" + id})
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+// GET /subtitles/:id?format=vtt
+// Serves a subtitle from search as WebVTT, or in its original format with ?format=srt.
+// Files are downloaded once and cached in the assets store.
+func GetSubtitle(c echo.Context) error {
+	name, id, _ := strings.Cut(c.Param("id"), "-")
+	provider, ok := SubtitleProviders[name]
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "unknown subtitle provider"})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "vtt"
+	}
+	if format != "vtt" && format != "srt" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "format must be vtt or srt"})
+	}
+
+	path, err := services.SubtitleFile(c.Request().Context(), provider, id, format)
+	if errors.Is(err, providers.ErrSubtitleNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+	}
+
+	if format == "vtt" {
+		c.Response().Header().Set(echo.HeaderContentType, "text/vtt; charset=utf-8")
+	} else {
+		c.Response().Header().Set(echo.HeaderContentType, "application/x-subrip; charset=utf-8")
+	}
+	return c.File(path)
+}
+
+// GET /profiles/:id/subtitles
+func GetSubtitleLanguages(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getOwnedProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	languages := profile.SubtitleLanguages
+	if languages == nil {
+		languages = []string{}
+	}
+	return c.JSON(http.StatusOK, map[string][]string{"languages": languages})
+}
+
+// PUT /profiles/:id/subtitles
+// Body: {"languages": ["en", "fr"]}, most wanted first
+func UpdateSubtitleLanguages(c echo.Context) error {
+	userID := c.Get("user_id").(uuid.UUID)
+	profile, err := getOwnedProfile(c, userID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+
+	var req struct {
+		Languages []string `json:"languages"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	}
+	languages := parseLanguages(req.Languages)
+	for _, lang := range languages {
+		if !languageCode.MatchString(lang) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "languages must be ISO 639-1 codes like \"en\" or \"pt-br\", got " + lang})
+		}
+	}
+
+	profile.SubtitleLanguages = languages
+	if err := db.DB.Save(&profile).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to save languages"})
+	}
+	return c.JSON(http.StatusOK, map[string][]string{"languages": languages})
+}
+
+// ISO 639-1, optionally with a region as OpenSubtitles uses for "pt-br" and "zh-cn"
+var languageCode = regexp.MustCompile(`^[a-z]{2}(-[a-z]{2})?$`)
+
+// parseLanguages lowercases and trims language codes, dropping blanks and duplicates
+func parseLanguages(raw []string) []string {
+	languages := []string{}
+	for _, lang := range raw {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" || slices.Contains(languages, lang) {
+			continue
+		}
+		languages = append(languages, lang)
+	}
+	return languages
+}
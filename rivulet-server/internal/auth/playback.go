@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// PlaybackClaims identify a stored playback token. They carry no expiry:
+// the token lives until its row is deleted.
+type PlaybackClaims struct {
+	TokenID   uuid.UUID `json:"tid"`
+	ProfileID uuid.UUID `json:"pid"`
+	jwt.RegisteredClaims
+}
+
+// Playback tokens are signed with their own key so they can't be used as API access tokens
+func playbackSecret() []byte {
+	sum := sha256.Sum256(append([]byte("playback:"), JwtSecret...))
+	return sum[:]
+}
+
+func GeneratePlaybackToken(tokenID, profileID uuid.UUID) (string, error) {
+	claims := &PlaybackClaims{TokenID: tokenID, ProfileID: profileID}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(playbackSecret())
+}
+
+func ParsePlaybackToken(tokenString string) (*PlaybackClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &PlaybackClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return playbackSecret(), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid playback token")
+	}
+	return token.Claims.(*PlaybackClaims), nil
+}
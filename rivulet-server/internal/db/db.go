@@ -49,6 +49,17 @@ func Connect() {
 
 		// favorites
 		&models.FavoriteTorrent{},
+
+		// streams
+		&models.RankingProfile{},
+		&models.StreamFilter{},
+		&models.DebridCachedHash{},
+		&models.ScrapeCacheEntry{},
+		&models.DebridTorrent{},
+		&models.PendingTorrent{},
+		&models.UnrestrictedLink{},
+		&models.PlaybackToken{},
+		&models.StreamUsage{},
 	)
 	if err != nil {
 		log.Fatal("❌ Migration failed:", err)
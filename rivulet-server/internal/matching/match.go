@@ -0,0 +1,285 @@
+package matching
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"rivulet_server/internal/release"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMatch is returned when no video file in the torrent fits the target
+var ErrNoMatch = errors.New("no matching file in torrent")
+
+// Below this confidence callers should ask the user instead of guessing
+const MinConfidence = 0.5
+
+// How many runner-up files are reported with a result
+const maxAlternatives = 5
+
+// File is a file inside a torrent. IDs follow the debrid service (1-based).
+type File struct {
+	ID    int
+	Path  string
+	Bytes int64
+}
+
+// Target is what the caller wants to play.
+// Episode 0 means a movie; Season 0 with an episode is a special.
+type Target struct {
+	Season   int
+	Episode  int
+	Absolute int // Absolute episode number for anime packs, 0 if unknown
+	// FileIndex is the addon's 0-based file hint (Stremio fileIdx), if any
+	FileIndex *int
+}
+
+func (t Target) IsMovie() bool {
+	return t.Episode == 0
+}
+
+// Candidate is a file that was considered, with why it scored what it did
+type Candidate struct {
+	FileID int     `json:"file_id"`
+	Path   string  `json:"path"`
+	Bytes  int64   `json:"bytes"`
+	Score  float64 `json:"score"` // 0-1
+	Reason string  `json:"reason"`
+}
+
+// Result is the chosen file plus the runner-ups
+type Result struct {
+	Candidate
+	Alternatives []Candidate `json:"alternatives,omitempty"`
+}
+
+// Confident reports whether the match is good enough to play without asking
+func (r *Result) Confident() bool {
+	return r.Score >= MinConfidence
+}
+
+var videoExtensions = []string{".mkv", ".mp4", ".avi", ".m2ts", ".ts", ".webm", ".mov", ".m4v", ".wmv", ".mpg", ".mpeg"}
+
+// Samples, trailers and bonus material, matched against every path component
+var junkRegex = regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(?:sample|trailers?|teasers?|extras?|featurettes?|bonus|behind[ ._-]the[ ._-]scenes|deleted[ ._-]scenes?|interviews?|making[ ._-]of|promos?|ncop|nced|creditless)(?:$|[ ._\-\])])`)
+
+var (
+	// "Season 2", "S02", "Staffel 2" folders
+	seasonFolderRegex = regexp.MustCompile(`(?i)^(?:.*[ ._-])?(?:season|series|staffel|saison|s)[ ._-]?(\d{1,2})(?:$|[ ._-])`)
+	// "Specials" / "Season 0" folders
+	specialsFolderRegex = regexp.MustCompile(`(?i)^(?:specials?|sp|season[ ._-]?0+)$`)
+	// "E05", "Ep 05", "Episode 5"
+	bareEpisodeRegex = regexp.MustCompile(`(?i)\b(?:e|ep|episode)[ ._-]?(\d{1,4})\b`)
+	// "05 - Title", "05.Title", "Show - 05"
+	leadingNumberRegex  = regexp.MustCompile(`^(\d{1,4})(?:[ ._]+-?[ ._]*\D|$)`)
+	trailingNumberRegex = regexp.MustCompile(`[ ._]-[ ._](\d{1,4})(?:v\d)?(?:[ ._\[(]|$)`)
+)
+
+// IsVideo reports whether the path has a playable video container
+func IsVideo(p string) bool {
+	return slices.Contains(videoExtensions, strings.ToLower(path.Ext(p)))
+}
+
+// IsJunk reports whether the path looks like a sample, trailer or extra
+func IsJunk(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if junkRegex.MatchString(strings.TrimSuffix(part, path.Ext(part))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match picks the file to play for target
+func Match(files []File, target Target) (*Result, error) {
+	var candidates []Candidate
+	for _, f := range files {
+		if !IsVideo(f.Path) {
+			continue
+		}
+		var c Candidate
+		if target.IsMovie() {
+			c = scoreMovie(f, files, target)
+		} else {
+			c = scoreEpisode(f, target)
+		}
+		candidates = append(candidates, c)
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if hinted(candidates[i].FileID, target) != hinted(candidates[j].FileID, target) {
+			return hinted(candidates[i].FileID, target)
+		}
+		return candidates[i].Bytes > candidates[j].Bytes
+	})
+
+	best := candidates[0]
+	if best.Score <= 0 {
+		if target.IsMovie() {
+			return nil, ErrNoMatch
+		}
+		return nil, fmt.Errorf("%w: episode S%02dE%02d", ErrNoMatch, target.Season, target.Episode)
+	}
+
+	// A near tie between two different files means we can't be sure,
+	// unless the addon (or the user, answering an earlier ambiguity) pointed at one
+	if len(candidates) > 1 && candidates[1].Score > 0 && best.Score-candidates[1].Score < 0.05 && !hinted(best.FileID, target) {
+		best.Score = min(best.Score, MinConfidence-0.1)
+		best.Reason += "; tied with " + path.Base(candidates[1].Path)
+	}
+
+	result := &Result{Candidate: best}
+	for _, c := range candidates[1:] {
+		if len(result.Alternatives) == maxAlternatives {
+			break
+		}
+		result.Alternatives = append(result.Alternatives, c)
+	}
+	return result, nil
+}
+
+// hinted reports whether the addon's file hint points at the file with this ID
+func hinted(fileID int, target Target) bool {
+	return target.FileIndex != nil && fileID == *target.FileIndex+1
+}
+
+func scoreMovie(f File, all []File, target Target) Candidate {
+	c := Candidate{FileID: f.ID, Path: f.Path, Bytes: f.Bytes}
+	if IsJunk(f.Path) {
+		c.Reason = "sample or extra"
+		return c
+	}
+
+	// Size relative to the largest real video decides between cuts/parts
+	var largest, runnerUp int64
+	for _, other := range all {
+		if !IsVideo(other.Path) || IsJunk(other.Path) {
+			continue
+		}
+		if other.Bytes > largest {
+			largest, runnerUp = other.Bytes, largest
+		} else if other.Bytes > runnerUp {
+			runnerUp = other.Bytes
+		}
+	}
+
+	switch {
+	case f.Bytes == largest && (runnerUp == 0 || largest >= 3*runnerUp):
+		c.Score, c.Reason = 1, "largest video file"
+	case f.Bytes == largest:
+		c.Score, c.Reason = 0.6, "largest video file, but others are similar in size"
+	case largest > 0:
+		c.Score = 0.5 * float64(f.Bytes) / float64(largest)
+		c.Reason = "smaller video file"
+	}
+
+	if hinted(f.ID, target) {
+		c.Score = max(c.Score, 0.95)
+		c.Reason = "addon file hint, " + c.Reason
+	}
+	return c
+}
+
+// Episodes reads the season and episode numbers a file's path names.
+// seasons is empty for absolute numbering.
+func Episodes(p string) (seasons, episodes []int) {
+	seasons, episodes, _, _ = fileEpisodes(p)
+	return seasons, episodes
+}
+
+// fileEpisodes reads season and episode numbers from a path.
+// The file name wins; folders only fill in a missing season.
+func fileEpisodes(p string) (seasons, episodes []int, seasonFromFolder, absolute bool) {
+	dir, base := path.Split(p)
+	name := strings.TrimSuffix(base, path.Ext(base))
+
+	info := release.Parse(name)
+	seasons, episodes = info.Seasons, info.Episodes
+
+	if len(episodes) == 0 {
+		clean := strings.ReplaceAll(name, "_", " ")
+		for _, re := range []*regexp.Regexp{bareEpisodeRegex, leadingNumberRegex, trailingNumberRegex} {
+			if m := re.FindStringSubmatch(clean); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				// Years and resolutions aren't episode numbers
+				if n > 0 && n < 1900 && n != 480 && n != 720 && n != 1080 {
+					episodes = []int{n}
+					break
+				}
+			}
+		}
+	}
+
+	if len(seasons) == 0 && len(episodes) > 0 {
+		// Innermost folder first
+		folders := strings.Split(strings.Trim(dir, "/"), "/")
+		for i := len(folders) - 1; i >= 0; i-- {
+			folder := strings.ReplaceAll(folders[i], "_", " ")
+			if specialsFolderRegex.MatchString(folder) {
+				seasons, seasonFromFolder = []int{0}, true
+				break
+			}
+			if m := seasonFolderRegex.FindStringSubmatch(folder); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				seasons, seasonFromFolder = []int{n}, true
+				break
+			}
+		}
+		absolute = len(seasons) == 0
+	}
+	return seasons, episodes, seasonFromFolder, absolute
+}
+
+func scoreEpisode(f File, target Target) Candidate {
+	c := Candidate{FileID: f.ID, Path: f.Path, Bytes: f.Bytes}
+	if IsJunk(f.Path) {
+		c.Reason = "sample or extra"
+		return c
+	}
+
+	seasons, episodes, fromFolder, absolute := fileEpisodes(f.Path)
+	seasonOK := slices.Contains(seasons, target.Season)
+	episodeOK := slices.Contains(episodes, target.Episode)
+
+	switch {
+	case seasonOK && episodeOK && len(episodes) == 1 && !fromFolder:
+		c.Score, c.Reason = 1, fmt.Sprintf("S%02dE%02d in file name", target.Season, target.Episode)
+	case seasonOK && episodeOK && len(episodes) == 1:
+		c.Score, c.Reason = 0.9, fmt.Sprintf("E%02d in file name, season %d from folder", target.Episode, target.Season)
+	case seasonOK && episodeOK:
+		c.Score, c.Reason = 0.85, fmt.Sprintf("multi-episode file covering E%02d", target.Episode)
+	case absolute && target.Absolute > 0 && slices.Contains(episodes, target.Absolute):
+		c.Score, c.Reason = 0.85, fmt.Sprintf("absolute episode %d", target.Absolute)
+	case absolute && episodeOK && target.Season <= 1:
+		// "[Group] Show - 05" in a single-season pack
+		c.Score, c.Reason = 0.6, fmt.Sprintf("episode %d without a season", target.Episode)
+	case len(seasons) > 0 && !seasonOK:
+		c.Reason = fmt.Sprintf("wrong season (%v)", seasons)
+	case len(episodes) > 0 && !episodeOK:
+		c.Reason = fmt.Sprintf("wrong episode (%v)", episodes)
+	default:
+		c.Reason = "no episode number"
+	}
+
+	if hinted(f.ID, target) {
+		if c.Score == 0 && len(episodes) == 0 {
+			// Unnamed file, but the addon says it's the one
+			c.Score, c.Reason = 0.7, "addon file hint"
+		} else if c.Score > 0 {
+			c.Score = min(1, c.Score+0.05)
+			c.Reason += ", addon file hint"
+		}
+	}
+	return c
+}
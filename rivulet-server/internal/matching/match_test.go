@@ -0,0 +1,170 @@
+package matching
+
+import "testing"
+
+func intPtr(i int) *int { return &i }
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     []File
+		target    Target
+		want      int // Expected file ID, 0 for ErrNoMatch
+		confident bool
+	}{
+		{
+			name: "movie skips sample and extras",
+			files: []File{
+				{1, "Movie.2020.1080p/Sample/movie-sample.mkv", 50 << 20},
+				{2, "Movie.2020.1080p/Movie.2020.1080p.BluRay.x264.mkv", 8 << 30},
+				{3, "Movie.2020.1080p/Extras/Behind.the.Scenes.mkv", 1 << 30},
+				{4, "Movie.2020.1080p/Movie.2020.1080p.nfo", 1 << 10},
+			},
+			target:    Target{},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "movie in m2ts",
+			files: []File{
+				{1, "BDMV/STREAM/00001.m2ts", 30 << 30},
+				{2, "BDMV/STREAM/00002.m2ts", 200 << 20},
+			},
+			target:    Target{},
+			want:      1,
+			confident: true,
+		},
+		{
+			name: "episode in season pack",
+			files: []File{
+				{1, "Show.S02.1080p/Show.S02E01.1080p.WEB.mkv", 1 << 30},
+				{2, "Show.S02.1080p/Show.S02E02.1080p.WEB.mkv", 1 << 30},
+				{3, "Show.S02.1080p/Show.S02E03.1080p.WEB.mkv", 1 << 30},
+			},
+			target:    Target{Season: 2, Episode: 2},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "special",
+			files: []File{
+				{1, "Show/Season 01/Show.S01E01.mkv", 1 << 30},
+				{2, "Show/Specials/Show.S00E01.Christmas.Special.mkv", 1 << 30},
+			},
+			target:    Target{Season: 0, Episode: 1},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "multi-episode file",
+			files: []File{
+				{1, "Show.S01E01-E02.Pilot.mkv", 2 << 30},
+				{2, "Show.S01E03.mkv", 1 << 30},
+			},
+			target:    Target{Season: 1, Episode: 2},
+			want:      1,
+			confident: true,
+		},
+		{
+			name: "1x01 naming",
+			files: []File{
+				{1, "Show 1x01 Pilot.avi", 700 << 20},
+				{2, "Show 1x02 Second.avi", 700 << 20},
+			},
+			target:    Target{Season: 1, Episode: 2},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "nested season folders with bare episode names",
+			files: []File{
+				{1, "Show/Season 1/01 - Pilot.mkv", 1 << 30},
+				{2, "Show/Season 2/01 - Return.mkv", 1 << 30},
+				{3, "Show/Season 2/02 - Fallout.mkv", 1 << 30},
+			},
+			target:    Target{Season: 2, Episode: 1},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "absolute anime numbering",
+			files: []File{
+				{1, "[SubsPlease] One Piece - 1070 (1080p) [ABCD].mkv", 1 << 30},
+				{2, "[SubsPlease] One Piece - 1071 (1080p) [EF01].mkv", 1 << 30},
+			},
+			target:    Target{Season: 21, Episode: 179, Absolute: 1071},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "webm and mov containers",
+			files: []File{
+				{1, "Show.S01E01.webm", 300 << 20},
+				{2, "Show.S01E02.mov", 300 << 20},
+			},
+			target:    Target{Season: 1, Episode: 2},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "addon hint for unnamed files",
+			files: []File{
+				{1, "Disc/track1.mkv", 1 << 30},
+				{2, "Disc/track2.mkv", 1 << 30},
+			},
+			target:    Target{Season: 1, Episode: 2, FileIndex: intPtr(1)},
+			want:      2,
+			confident: true,
+		},
+		{
+			name: "duplicate episode is not confident",
+			files: []File{
+				{1, "Show.S01E01.720p.mkv", 1 << 30},
+				{2, "Show.S01E01.1080p.mkv", 2 << 30},
+			},
+			target:    Target{Season: 1, Episode: 1},
+			want:      2,
+			confident: false,
+		},
+		{
+			name: "hint settles a duplicate episode",
+			files: []File{
+				{1, "Show.S01E01.720p.mkv", 1 << 30},
+				{2, "Show.S01E01.1080p.mkv", 2 << 30},
+			},
+			target:    Target{Season: 1, Episode: 1, FileIndex: intPtr(0)},
+			want:      1,
+			confident: true,
+		},
+		{
+			name: "episode missing",
+			files: []File{
+				{1, "Show.S01E01.mkv", 1 << 30},
+				{2, "Show.S01E01.sample.mkv", 1 << 20},
+			},
+			target: Target{Season: 1, Episode: 5},
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.files, tt.target)
+			if tt.want == 0 {
+				if err == nil {
+					t.Fatalf("expected no match, got file %d (%s)", got.FileID, got.Reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.FileID != tt.want {
+				t.Errorf("got file %d (%s, %.2f), want %d", got.FileID, got.Reason, got.Score, tt.want)
+			}
+			if got.Confident() != tt.confident {
+				t.Errorf("confident = %v (%.2f, %s), want %v", got.Confident(), got.Score, got.Reason, tt.confident)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CachedFile mirrors a debrid torrent file entry
+type CachedFile struct {
+	ID    int    `json:"id"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// DebridCachedHash remembers hashes we've seen fully downloaded on a debrid service.
+// Debrid caches are shared between users, so this isn't scoped to an account.
+type DebridCachedHash struct {
+	Base
+	Service    string       `gorm:"uniqueIndex:idx_debrid_cached_hash;not null"` // "realdebrid", "alldebrid", "premiumize", "torbox"
+	Hash       string       `gorm:"uniqueIndex:idx_debrid_cached_hash;not null"` // Lowercase info hash
+	Files      []CachedFile `gorm:"type:jsonb;serializer:json"`
+	LastSeenAt time.Time
+}
+
+// ScrapeCacheEntry is one provider's scrape result, stored when SCRAPE_CACHE_STORE=postgres
+type ScrapeCacheEntry struct {
+	Base
+	Key      string `gorm:"uniqueIndex;not null"` // provider|imdb_id|season|episode
+	Streams  []byte `gorm:"type:jsonb"`
+	StoredAt time.Time
+}
+
+// DebridTorrent records a torrent Rivulet added to an account's debrid cloud,
+// so the next resolve of the same hash reuses it instead of adding it again.
+// Only torrents listed here are ever deleted by the cleanup job.
+type DebridTorrent struct {
+	Base
+	AccountID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_debrid_torrent;not null"`
+	Service   string    `gorm:"uniqueIndex:idx_debrid_torrent;not null"`
+	TorrentID string    `gorm:"uniqueIndex:idx_debrid_torrent;not null"` // The service's torrent ID
+	Hash      string    `gorm:"index;not null"`                          // Lowercase info hash
+
+	// What was played from it, so it can go once watched
+	ImdbID     string       `gorm:"index"`
+	Episodes   []EpisodeRef `gorm:"type:jsonb;serializer:json"` // Empty for movies
+	LastUsedAt time.Time
+}
+
+// EpisodeRef identifies one episode of a series
+type EpisodeRef struct {
+	Season  int `json:"season"`
+	Episode int `json:"episode"`
+}
+
+// Pending download states
+const (
+	PendingDownloading = "downloading"
+	PendingReady       = "ready"
+	PendingError       = "error"
+)
+
+// PendingTorrent is a resolve that came back "downloading", polled until the
+// debrid service finishes so the profile can be told it's ready to play
+type PendingTorrent struct {
+	Base
+	AccountID uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	ProfileID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_pending_torrent;not null" json:"profile_id"`
+	Service   string    `gorm:"uniqueIndex:idx_pending_torrent;not null" json:"service"`
+	TorrentID string    `gorm:"uniqueIndex:idx_pending_torrent;not null" json:"torrent_id"`
+	Hash      string    `json:"hash"`
+
+	// What the user asked to play, echoed back so the app can resolve again
+	Magnet     string `json:"magnet"`
+	ExternalID string `json:"external_id,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Season     int    `json:"season,omitempty"`
+	Episode    int    `json:"episode,omitempty"`
+	FileIndex  *int   `json:"file_index,omitempty"`
+
+	Status   string     `gorm:"index;not null" json:"status"` // "downloading", "ready", "error"
+	Progress int        `json:"progress"`
+	Error    string     `json:"error,omitempty"`
+	ReadyAt  *time.Time `json:"ready_at,omitempty"`
+
+	// Polling backoff
+	Attempts    int       `json:"-"`
+	NextCheckAt time.Time `gorm:"index" json:"-"`
+}
+
+// UnrestrictedLink caches a direct download URL for one file of a torrent.
+// Scoped to the account: download URLs are tied to the account that unrestricted them.
+type UnrestrictedLink struct {
+	Base
+	AccountID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_unrestricted_file,where:hash <> '';uniqueIndex:idx_unrestricted_source,where:hash = '';not null"`
+	Service   string    `gorm:"uniqueIndex:idx_unrestricted_file;uniqueIndex:idx_unrestricted_source;not null"`
+	// Lowercase info hash. Empty for links unrestricted without one, which are keyed by Source instead.
+	Hash      string `gorm:"uniqueIndex:idx_unrestricted_file;not null"`
+	FileID    int    `gorm:"uniqueIndex:idx_unrestricted_file;not null"` // 1-based, as the debrid service numbers them
+	TorrentID string
+	Source    string `gorm:"index;uniqueIndex:idx_unrestricted_source"` // The DebridFile.Link that was unrestricted
+
+	// What it was resolved for, so repeat plays can skip matching
+	Season  int
+	Episode int
+	Path    string
+
+	Filename  string
+	Bytes     int64
+	Download  string
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// PlaybackToken is a long-lived playback URL for one file of a torrent.
+// Every use resolves the file again, so the URL outlives debrid download links.
+// Deleting the row revokes it.
+type PlaybackToken struct {
+	Base
+	AccountID uuid.UUID `gorm:"type:uuid;index;not null" json:"-"`
+	ProfileID uuid.UUID `gorm:"type:uuid;index;not null" json:"profile_id"`
+	Hash      string    `gorm:"not null" json:"hash"`
+	Magnet    string    `gorm:"not null" json:"magnet"` // To add the torrent again if it was removed
+	FileIndex *int      `json:"file_index,omitempty"`   // 0-based, as returned by /stream/resolve
+	Season    int       `json:"season,omitempty"`
+	Episode   int       `json:"episode,omitempty"`
+	Title     string    `json:"title,omitempty"`
+
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// StreamUsage counts the bytes a profile streamed through the server's proxy per day
+type StreamUsage struct {
+	Base
+	ProfileID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_stream_usage;not null" json:"profile_id"`
+	Day       time.Time `gorm:"type:date;uniqueIndex:idx_stream_usage;not null" json:"day"`
+	Bytes     int64     `gorm:"not null;default:0" json:"bytes"`
+}
@@ -24,39 +24,42 @@ type Movie struct {
 
 type Series struct {
 	Base
-	ProfileID     uuid.UUID `gorm:"type:uuid;index;not null"`
-	Title         string    `gorm:"index;not null"`
-	Overview      string    `gorm:"type:text"`
-	Status        string
-	ContentRating string
-	ExternalIDs   map[string]any `gorm:"type:jsonb;serializer:json"`
-	Seasons       []Season
-	Images        []Image  `gorm:"polymorphic:Owner;"`
-	Credits       []Credit `gorm:"polymorphic:Media;"`
+	ProfileID      uuid.UUID `gorm:"type:uuid;index;not null"`
+	Title          string    `gorm:"index;not null"`
+	Overview       string    `gorm:"type:text"`
+	Status         string
+	ContentRating  string
+	ExternalIDs    map[string]any `gorm:"type:jsonb;serializer:json"`
+	MetadataSource string         `gorm:"default:'manual'"`
+	Seasons        []Season
+	Images         []Image  `gorm:"polymorphic:Owner;"`
+	Credits        []Credit `gorm:"polymorphic:Media;"`
 }
 
 type Season struct {
 	Base
-	SeriesID     uuid.UUID `gorm:"type:uuid;index;not null"`
-	SeasonNumber int       `gorm:"not null"`
-	Title        string
-	Overview     string         `gorm:"type:text"`
-	ExternalIDs  map[string]any `gorm:"type:jsonb;serializer:json"`
-	Episodes     []Episode
-	Images       []Image `gorm:"polymorphic:Owner;"`
+	SeriesID       uuid.UUID `gorm:"type:uuid;index;not null"`
+	SeasonNumber   int       `gorm:"not null"`
+	Title          string
+	Overview       string         `gorm:"type:text"`
+	ExternalIDs    map[string]any `gorm:"type:jsonb;serializer:json"`
+	MetadataSource string         `gorm:"default:'manual'"`
+	Episodes       []Episode
+	Images         []Image `gorm:"polymorphic:Owner;"`
 }
 
 type Episode struct {
 	Base
-	SeriesID      uuid.UUID `gorm:"type:uuid;index;not null"`
-	SeasonID      uuid.UUID `gorm:"type:uuid;index;not null"`
-	EpisodeNumber int       `gorm:"not null"`
-	Title         string
-	Overview      string `gorm:"type:text"`
-	AirDate       *time.Time
-	Runtime       int
-	ExternalIDs   map[string]any `gorm:"type:jsonb;serializer:json"`
-	StillImage    Image          `gorm:"polymorphic:Owner;"`
+	SeriesID       uuid.UUID `gorm:"type:uuid;index;not null"`
+	SeasonID       uuid.UUID `gorm:"type:uuid;index;not null"`
+	EpisodeNumber  int       `gorm:"not null"`
+	Title          string
+	Overview       string `gorm:"type:text"`
+	AirDate        *time.Time
+	Runtime        int
+	ExternalIDs    map[string]any `gorm:"type:jsonb;serializer:json"`
+	MetadataSource string         `gorm:"default:'manual'"`
+	StillImage     Image          `gorm:"polymorphic:Owner;"`
 }
 
 // --- People & Credits ---
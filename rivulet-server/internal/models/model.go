@@ -21,9 +21,33 @@ type Account struct {
 
 	// API Keys
 	RealDebridKey string 
+	AllDebridKey  string
+	PremiumizeKey string
+	TorBoxKey     string
 	TMDbKey       string 
 	MDBListKey    string
 
+	// Debrid service used to resolve streams: "realdebrid", "alldebrid", "premiumize", "torbox".
+	// Empty means the first one with a key.
+	DebridService string
+
+	// Real-Debrid linked through the device flow: RealDebridKey holds the
+	// OAuth access token, refreshed with these before it expires
+	RDClientID       string    `json:"-"`
+	RDClientSecret   string    `json:"-"`
+	RDRefreshToken   string    `json:"-"`
+	RDTokenExpiresAt time.Time `json:"-"`
+	// The access token before the last refresh, so requests still using it find the account
+	RDPreviousKey string `gorm:"index" json:"-"`
+	// Device code of a linking flow in progress
+	RDDeviceCode      string    `json:"-"`
+	RDDeviceExpiresAt time.Time `json:"-"`
+
+	// Cleanup of torrents Rivulet added to the debrid account:
+	// days since last use before they're deleted (0 = keep), and whether watched titles go right away
+	TorrentRetentionDays  int  `gorm:"default:14"`
+	DeleteWatchedTorrents bool `gorm:"default:true"`
+
 	// OTP Logic (Stored in DB for simplicity)
 	CurrentOtp   string    `json:"-"`
 	OtpExpiresAt time.Time `json:"-"`
@@ -34,4 +58,6 @@ type Profile struct {
 	AccountID uuid.UUID `gorm:"type:uuid;not null"`
 	Name      string    `gorm:"not null"`
 	Avatar    string
+	// Preferred subtitle languages (ISO 639-1), most wanted first
+	SubtitleLanguages []string `gorm:"type:jsonb;serializer:json"`
 }
\ No newline at end of file
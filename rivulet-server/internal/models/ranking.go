@@ -0,0 +1,72 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// RankingRules is a weighted rule set used to order scraped streams.
+// Each rule contributes points scaled by its weight; a zero weight disables it.
+type RankingRules struct {
+	// Resolution: "2160p", "1080p", "720p", "480p"
+	PreferredResolution string  `json:"preferred_resolution"`
+	MaxResolution       string  `json:"max_resolution,omitempty"` // Streams above this sink to the bottom
+	ResolutionWeight    float64 `json:"resolution_weight"`
+
+	// Codecs in order of preference, e.g. ["HEVC", "AVC"]
+	PreferredCodecs []string `json:"preferred_codecs,omitempty"`
+	CodecWeight     float64  `json:"codec_weight"`
+
+	// HDR: "prefer", "avoid" or "" (don't care)
+	HDR       string  `json:"hdr,omitempty"`
+	HDRWeight float64 `json:"hdr_weight"`
+
+	// Size window in MB per minute of runtime (0 = unbounded)
+	MinMBPerMinute float64 `json:"min_mb_per_minute,omitempty"`
+	MaxMBPerMinute float64 `json:"max_mb_per_minute,omitempty"`
+	SizeWeight     float64 `json:"size_weight"`
+
+	// Case-insensitive matches against the stream title
+	RequiredKeywords  []string `json:"required_keywords,omitempty"`
+	ForbiddenKeywords []string `json:"forbidden_keywords,omitempty"`
+
+	SeedsWeight float64 `json:"seeds_weight"`
+
+	// Bonus for streams the debrid service can play instantly
+	CachedWeight float64 `json:"cached_weight"`
+
+	// Season/series packs: "prefer" (binge watching), "avoid" or "" (don't care)
+	Packs      string  `json:"packs,omitempty"`
+	PackWeight float64 `json:"pack_weight"`
+}
+
+// RankingProfile stores the ranking rules a profile sorts streams with
+type RankingProfile struct {
+	Base
+	ProfileID uuid.UUID    `gorm:"type:uuid;uniqueIndex;not null" json:"profile_id"`
+	Preset    string       `json:"preset"` // Built-in preset the rules started from, "custom" once edited
+	Rules     RankingRules `gorm:"type:jsonb;serializer:json" json:"rules"`
+}
+
+// FilterRules are hard limits; streams that break any rule are dropped before ranking
+type FilterRules struct {
+	MinSizeMB int64 `json:"min_size_mb,omitempty"`
+	MaxSizeMB int64 `json:"max_size_mb,omitempty"`
+	MinSeeds  int   `json:"min_seeds,omitempty"` // Streams with an unknown seed count are kept
+
+	// Parsed release sources to drop, e.g. ["CAM", "TS", "SCR"]
+	ExcludedSources []string `json:"excluded_sources,omitempty"`
+	// Case-insensitive matches against the stream title
+	ExcludedKeywords []string `json:"excluded_keywords,omitempty"`
+	ExcludedGroups   []string `json:"excluded_groups,omitempty"`
+
+	// Keep only streams with one of these audio languages (ISO 639-1).
+	// Streams without language tags are kept, since most English releases don't carry one.
+	RequiredLanguages []string `json:"required_languages,omitempty"`
+}
+
+// StreamFilter stores the filter rules applied to a profile's scrape results
+type StreamFilter struct {
+	Base
+	ProfileID uuid.UUID   `gorm:"type:uuid;uniqueIndex;not null" json:"profile_id"`
+	Rules     FilterRules `gorm:"type:jsonb;serializer:json" json:"rules"`
+}
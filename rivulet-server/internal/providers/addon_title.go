@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Addon Stream Titles ---
+// Torrentio and most Stremio addons that copy it pack the metadata into the
+// stream title with emoji markers: "Name\n👤 123 💾 1.2 GB ⚙️ Source".
+
+// Extract size: "💾 1.2 GB"
+var addonSizeRegex = regexp.MustCompile(`💾\s*(\d+(?:\.\d+)?)\s*(TB|GB|MB)`)
+
+// Extract seeds: "👤 123"
+var addonSeedsRegex = regexp.MustCompile(`👤\s*(\d+)`)
+
+// Extract resolution: "4k", "2160p", "1080p"
+var addonResRegex = regexp.MustCompile(`(?i)\b(2160p|4k|1080p|720p|480p)\b`)
+
+// ParseAddonTitle extracts size (bytes), seeds and quality from an addon stream title
+func ParseAddonTitle(rawTitle string) (int64, int, string) {
+	var bytes int64
+	sizeMatch := addonSizeRegex.FindStringSubmatch(rawTitle)
+	if len(sizeMatch) >= 3 {
+		val, _ := strconv.ParseFloat(sizeMatch[1], 64)
+		switch sizeMatch[2] {
+		case "TB":
+			bytes = int64(val * 1024 * 1024 * 1024 * 1024)
+		case "GB":
+			bytes = int64(val * 1024 * 1024 * 1024)
+		case "MB":
+			bytes = int64(val * 1024 * 1024)
+		}
+	}
+
+	seeds := 0
+	seedMatch := addonSeedsRegex.FindStringSubmatch(rawTitle)
+	if len(seedMatch) >= 2 {
+		seeds, _ = strconv.Atoi(seedMatch[1])
+	}
+
+	quality := "Unknown"
+	resMatch := addonResRegex.FindStringSubmatch(rawTitle)
+	if len(resMatch) >= 2 {
+		quality = strings.ToLower(resMatch[1])
+		if quality == "2160p" {
+			quality = "4k"
+		}
+	}
+
+	return bytes, seeds, quality
+}
@@ -0,0 +1,23 @@
+package providers
+
+import "testing"
+
+func TestParseAddonTitle(t *testing.T) {
+	tests := []struct {
+		title   string
+		size    int64
+		seeds   int
+		quality string
+	}{
+		{"Movie.2023.2160p.WEB-DL\n👤 42 💾 1.5 GB ⚙️ ThePirateBay", 1610612736, 42, "4k"},
+		{"Movie.2023.1080p.BluRay\n👤 7 💾 700 MB", 734003200, 7, "1080p"},
+		{"Show.S01.COMPLETE.4K\n💾1.2TB 👤3", 1319413953331, 3, "4k"},
+		{"Movie.2023.DVDRip", 0, 0, "Unknown"},
+	}
+	for _, tt := range tests {
+		size, seeds, quality := ParseAddonTitle(tt.title)
+		if size != tt.size || seeds != tt.seeds || quality != tt.quality {
+			t.Errorf("ParseAddonTitle(%q) = %d, %d, %q; want %d, %d, %q", tt.title, size, seeds, quality, tt.size, tt.seeds, tt.quality)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package alldebrid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Agent identifies the app to AllDebrid; every request must carry it
+const Agent = "rivulet"
+
+type Client struct {
+	BaseURL    string
+	HttpClient *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{
+		BaseURL: "https://api.alldebrid.com/v4",
+		HttpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+var _ providers.DebridService = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "alldebrid"
+}
+
+// --- Models ---
+
+// envelope wraps every AllDebrid response
+type envelope struct {
+	Status string          `json:"status"` // "success" or "error"
+	Data   json.RawMessage `json:"data"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// apiError is an error AllDebrid reported in the envelope
+type apiError struct {
+	Endpoint string
+	Code     string // e.g. "MAGNET_INVALID_ID"
+	Message  string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("AllDebrid %s: %s (%s)", e.Endpoint, e.Message, e.Code)
+}
+
+type Magnet struct {
+	ID         int    `json:"id"`
+	Hash       string `json:"hash"`
+	Filename   string `json:"filename"`
+	Name       string `json:"name"` // Upload responses use "name" instead of "filename"
+	Size       int64  `json:"size"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"statusCode"` // 0-3 processing, 4 ready, 5+ error
+	Downloaded int64  `json:"downloaded"`
+	Links      []Link `json:"links"`
+}
+
+type Link struct {
+	Link     string `json:"link"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// FileNode is an entry in AllDebrid's file tree; folders carry children in E
+type FileNode struct {
+	N string     `json:"n"`
+	S int64      `json:"s"`
+	E []FileNode `json:"e"`
+}
+
+// --- DebridService ---
+
+func (c *Client) AddTorrent(ctx context.Context, token, magnet string) (string, error) {
+	form := url.Values{}
+	form.Add("magnets[]", magnet)
+
+	var data struct {
+		Magnets []struct {
+			ID    int `json:"id"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"magnets"`
+	}
+	if err := c.do(ctx, "POST", "/magnet/upload", token, form, &data); err != nil {
+		return "", err
+	}
+	if len(data.Magnets) == 0 {
+		return "", fmt.Errorf("AllDebrid returned no magnet")
+	}
+	if e := data.Magnets[0].Error; e != nil {
+		return "", fmt.Errorf("AllDebrid: %s", e.Message)
+	}
+	return strconv.Itoa(data.Magnets[0].ID), nil
+}
+
+func (c *Client) TorrentStatus(ctx context.Context, token, id string) (*providers.DebridTorrent, error) {
+	params := url.Values{}
+	params.Set("id", id)
+
+	var data struct {
+		Magnets Magnet `json:"magnets"`
+	}
+	if err := c.do(ctx, "GET", "/magnet/status", token, params, &data); err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.Code == "MAGNET_INVALID_ID" {
+			return nil, providers.ErrDebridTorrentNotFound
+		}
+		return nil, err
+	}
+
+	m := data.Magnets
+	t := &providers.DebridTorrent{
+		ID:     strconv.Itoa(m.ID),
+		Hash:   strings.ToLower(m.Hash),
+		Name:   m.Filename,
+		Status: providers.DebridStatusDownloading,
+	}
+	if m.Size > 0 {
+		t.Progress = int(m.Downloaded * 100 / m.Size)
+	}
+
+	switch {
+	case m.StatusCode == 4:
+		t.Status = providers.DebridStatusDownloaded
+		t.Progress = 100
+	case m.StatusCode >= 5:
+		t.Status = providers.DebridStatusError
+	}
+
+	// Links only exist once the magnet is ready. AllDebrid downloads every file,
+	// so IDs are 1-based positions like RD's.
+	for i, l := range m.Links {
+		t.Files = append(t.Files, providers.DebridFile{
+			ID:       i + 1,
+			Path:     l.Filename,
+			Bytes:    l.Size,
+			Selected: true,
+			Link:     l.Link,
+		})
+	}
+	return t, nil
+}
+
+// SelectTorrentFiles is a no-op: AllDebrid always downloads the whole torrent
+func (c *Client) SelectTorrentFiles(ctx context.Context, token, id string, fileIDs []int) error {
+	return nil
+}
+
+func (c *Client) UnrestrictFile(ctx context.Context, token, link string) (*providers.DebridLink, error) {
+	params := url.Values{}
+	params.Set("link", link)
+
+	var data struct {
+		Link     string `json:"link"`
+		Filename string `json:"filename"`
+		Filesize int64  `json:"filesize"`
+	}
+	if err := c.do(ctx, "GET", "/link/unlock", token, params, &data); err != nil {
+		return nil, err
+	}
+	return &providers.DebridLink{Filename: data.Filename, Bytes: data.Filesize, Download: data.Link}, nil
+}
+
+func (c *Client) CheckAvailability(ctx context.Context, token string, hashes []string) (map[string][]providers.CachedFile, error) {
+	available := make(map[string][]providers.CachedFile)
+	if len(hashes) == 0 {
+		return available, nil
+	}
+
+	params := url.Values{}
+	for _, h := range hashes {
+		params.Add("magnets[]", h)
+	}
+
+	var data struct {
+		Magnets []struct {
+			Hash    string     `json:"hash"`
+			Instant bool       `json:"instant"`
+			Files   []FileNode `json:"files"`
+		} `json:"magnets"`
+	}
+	if err := c.do(ctx, "GET", "/magnet/instant", token, params, &data); err != nil {
+		return nil, err
+	}
+
+	for _, m := range data.Magnets {
+		if !m.Instant {
+			continue
+		}
+		var files []providers.CachedFile
+		flattenFiles(m.Files, "", &files)
+		available[strings.ToLower(m.Hash)] = files
+	}
+	return available, nil
+}
+
+func (c *Client) AccountInfo(ctx context.Context, token string) (*providers.DebridAccount, error) {
+	var data struct {
+		User struct {
+			Username     string `json:"username"`
+			Email        string `json:"email"`
+			IsPremium    bool   `json:"isPremium"`
+			PremiumUntil int64  `json:"premiumUntil"` // Unix seconds
+		} `json:"user"`
+	}
+	if err := c.do(ctx, "GET", "/user", token, nil, &data); err != nil {
+		return nil, err
+	}
+
+	u := data.User
+	account := &providers.DebridAccount{
+		Service:  c.Name(),
+		Username: u.Username,
+		Email:    u.Email,
+		Premium:  u.IsPremium,
+	}
+	if u.PremiumUntil > 0 {
+		account.ExpiresAt = time.Unix(u.PremiumUntil, 0)
+		account.PremiumDays = max(0, int(time.Until(account.ExpiresAt).Hours()/24))
+	}
+	return account, nil
+}
+
+// flattenFiles walks the file tree into paths, numbering files in order
+func flattenFiles(nodes []FileNode, prefix string, out *[]providers.CachedFile) {
+	for _, n := range nodes {
+		path := n.N
+		if prefix != "" {
+			path = prefix + "/" + n.N
+		}
+		if len(n.E) > 0 {
+			flattenFiles(n.E, path, out)
+			continue
+		}
+		*out = append(*out, providers.CachedFile{ID: len(*out) + 1, Path: path, Bytes: n.S})
+	}
+}
+
+// do sends an authenticated request and decodes the "data" member into out
+func (c *Client) do(ctx context.Context, method, endpoint, token string, params url.Values, out any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("agent", Agent)
+
+	u := c.BaseURL + endpoint
+	var body *strings.Reader
+	if method == "POST" {
+		body = strings.NewReader(params.Encode())
+	} else {
+		u += "?" + params.Encode()
+		body = strings.NewReader("")
+	}
+
+	log.Printf("AD Request: %s %s", method, c.BaseURL+endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("AllDebrid %s: %s", endpoint, resp.Status)
+	}
+	if env.Status != "success" {
+		if env.Error != nil {
+			return &apiError{Endpoint: endpoint, Code: env.Error.Code, Message: env.Error.Message}
+		}
+		return fmt.Errorf("AllDebrid %s: %s", endpoint, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
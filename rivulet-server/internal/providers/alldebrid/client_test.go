@@ -0,0 +1,185 @@
+package alldebrid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rivulet_server/internal/providers"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeAccount is an AllDebrid account holding magnets, served with AllDebrid's envelope
+type fakeAccount struct {
+	t       *testing.T
+	token   string
+	magnets map[int]Magnet
+	instant string // Raw /magnet/instant data
+}
+
+func (a *fakeAccount) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	if r.Form.Get("agent") != Agent {
+		a.t.Errorf("%s %s without the agent", r.Method, r.URL.Path)
+	}
+	if r.Header.Get("Authorization") != "Bearer "+a.token {
+		a.fail(w, "AUTH_BAD_APIKEY", "The auth apikey is invalid")
+		return
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /magnet/status":
+		id, _ := strconv.Atoi(r.Form.Get("id"))
+		m, ok := a.magnets[id]
+		if !ok {
+			a.fail(w, "MAGNET_INVALID_ID", "This magnet ID does not exists")
+			return
+		}
+		a.succeed(w, map[string]any{"magnets": m})
+	case "POST /magnet/upload":
+		magnet := r.PostForm.Get("magnets[]")
+		if !strings.HasPrefix(magnet, "magnet:") {
+			a.succeed(w, map[string]any{"magnets": []any{map[string]any{"error": map[string]string{"code": "MAGNET_INVALID_URI", "message": "This magnet is not valid"}}}})
+			return
+		}
+		id := len(a.magnets) + 1
+		a.magnets[id] = Magnet{ID: id, StatusCode: 0}
+		a.succeed(w, map[string]any{"magnets": []any{map[string]any{"id": id}}})
+	case "GET /magnet/instant":
+		w.Write([]byte(`{"status": "success", "data": ` + a.instant + `}`))
+	default:
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}
+}
+
+func (a *fakeAccount) succeed(w http.ResponseWriter, data any) {
+	json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": data})
+}
+
+func (a *fakeAccount) fail(w http.ResponseWriter, code, message string) {
+	json.NewEncoder(w).Encode(map[string]any{"status": "error", "error": map[string]string{"code": code, "message": message}})
+}
+
+// connect points a client at the account
+func (a *fakeAccount) connect() *Client {
+	srv := httptest.NewServer(a)
+	a.t.Cleanup(srv.Close)
+	c := NewClient()
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestTorrentStatus(t *testing.T) {
+	account := &fakeAccount{t: t, token: "secret", magnets: map[int]Magnet{
+		42: {ID: 42, Hash: "ABCDEF", Filename: "Show.S01", Size: 300, Downloaded: 300, StatusCode: 4, Links: []Link{
+			{Link: "https://alldebrid.com/f/aaa", Filename: "Show.S01E01.mkv", Size: 100},
+			{Link: "https://alldebrid.com/f/bbb", Filename: "Show.S01E02.mkv", Size: 200},
+		}},
+		43: {ID: 43, Size: 400, Downloaded: 100, StatusCode: 1},
+		44: {ID: 44, StatusCode: 7},
+	}}
+	c := account.connect()
+
+	tests := []struct {
+		id       string
+		status   string
+		progress int
+		files    int
+		err      error
+	}{
+		{"42", providers.DebridStatusDownloaded, 100, 2, nil},
+		{"43", providers.DebridStatusDownloading, 25, 0, nil},
+		{"44", providers.DebridStatusError, 0, 0, nil},
+		{"45", "", 0, 0, providers.ErrDebridTorrentNotFound},
+	}
+	for _, tt := range tests {
+		torrent, err := c.TorrentStatus(context.Background(), "secret", tt.id)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("magnet %s: err = %v, want %v", tt.id, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if torrent.Status != tt.status || torrent.Progress != tt.progress || len(torrent.Files) != tt.files {
+			t.Errorf("magnet %s: status %q, progress %d, %d files", tt.id, torrent.Status, torrent.Progress, len(torrent.Files))
+		}
+	}
+}
+
+func TestFilesNumberedByLinkPosition(t *testing.T) {
+	account := &fakeAccount{t: t, token: "secret", magnets: map[int]Magnet{
+		1: {ID: 1, Hash: "ABCDEF", StatusCode: 4, Links: []Link{
+			{Link: "https://alldebrid.com/f/aaa", Filename: "E01.mkv"},
+			{Link: "https://alldebrid.com/f/bbb", Filename: "E02.mkv"},
+			{Link: "https://alldebrid.com/f/ccc", Filename: "E03.mkv"},
+		}},
+	}}
+
+	torrent, err := account.connect().TorrentStatus(context.Background(), "secret", "1")
+	if err != nil {
+		t.Fatalf("TorrentStatus: %v", err)
+	}
+	if torrent.Hash != "abcdef" {
+		t.Errorf("hash = %q, want lowercase", torrent.Hash)
+	}
+	for i, f := range torrent.Files {
+		if f.ID != i+1 || !f.Selected || f.Link != account.magnets[1].Links[i].Link {
+			t.Errorf("file %d = %+v, want ID %d with its link", i, f, i+1)
+		}
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	account := &fakeAccount{t: t, token: "secret", magnets: map[int]Magnet{}}
+	c := account.connect()
+	ctx := context.Background()
+
+	if _, err := c.TorrentStatus(ctx, "wrong", "1"); err == nil || !strings.Contains(err.Error(), "AUTH_BAD_APIKEY") {
+		t.Errorf("bad token: err = %v", err)
+	}
+	if _, err := c.AddTorrent(ctx, "secret", "not a magnet"); err == nil || !strings.Contains(err.Error(), "not valid") {
+		t.Errorf("rejected magnet: err = %v", err)
+	}
+	if _, err := c.AccountInfo(ctx, "secret"); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("non-JSON response: err = %v", err)
+	}
+	if id, err := c.AddTorrent(ctx, "secret", "magnet:?xt=urn:btih:abcdef"); err != nil || id != "1" {
+		t.Errorf("AddTorrent = %q, %v", id, err)
+	}
+}
+
+func TestCheckAvailabilityFlattensTree(t *testing.T) {
+	account := &fakeAccount{t: t, token: "secret", instant: `{"magnets": [
+		{"hash": "AAA", "instant": true, "files": [
+			{"n": "Show.S01", "e": [{"n": "E01.mkv", "s": 100}, {"n": "Extras", "e": [{"n": "Featurette.mkv", "s": 5}]}]},
+			{"n": "Show.nfo", "s": 1}
+		]},
+		{"hash": "bbb", "instant": false}
+	]}`}
+
+	available, err := account.connect().CheckAvailability(context.Background(), "secret", []string{"aaa", "bbb"})
+	if err != nil {
+		t.Fatalf("CheckAvailability: %v", err)
+	}
+	if _, ok := available["bbb"]; ok {
+		t.Error("uncached hash reported as available")
+	}
+	want := []providers.CachedFile{
+		{ID: 1, Path: "Show.S01/E01.mkv", Bytes: 100},
+		{ID: 2, Path: "Show.S01/Extras/Featurette.mkv", Bytes: 5},
+		{ID: 3, Path: "Show.nfo", Bytes: 1},
+	}
+	got := available["aaa"]
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("file %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache status values reported per provider
+const (
+	CacheHit    = "hit"    // Fresh entry served
+	CacheStale  = "stale"  // Expired entry served while a background scrape refreshes it
+	CacheMiss   = "miss"   // Scraped live
+	CacheBypass = "bypass" // Caller asked for fresh results
+)
+
+// Default cache windows used when the Manager is created without explicit values
+const (
+	DefaultCacheTTL   = 30 * time.Minute
+	DefaultCacheStale = 6 * time.Hour
+)
+
+// CacheEntry is one provider's result for one title/episode
+type CacheEntry struct {
+	Streams  []*Stream `json:"streams"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// CacheStore persists scrape results. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+}
+
+// CacheKey identifies a provider's results for a title, season and episode.
+// provider is the scraper's ID, not its display name.
+func CacheKey(provider, imdbID string, season, episode int) string {
+	return fmt.Sprintf("%s|%s|%d|%d", provider, imdbID, season, episode)
+}
+
+// cloneStreams copies streams so cached entries aren't mutated by ranking/annotation
+func cloneStreams(streams []*Stream) []*Stream {
+	out := make([]*Stream, 0, len(streams))
+	for _, s := range streams {
+		cp := *s
+		cp.Score = 0
+		cp.ScoreBreakdown = nil
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// --- In-memory store ---
+
+type MemoryCache struct {
+	// MaxAge is how long entries are kept at all (fresh + stale window)
+	MaxAge time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*CacheEntry
+}
+
+func NewMemoryCache(maxAge time.Duration) *MemoryCache {
+	return &MemoryCache{
+		MaxAge:  maxAge,
+		entries: make(map[string]*CacheEntry),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.MaxAge > 0 && time.Since(entry.StoredAt) > c.MaxAge {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Sweep expired entries once the map grows
+	if len(c.entries) > 1000 && c.MaxAge > 0 {
+		for k, e := range c.entries {
+			if time.Since(e.StoredAt) > c.MaxAge {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = entry
+}
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Normalized torrent states shared by every debrid backend
+const (
+	DebridStatusWaitingFiles = "waiting_files_selection" // Files must be picked before the download starts
+	DebridStatusDownloading  = "downloading"             // Queued, converting or downloading
+	DebridStatusDownloaded   = "downloaded"              // Ready to unrestrict
+	DebridStatusError        = "error"                   // Dead, virus, failed...
+)
+
+// ErrDebridNotConfigured is returned when an account has no debrid key
+var ErrDebridNotConfigured = errors.New("no debrid service configured")
+
+// ErrDebridTorrentNotFound is returned when a torrent is no longer on the account
+var ErrDebridTorrentNotFound = errors.New("torrent not found on debrid account")
+
+// DebridService is a debrid provider (Real-Debrid, AllDebrid, Premiumize, TorBox).
+// Every call takes the account's API token, since clients are shared between users.
+type DebridService interface {
+	// Name is the stable service ID, e.g. "realdebrid"
+	Name() string
+
+	// AddTorrent adds a magnet to the account and returns the service's torrent ID
+	AddTorrent(ctx context.Context, token, magnet string) (string, error)
+	// TorrentStatus reports progress and files for a torrent added with AddTorrent
+	TorrentStatus(ctx context.Context, token, id string) (*DebridTorrent, error)
+	// SelectTorrentFiles picks which files to download; a no-op for services that fetch everything
+	SelectTorrentFiles(ctx context.Context, token, id string, fileIDs []int) error
+	// UnrestrictFile turns a DebridFile.Link into a direct download URL
+	UnrestrictFile(ctx context.Context, token, link string) (*DebridLink, error)
+	// CheckAvailability maps lowercase hashes to the files the service can serve instantly.
+	// Hashes that aren't cached are left out.
+	CheckAvailability(ctx context.Context, token string, hashes []string) (map[string][]CachedFile, error)
+	// AccountInfo returns the account's subscription state
+	AccountInfo(ctx context.Context, token string) (*DebridAccount, error)
+}
+
+// DebridTorrentFinder is implemented by services that can search the account's
+// existing torrents, so a magnet doesn't have to be added twice
+type DebridTorrentFinder interface {
+	// FindTorrents returns the IDs of torrents on the account with this info hash
+	FindTorrents(ctx context.Context, token, hash string) ([]string, error)
+}
+
+// DebridTorrentRemover is implemented by services that can delete torrents from the account
+type DebridTorrentRemover interface {
+	// DeleteTorrent removes a torrent. Returns ErrDebridTorrentNotFound if it's already gone.
+	DeleteTorrent(ctx context.Context, token, id string) error
+}
+
+// DebridTorrent is a torrent on a debrid account
+type DebridTorrent struct {
+	ID       string       `json:"id"`
+	Hash     string       `json:"hash"`
+	Name     string       `json:"name"`
+	Status   string       `json:"status"`   // One of the DebridStatus constants
+	Progress int          `json:"progress"` // 0-100
+	Files    []DebridFile `json:"files"`
+	// UnmappedLinks counts links that couldn't be paired with a file,
+	// e.g. when several selected files were packed into one archive
+	UnmappedLinks int `json:"unmapped_links,omitempty"`
+}
+
+// DebridFile is a file inside a debrid torrent. IDs are 1-based, following RD.
+// Link is set once the file is downloadable and is what UnrestrictFile expects.
+type DebridFile struct {
+	ID       int    `json:"id"`
+	Path     string `json:"path"`
+	Bytes    int64  `json:"bytes"`
+	Selected bool   `json:"selected"`
+	Link     string `json:"link,omitempty"`
+}
+
+// DebridLink is a direct download URL for one file
+type DebridLink struct {
+	Filename string `json:"filename"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Download string `json:"download"`
+}
+
+// DebridAccount is the subscription state of a debrid account
+type DebridAccount struct {
+	Service     string    `json:"service"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email,omitempty"`
+	Type        string    `json:"type"` // "premium" or "free"
+	Premium     bool      `json:"premium"`
+	PremiumDays int       `json:"premium_days"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"`
+	Points      int       `json:"points,omitempty"` // Loyalty points, where the service has them
+
+	// Per-hoster quotas, for services that report them
+	Traffic []DebridTraffic `json:"traffic,omitempty"`
+}
+
+// DebridTraffic is a download quota on a debrid account
+type DebridTraffic struct {
+	Host  string `json:"host"`
+	Type  string `json:"type"`            // What Left and Limit count: "links", "gigabytes" or "traffic" (bytes)
+	Left  int64  `json:"left"`            // Remaining in the current period
+	Used  int64  `json:"used"`            // Bytes downloaded in the current period
+	Limit int64  `json:"limit,omitempty"` // 0 = unlimited
+	Reset string `json:"reset,omitempty"` // When the quota resets, e.g. "daily"
+}
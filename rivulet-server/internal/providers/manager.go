@@ -1,39 +1,117 @@
 package providers
 
 import (
+	"context"
+	"errors"
 	"log"
 	"sync"
+	"time"
+)
+
+// Default budgets used when the Manager is created without explicit values
+const (
+	DefaultTimeout         = 12 * time.Second
+	DefaultProviderTimeout = 10 * time.Second
+)
+
+// Provider status values reported by ScrapeAll
+const (
+	StatusOK      = "ok"
+	StatusTimeout = "timeout"
+	StatusError   = "error"
+	StatusSkipped = "skipped" // Provider doesn't handle this type/ID
 )
 
 type Manager struct {
 	Scrapers []Scraper
+
+	// Timeout is the overall budget for a ScrapeAll call.
+	// Providers that haven't answered by then are reported as timed out.
+	Timeout time.Duration
+	// ProviderTimeout is the deadline applied to each individual scraper.
+	ProviderTimeout time.Duration
+
+	// Cache stores per-provider results; nil disables caching.
+	Cache CacheStore
+	// CacheTTL is how long an entry is served as fresh.
+	CacheTTL time.Duration
+	// CacheStale is how long past CacheTTL an entry is still served
+	// while a background scrape refreshes it.
+	CacheStale time.Duration
+
+	revalidateMu sync.Mutex
+	revalidating map[string]bool
+}
+
+// ProviderStatus describes how a single scraper behaved during a ScrapeAll call
+type ProviderStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"` // "ok", "timeout", "error", "skipped"
+	Error     string `json:"error,omitempty"`
+	Count     int    `json:"count"`
+	LatencyMs int64  `json:"latency_ms"`
+	Cache     string `json:"cache,omitempty"` // "hit", "stale", "miss", "bypass"
+}
+
+// ScrapeResult is the merged output of all scrapers plus a per-provider report
+type ScrapeResult struct {
+	Streams   []*Stream        `json:"streams"`
+	Providers []ProviderStatus `json:"providers"`
+	Responded int              `json:"responded"`
+	Total     int              `json:"total"`
+	// Cache summarises provider cache use: "hit" (all cached), "partial", "miss" or "bypass"
+	Cache string `json:"cache"`
 }
 
 func NewManager(scrapers ...Scraper) *Manager {
 	return &Manager{
-		Scrapers: scrapers,
+		Scrapers:        scrapers,
+		Timeout:         DefaultTimeout,
+		ProviderTimeout: DefaultProviderTimeout,
+		CacheTTL:        DefaultCacheTTL,
+		CacheStale:      DefaultCacheStale,
 	}
 }
 
-// ScrapeAll queries all providers in parallel and merges results
-func (m *Manager) ScrapeAll(mediaType, imdbID, rdKey string, season, episode int) []*Stream {
+type scrapeOutcome struct {
+	index   int
+	streams []*Stream
+	status  ProviderStatus
+}
+
+// ProgressFunc receives each provider's outcome as soon as it's known.
+// streams is nil unless the provider answered successfully.
+type ProgressFunc func(status ProviderStatus, streams []*Stream)
+
+// ScrapeAll queries all providers in parallel and merges results.
+// It returns whatever finished before ctx or the overall budget expired,
+// along with a status entry for every provider.
+// refresh skips cached results and scrapes every provider live.
+func (m *Manager) ScrapeAll(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int, refresh bool) *ScrapeResult {
+	return m.ScrapeProgressive(ctx, mediaType, imdbID, rdKey, season, episode, refresh, nil)
+}
+
+// ScrapeProgressive is ScrapeAll with a callback fired as each provider finishes,
+// including providers abandoned when the overall budget runs out.
+// The callback runs on the caller's goroutine, one provider at a time.
+func (m *Manager) ScrapeProgressive(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int, refresh bool, onProvider ProgressFunc) *ScrapeResult {
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+
 	var wg sync.WaitGroup
-	resultsChan := make(chan []*Stream, len(m.Scrapers))
+	resultsChan := make(chan scrapeOutcome, len(m.Scrapers))
 
 	// 1. Launch Goroutines
-	for _, s := range m.Scrapers {
+	for i, s := range m.Scrapers {
 		wg.Add(1)
-		go func(scraper Scraper) {
+		go func(index int, scraper Scraper) {
 			defer wg.Done()
-			
-			// Add timeout/context logic here in production
-			streams, err := scraper.Scrape(mediaType, imdbID, rdKey, season, episode)
-			if err != nil {
-				log.Printf("⚠️ [%s] Scrape failed: %v", scraper.Name(), err)
-				return
-			}
-			resultsChan <- streams
-		}(s)
+			streams, status := m.scrapeCached(ctx, scraper, mediaType, imdbID, rdKey, season, episode, refresh)
+			resultsChan <- scrapeOutcome{index: index, streams: streams, status: status}
+		}(i, s)
 	}
 
 	// 2. Wait and Close
@@ -42,19 +120,191 @@ func (m *Manager) ScrapeAll(mediaType, imdbID, rdKey string, season, episode int
 		close(resultsChan)
 	}()
 
-	// 3. Aggregate
-	var allStreams []*Stream
-	// Use a map to deduplicate by Hash if multiple providers return the same torrent
+	// 3. Aggregate until every provider answered or the budget ran out
+	statuses := make([]*ProviderStatus, len(m.Scrapers))
+	outcomes := make([][]*Stream, len(m.Scrapers))
+	start := time.Now()
+
+collect:
+	for {
+		select {
+		case out, ok := <-resultsChan:
+			if !ok {
+				break collect
+			}
+			if season > 0 || episode > 0 {
+				ClassifyPacks(out.streams)
+			}
+			statuses[out.index] = &out.status
+			outcomes[out.index] = out.streams
+			if onProvider != nil {
+				onProvider(out.status, out.streams)
+			}
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	result := &ScrapeResult{}
+	cached, live := 0, 0
+
+	// Use a map to deduplicate by Hash if multiple providers return the same torrent.
+	// Providers are merged in registration order so results are stable.
 	seenHashes := make(map[string]bool)
+	for i, scraper := range m.Scrapers {
+		status := statuses[i]
+		if status == nil {
+			// Still running when the overall budget expired
+			status = &ProviderStatus{
+				Name:      scraper.Name(),
+				Status:    StatusTimeout,
+				Error:     "overall scrape budget exceeded",
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			log.Printf("⚠️ [%s] Scrape abandoned: overall budget exceeded", scraper.Name())
+			if onProvider != nil {
+				onProvider(*status, nil)
+			}
+		}
+		// Skipped providers were never asked, so they don't count towards "N of M responded"
+		if status.Status != StatusSkipped {
+			result.Total++
+		}
+		if status.Status == StatusOK {
+			result.Responded++
+		}
+		result.Providers = append(result.Providers, *status)
+
+		switch status.Cache {
+		case CacheHit, CacheStale:
+			cached++
+		case CacheMiss:
+			live++
+		}
 
-	for streams := range resultsChan {
-		for _, s := range streams {
+		for _, s := range outcomes[i] {
 			if !seenHashes[s.Hash] {
 				seenHashes[s.Hash] = true
-				allStreams = append(allStreams, s)
+				result.Streams = append(result.Streams, s)
 			}
 		}
 	}
 
-	return allStreams
-}
\ No newline at end of file
+	switch {
+	case refresh:
+		result.Cache = CacheBypass
+	case cached > 0 && live == 0:
+		result.Cache = CacheHit
+	case cached > 0:
+		result.Cache = "partial"
+	default:
+		result.Cache = CacheMiss
+	}
+
+	return result
+}
+
+// scrapeCached serves a provider's results from the cache when possible.
+// Fresh entries are returned as-is; stale ones are returned immediately and
+// refreshed in the background. Only successful scrapes are stored.
+func (m *Manager) scrapeCached(ctx context.Context, scraper Scraper, mediaType, imdbID, rdKey string, season, episode int, refresh bool) ([]*Stream, ProviderStatus) {
+	if m.Cache == nil {
+		return m.scrapeOne(ctx, scraper, mediaType, imdbID, rdKey, season, episode)
+	}
+
+	key := CacheKey(scraperID(scraper), imdbID, season, episode)
+
+	if !refresh {
+		if entry, ok := m.Cache.Get(key); ok {
+			age := time.Since(entry.StoredAt)
+			if age <= m.CacheTTL+m.CacheStale {
+				status := ProviderStatus{
+					Name:   scraper.Name(),
+					Status: StatusOK,
+					Count:  len(entry.Streams),
+					Cache:  CacheHit,
+				}
+				if age > m.CacheTTL {
+					status.Cache = CacheStale
+					m.revalidate(key, scraper, mediaType, imdbID, rdKey, season, episode)
+				}
+				return cloneStreams(entry.Streams), status
+			}
+		}
+	}
+
+	streams, status := m.scrapeOne(ctx, scraper, mediaType, imdbID, rdKey, season, episode)
+	status.Cache = CacheMiss
+	if refresh {
+		status.Cache = CacheBypass
+	}
+	if status.Status == StatusOK {
+		m.Cache.Set(key, &CacheEntry{Streams: cloneStreams(streams), StoredAt: time.Now()})
+	}
+	return streams, status
+}
+
+// revalidate re-scrapes a stale entry in the background, at most once per key at a time
+func (m *Manager) revalidate(key string, scraper Scraper, mediaType, imdbID, rdKey string, season, episode int) {
+	m.revalidateMu.Lock()
+	if m.revalidating == nil {
+		m.revalidating = make(map[string]bool)
+	}
+	if m.revalidating[key] {
+		m.revalidateMu.Unlock()
+		return
+	}
+	m.revalidating[key] = true
+	m.revalidateMu.Unlock()
+
+	go func() {
+		defer func() {
+			m.revalidateMu.Lock()
+			delete(m.revalidating, key)
+			m.revalidateMu.Unlock()
+		}()
+
+		// Detached from the request, which has usually finished by now
+		streams, status := m.scrapeOne(context.Background(), scraper, mediaType, imdbID, rdKey, season, episode)
+		if status.Status == StatusOK {
+			m.Cache.Set(key, &CacheEntry{Streams: cloneStreams(streams), StoredAt: time.Now()})
+		}
+	}()
+}
+
+// scrapeOne runs a single scraper under its own deadline and reports how it went
+func (m *Manager) scrapeOne(ctx context.Context, scraper Scraper, mediaType, imdbID, rdKey string, season, episode int) ([]*Stream, ProviderStatus) {
+	if m.ProviderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.ProviderTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	streams, err := scraper.Scrape(ctx, mediaType, imdbID, rdKey, season, episode)
+	status := ProviderStatus{
+		Name:      scraper.Name(),
+		Status:    StatusOK,
+		Count:     len(streams),
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+
+	if errors.Is(err, ErrNotSupported) {
+		status.Status = StatusSkipped
+		status.Count = 0
+		return nil, status
+	}
+
+	if err != nil {
+		status.Status = StatusError
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+			status.Status = StatusTimeout
+		}
+		status.Error = err.Error()
+		status.Count = 0
+		log.Printf("⚠️ [%s] Scrape failed: %v", scraper.Name(), err)
+		return nil, status
+	}
+
+	return streams, status
+}
@@ -0,0 +1,227 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubScraper answers every scrape with fixed streams. A delay is cut short
+// by the context; hold, when set, blocks until closed whatever the context says.
+type stubScraper struct {
+	name    string
+	id      string // Empty falls back to Name, like scrapers without ScraperID
+	streams []*Stream
+	err     error
+	delay   time.Duration
+	hold    chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *stubScraper) Name() string { return s.name }
+
+func (s *stubScraper) ID() string {
+	if s.id == "" {
+		return s.name
+	}
+	return s.id
+}
+
+func (s *stubScraper) Scrape(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int) ([]*Stream, error) {
+	s.calls.Add(1)
+	if s.hold != nil {
+		<-s.hold
+	}
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.streams, nil
+}
+
+// parsed builds a stream with its release name parsed, as scrapers return them
+func parsed(title, hash string) *Stream {
+	s := &Stream{Title: title, Hash: hash}
+	s.ParseRelease(title)
+	return s
+}
+
+func statusOf(t *testing.T, result *ScrapeResult, name string) ProviderStatus {
+	t.Helper()
+	for _, p := range result.Providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	t.Fatalf("no status for %s in %+v", name, result.Providers)
+	return ProviderStatus{}
+}
+
+func TestCacheKeyedByScraperID(t *testing.T) {
+	public := &stubScraper{name: "Torrentio", id: "https://torrentio.strem.fun", streams: []*Stream{{Hash: "a"}}}
+	selfHosted := &stubScraper{name: "Torrentio", id: "https://torrentio.example.com", streams: []*Stream{{Hash: "b"}}}
+
+	m := NewManager(public, selfHosted)
+	m.Cache = NewMemoryCache(0)
+	m.ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+	result := m.ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+
+	if len(result.Streams) != 2 {
+		t.Errorf("got %d streams, want both instances' results", len(result.Streams))
+	}
+	if result.Cache != CacheHit || public.calls.Load() != 1 || selfHosted.calls.Load() != 1 {
+		t.Errorf("cache %q, calls %d/%d; want one live scrape each", result.Cache, public.calls.Load(), selfHosted.calls.Load())
+	}
+}
+
+func TestProviderTimeout(t *testing.T) {
+	fast := &stubScraper{name: "Fast", streams: []*Stream{{Hash: "a"}}}
+	slow := &stubScraper{name: "Slow", delay: time.Second, streams: []*Stream{{Hash: "b"}}}
+
+	m := NewManager(fast, slow)
+	m.Timeout = 5 * time.Second
+	m.ProviderTimeout = 20 * time.Millisecond
+	start := time.Now()
+	result := m.ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %v, want about the provider timeout", elapsed)
+	}
+	if s := statusOf(t, result, "Slow"); s.Status != StatusTimeout || !strings.Contains(s.Error, "deadline exceeded") {
+		t.Errorf("slow provider: %+v", s)
+	}
+	if result.Responded != 1 || result.Total != 2 || len(result.Streams) != 1 {
+		t.Errorf("%d of %d responded with %d streams", result.Responded, result.Total, len(result.Streams))
+	}
+}
+
+func TestOverallBudget(t *testing.T) {
+	// Ignores its context, so only the overall budget gets ScrapeAll out
+	stuck := &stubScraper{name: "Stuck", hold: make(chan struct{})}
+	defer close(stuck.hold)
+	fast := &stubScraper{name: "Fast", streams: []*Stream{{Hash: "a"}}}
+
+	m := NewManager(stuck, fast)
+	m.Timeout = 30 * time.Millisecond
+	m.ProviderTimeout = 5 * time.Second
+
+	var reported []string
+	result := m.ScrapeProgressive(context.Background(), "movie", "tt1375666", "", 0, 0, false, func(status ProviderStatus, streams []*Stream) {
+		reported = append(reported, status.Name+":"+status.Status)
+	})
+
+	s := statusOf(t, result, "Stuck")
+	if s.Status != StatusTimeout || s.Error != "overall scrape budget exceeded" {
+		t.Errorf("stuck provider: %+v", s)
+	}
+	// Abandoned providers are reported to the callback too, after the ones that answered
+	if strings.Join(reported, ",") != "Fast:ok,Stuck:timeout" {
+		t.Errorf("callback saw %v", reported)
+	}
+	if len(result.Streams) != 1 || result.Responded != 1 {
+		t.Errorf("got %d streams, %d responded", len(result.Streams), result.Responded)
+	}
+}
+
+func TestUnsupportedProviderSkipped(t *testing.T) {
+	movies := &stubScraper{name: "Movies", err: ErrNotSupported}
+	shows := &stubScraper{name: "Shows", streams: []*Stream{{Hash: "a"}}}
+
+	result := NewManager(movies, shows).ScrapeAll(context.Background(), "show", "tt0903747", "", 1, 2, false)
+
+	if s := statusOf(t, result, "Movies"); s.Status != StatusSkipped || s.Error != "" || s.Count != 0 {
+		t.Errorf("unsupported provider: %+v", s)
+	}
+	// Skipped providers aren't counted in "N of M responded"
+	if result.Responded != 1 || result.Total != 1 {
+		t.Errorf("%d of %d responded, want 1 of 1", result.Responded, result.Total)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	scraper := &stubScraper{name: "Torrentio", streams: []*Stream{{Hash: "new"}}, hold: make(chan struct{})}
+
+	m := NewManager(scraper)
+	m.Cache = NewMemoryCache(0)
+	m.CacheTTL = time.Hour
+	m.CacheStale = time.Hour
+	key := CacheKey("Torrentio", "tt1375666", 0, 0)
+	m.Cache.Set(key, &CacheEntry{Streams: []*Stream{{Hash: "old"}}, StoredAt: time.Now().Add(-90 * time.Minute)})
+
+	// Both requests get the stale entry at once while a single refresh is held
+	for range 2 {
+		result := m.ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+		if len(result.Streams) != 1 || result.Streams[0].Hash != "old" || result.Providers[0].Cache != CacheStale || result.Cache != CacheHit {
+			t.Fatalf("got %+v, cache %q; want the stale entry", result.Streams, result.Cache)
+		}
+	}
+	close(scraper.hold)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if entry, _ := m.Cache.Get(key); entry.Streams[0].Hash == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stale entry was never refreshed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if scraper.calls.Load() != 1 {
+		t.Errorf("revalidated %d times, want once", scraper.calls.Load())
+	}
+
+	result := m.ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+	if result.Streams[0].Hash != "new" || result.Providers[0].Cache != CacheHit {
+		t.Errorf("after the refresh: %+v, cache %q", result.Streams, result.Providers[0].Cache)
+	}
+
+	// Past the stale window the entry isn't served at all
+	m.Cache.Set(key, &CacheEntry{Streams: []*Stream{{Hash: "old"}}, StoredAt: time.Now().Add(-3 * time.Hour)})
+	result = m.ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+	if result.Streams[0].Hash != "new" || result.Cache != CacheMiss {
+		t.Errorf("expired entry: %+v, cache %q", result.Streams, result.Cache)
+	}
+}
+
+func TestEpisodeScrapesClassifyCoverage(t *testing.T) {
+	idx := 4
+	pack := parsed("Breaking.Bad.S01.1080p.BluRay.x264-ROVERS", "pack")
+	pack.FileIndex = &idx
+	scraper := &stubScraper{name: "Torrentio", streams: []*Stream{
+		parsed("Breaking.Bad.S01E02.1080p.BluRay.x264-ROVERS", "single"),
+		pack,
+		parsed("Breaking.Bad.S01-S05.Complete.1080p.BluRay", "complete"),
+		parsed("Breaking Bad 1080p", "unknown"),
+	}}
+
+	result := NewManager(scraper).ScrapeAll(context.Background(), "show", "tt0903747", "", 1, 2, false)
+	want := map[string]string{"single": PackEpisode, "pack": PackSeason, "complete": PackComplete}
+	for _, s := range result.Streams {
+		switch {
+		case want[s.Hash] == "":
+			if s.Coverage != nil {
+				t.Errorf("%s: coverage %+v, want none", s.Hash, s.Coverage)
+			}
+		case s.Coverage == nil || s.Coverage.Kind != want[s.Hash]:
+			t.Errorf("%s: coverage %+v, want %s", s.Hash, s.Coverage, want[s.Hash])
+		}
+	}
+	if !pack.Coverage.FileMapped {
+		t.Error("season pack with a file index isn't file-mapped")
+	}
+
+	movie := &stubScraper{name: "Movies", streams: []*Stream{parsed("Inception.2010.1080p.BluRay", "movie")}}
+	result = NewManager(movie).ScrapeAll(context.Background(), "movie", "tt1375666", "", 0, 0, false)
+	if result.Streams[0].Coverage != nil {
+		t.Errorf("movie scrape classified: %+v", result.Streams[0].Coverage)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"rivulet_server/internal/providers"
 	"time"
 )
 
@@ -123,7 +124,7 @@ func (c *Client) GetDetails(apiKey, id, mediaType string) (*MediaDetail, error)
     
     // MDBList returns empty title if not found
     if detail.Title == "" {
-        return nil, fmt.Errorf("item not found in MDBList")
+        return nil, fmt.Errorf("item not found in MDBList: %w", providers.ErrMetadataNotFound)
     }
 
 	return &detail, nil
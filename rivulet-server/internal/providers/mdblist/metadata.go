@@ -0,0 +1,78 @@
+package mdblist
+
+import (
+	"context"
+	"rivulet_server/internal/providers"
+	"strconv"
+)
+
+var _ providers.MetadataProvider = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "mdblist"
+}
+
+// SearchMedia isn't supported: MDBList results carry no TMDB ID
+func (c *Client) SearchMedia(ctx context.Context, apiKey, query string) ([]providers.MediaSummary, error) {
+	return nil, providers.ErrNotSupported
+}
+
+func (c *Client) MediaDetails(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (*providers.MediaDetails, error) {
+	detail, err := c.detailsFor(apiKey, ids, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &providers.MediaDetails{
+		Title:       detail.Title,
+		Year:        detail.Year,
+		Description: detail.Description,
+		ImdbID:      detail.ImdbID,
+		TmdbID:      detail.TmdbID,
+		Type:        detail.Type,
+		Poster:      detail.Poster,
+		Backdrop:    detail.Backdrop,
+		Logo:        detail.Logo,
+		Ratings:     make([]providers.Rating, 0, len(detail.Ratings)),
+	}
+	for _, r := range detail.Ratings {
+		details.Ratings = append(details.Ratings, providers.Rating{Source: r.Source, Value: r.Value})
+	}
+	return details, nil
+}
+
+func (c *Client) ShowSeasons(ctx context.Context, apiKey string, ids providers.MediaIDs) ([]providers.SeasonSummary, error) {
+	return nil, providers.ErrNotSupported
+}
+
+func (c *Client) SeasonEpisodes(ctx context.Context, apiKey string, ids providers.MediaIDs, season int) (*providers.SeasonDetails, error) {
+	return nil, providers.ErrNotSupported
+}
+
+func (c *Client) MediaImages(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (*providers.MediaImages, error) {
+	detail, err := c.detailsFor(apiKey, ids, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	return &providers.MediaImages{Poster: detail.Poster, Backdrop: detail.Backdrop, Logo: detail.Logo}, nil
+}
+
+func (c *Client) LookupIDs(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (providers.MediaIDs, error) {
+	detail, err := c.detailsFor(apiKey, ids, mediaType)
+	if err != nil {
+		return ids, err
+	}
+	return ids.Merge(providers.MediaIDs{Imdb: detail.ImdbID, Tmdb: detail.TmdbID}), nil
+}
+
+// detailsFor looks a title up by IMDb ID, or by TMDB ID when that's all there is
+func (c *Client) detailsFor(apiKey string, ids providers.MediaIDs, mediaType string) (*MediaDetail, error) {
+	id := ids.Imdb
+	if id == "" {
+		if ids.Tmdb == 0 {
+			return nil, providers.ErrNotSupported
+		}
+		id = strconv.Itoa(ids.Tmdb)
+	}
+	return c.GetDetails(apiKey, id, mediaType)
+}
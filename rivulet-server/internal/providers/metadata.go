@@ -0,0 +1,176 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrMetadataNotFound is returned when no provider knows the title
+var ErrMetadataNotFound = errors.New("metadata not found")
+
+// ErrMetadataUnavailable is returned when no configured provider can serve a field
+var ErrMetadataUnavailable = errors.New("no metadata provider configured")
+
+// MetadataProvider is a source of titles, artwork and episode lists (TMDB, MDBList, ...).
+// Every call takes the account's API key for the provider, since clients are shared between users.
+// Methods a provider can't serve, or can't serve for the IDs given, return ErrNotSupported.
+type MetadataProvider interface {
+	// Name is the stable provider ID, e.g. "tmdb"
+	Name() string
+
+	// SearchMedia finds movies and shows; an empty query lists what's trending
+	SearchMedia(ctx context.Context, apiKey, query string) ([]MediaSummary, error)
+	// MediaDetails describes a movie or show
+	MediaDetails(ctx context.Context, apiKey string, ids MediaIDs, mediaType string) (*MediaDetails, error)
+	// ShowSeasons lists a show's seasons
+	ShowSeasons(ctx context.Context, apiKey string, ids MediaIDs) ([]SeasonSummary, error)
+	// SeasonEpisodes lists the episodes of one season
+	SeasonEpisodes(ctx context.Context, apiKey string, ids MediaIDs, season int) (*SeasonDetails, error)
+	// MediaImages returns artwork URLs; missing images are left empty
+	MediaImages(ctx context.Context, apiKey string, ids MediaIDs, mediaType string) (*MediaImages, error)
+	// LookupIDs fills in the IDs other providers know the title by
+	LookupIDs(ctx context.Context, apiKey string, ids MediaIDs, mediaType string) (MediaIDs, error)
+}
+
+// MediaIDs identifies a title across providers
+type MediaIDs struct {
+	Imdb string `json:"imdb,omitempty"` // "tt1375666"
+	Tmdb int    `json:"tmdb,omitempty"`
+}
+
+// Empty reports whether no ID is known
+func (ids MediaIDs) Empty() bool {
+	return ids.Imdb == "" && ids.Tmdb == 0
+}
+
+// Merge fills IDs missing from ids with the ones in other
+func (ids MediaIDs) Merge(other MediaIDs) MediaIDs {
+	if ids.Imdb == "" {
+		ids.Imdb = other.Imdb
+	}
+	if ids.Tmdb == 0 {
+		ids.Tmdb = other.Tmdb
+	}
+	return ids
+}
+
+// ParseMediaID reads the ID forms the API accepts: "tt123", "imdb:tt123",
+// "tmdb:123", "tm123" and a bare TMDB number
+func ParseMediaID(id string) MediaIDs {
+	if prefix, rest, found := strings.Cut(id, ":"); found {
+		switch prefix {
+		case "imdb":
+			return MediaIDs{Imdb: rest}
+		case "tmdb":
+			n, _ := strconv.Atoi(rest)
+			return MediaIDs{Tmdb: n}
+		}
+		return MediaIDs{}
+	}
+	if strings.HasPrefix(id, "tt") {
+		return MediaIDs{Imdb: id}
+	}
+	n, _ := strconv.Atoi(strings.TrimPrefix(id, "tm"))
+	return MediaIDs{Tmdb: n}
+}
+
+// IsShow reports whether a media type names a series ("show", "tv", "series")
+func IsShow(mediaType string) bool {
+	return mediaType == "show" || mediaType == "tv" || mediaType == "series"
+}
+
+// MediaSummary is a search result
+type MediaSummary struct {
+	ID           int    `json:"id"`              // TMDB ID
+	Title        string `json:"title,omitempty"` // Movie
+	Name         string `json:"name,omitempty"`  // TV Show
+	PosterPath   string `json:"poster_path"`
+	BackdropPath string `json:"backdrop_path"`
+	MediaType    string `json:"media_type"`               // "movie", "tv"
+	ReleaseDate  string `json:"release_date,omitempty"`   // Movie
+	FirstAirDate string `json:"first_air_date,omitempty"` // TV
+	Overview     string `json:"overview"`
+}
+
+// MediaDetails describes a movie or show
+type MediaDetails struct {
+	Title       string   `json:"title"`
+	Year        int      `json:"year"`
+	Description string   `json:"description"`
+	ImdbID      string   `json:"imdbid"`
+	TmdbID      int      `json:"tmdbid"`
+	Type        string   `json:"type"` // "movie" or "show"
+	Poster      string   `json:"poster"`
+	Backdrop    string   `json:"backdrop"`
+	Logo        string   `json:"logo"`
+	Ratings     []Rating `json:"ratings"`
+	Source      string   `json:"source"` // Provider that supplied the record
+}
+
+// IDs returns the IDs the details carry
+func (d *MediaDetails) IDs() MediaIDs {
+	return MediaIDs{Imdb: d.ImdbID, Tmdb: d.TmdbID}
+}
+
+// Rating is a score from one site, e.g. {"imdb", 8.8}
+type Rating struct {
+	Source string `json:"source"`
+	Value  any    `json:"value"`
+}
+
+// MediaImages are artwork URLs for a title
+type MediaImages struct {
+	Poster   string `json:"poster,omitempty"`
+	Backdrop string `json:"backdrop,omitempty"`
+	Logo     string `json:"logo,omitempty"`
+}
+
+// SeasonSummary is one entry in a show's season list
+type SeasonSummary struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	Overview      string `json:"overview"`
+	PosterPath    string `json:"poster_path"`
+	SeasonNumber  int    `json:"season_number"`
+	EpisodeCount  int    `json:"episode_count"`
+	AirDate       string `json:"air_date"`
+	HasNextSeason bool   `json:"has_next_season"`
+	Source        string `json:"source,omitempty"`
+}
+
+// SeasonDetails is a season with its episodes
+type SeasonDetails struct {
+	ID           int              `json:"id"`
+	AirDate      string           `json:"air_date"`
+	Name         string           `json:"name"`
+	Overview     string           `json:"overview"`
+	PosterPath   string           `json:"poster_path"`
+	SeasonNumber int              `json:"season_number"`
+	Episodes     []EpisodeDetails `json:"episodes"`
+	Source       string           `json:"source,omitempty"`
+}
+
+// EpisodeDetails describes one episode
+type EpisodeDetails struct {
+	AirDate        string  `json:"air_date"`
+	EpisodeNumber  int     `json:"episode_number"`
+	ID             int     `json:"id"`
+	Name           string  `json:"name"`
+	Overview       string  `json:"overview"`
+	StillPath      string  `json:"still_path"`
+	VoteAverage    float64 `json:"vote_average"`
+	Runtime        int     `json:"runtime"`
+	IsSeasonFinale bool    `json:"is_season_finale"`
+}
+
+// Episode returns the episode with the given number, or nil
+func (s *SeasonDetails) Episode(number int) *EpisodeDetails {
+	for i := range s.Episodes {
+		if s.Episodes[i].EpisodeNumber == number {
+			return &s.Episodes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Metadata fields a MetadataResolver picks providers for
+const (
+	MetadataSearch   = "search"
+	MetadataDetails  = "details"
+	MetadataSeasons  = "seasons"
+	MetadataEpisodes = "episodes"
+	MetadataImages   = "images"
+	MetadataIDs      = "ids"
+	MetadataRatings  = "ratings"
+)
+
+// DefaultMetadataPlan is the provider order for each field: TMDB for everything,
+// with MDBList as a fallback and the only source of ratings
+var DefaultMetadataPlan = map[string][]string{
+	MetadataSearch:   {"tmdb"},
+	MetadataDetails:  {"tmdb", "mdblist"},
+	MetadataSeasons:  {"tmdb"},
+	MetadataEpisodes: {"tmdb"},
+	MetadataImages:   {"tmdb", "mdblist"},
+	MetadataIDs:      {"tmdb", "mdblist"},
+	MetadataRatings:  {"mdblist"},
+}
+
+// ParseMetadataPlan reads plan overrides like "details=tmdb,mdblist;images=tmdb".
+// Fields that aren't mentioned keep their DefaultMetadataPlan order.
+func ParseMetadataPlan(raw string) (map[string][]string, error) {
+	plan := make(map[string][]string, len(DefaultMetadataPlan))
+	for field, names := range DefaultMetadataPlan {
+		plan[field] = names
+	}
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		field, list, found := strings.Cut(entry, "=")
+		field = strings.TrimSpace(field)
+		if _, known := DefaultMetadataPlan[field]; !found || !known {
+			return nil, fmt.Errorf("invalid metadata plan entry %q", entry)
+		}
+		var names []string
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		plan[field] = names
+	}
+	return plan, nil
+}
+
+// MetadataResolver answers metadata calls from several providers. Each field tries
+// its providers in plan order and falls back to the next one when a provider fails,
+// doesn't support the call or hasn't been configured for the account.
+type MetadataResolver struct {
+	Providers map[string]MetadataProvider
+	Plan      map[string][]string // Field -> provider names, in order; nil uses DefaultMetadataPlan
+	// Keys are the account's API keys by provider name. Providers without an
+	// entry are skipped; keyless providers are enabled with an empty key.
+	Keys map[string]string
+}
+
+// candidates returns the enabled providers for a field, in order
+func (r *MetadataResolver) candidates(field string) []MetadataProvider {
+	plan := r.Plan
+	if plan == nil {
+		plan = DefaultMetadataPlan
+	}
+	var out []MetadataProvider
+	for _, name := range plan[field] {
+		p, ok := r.Providers[name]
+		if !ok {
+			continue
+		}
+		if _, enabled := r.Keys[name]; enabled {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Available reports whether any enabled provider is planned for the field
+func (r *MetadataResolver) Available(field string) bool {
+	return len(r.candidates(field)) > 0
+}
+
+// try calls fn for each of the field's providers until one succeeds.
+// It returns the provider that answered.
+func (r *MetadataResolver) try(field string, fn func(p MetadataProvider, key string) error) (string, error) {
+	candidates := r.candidates(field)
+	if len(candidates) == 0 {
+		return "", ErrMetadataUnavailable
+	}
+	lastErr := ErrMetadataNotFound
+	for _, p := range candidates {
+		err := fn(p, r.Keys[p.Name()])
+		if err == nil {
+			return p.Name(), nil
+		}
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if !errors.Is(err, ErrMetadataNotFound) {
+			log.Printf("⚠️ %s %s lookup failed: %v", p.Name(), field, err)
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// SearchMedia finds movies and shows; an empty query lists what's trending
+func (r *MetadataResolver) SearchMedia(ctx context.Context, query string) ([]MediaSummary, error) {
+	var results []MediaSummary
+	_, err := r.try(MetadataSearch, func(p MetadataProvider, key string) error {
+		var err error
+		results, err = p.SearchMedia(ctx, key, query)
+		return err
+	})
+	return results, err
+}
+
+// LookupIDs fills in the IDs of a title that are missing from ids.
+// Lookups that fail leave ids as they were.
+func (r *MetadataResolver) LookupIDs(ctx context.Context, ids MediaIDs, mediaType string) MediaIDs {
+	if ids.Imdb != "" && ids.Tmdb != 0 {
+		return ids
+	}
+	r.try(MetadataIDs, func(p MetadataProvider, key string) error {
+		found, err := p.LookupIDs(ctx, key, ids, mediaType)
+		if err != nil {
+			return err
+		}
+		ids = ids.Merge(found)
+		if ids.Imdb == "" || ids.Tmdb == 0 {
+			// Keep asking the others for the rest
+			return ErrNotSupported
+		}
+		return nil
+	})
+	return ids
+}
+
+// MediaDetails describes a movie or show. Artwork the details provider didn't
+// supply is filled in from the images providers, and ratings from the ratings providers.
+func (r *MetadataResolver) MediaDetails(ctx context.Context, ids MediaIDs, mediaType string) (*MediaDetails, error) {
+	details, err := r.MediaOverview(ctx, ids, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	full := ids.Merge(details.IDs())
+	if mediaType == "" {
+		mediaType = details.Type
+	}
+
+	if len(details.Ratings) == 0 {
+		r.try(MetadataRatings, func(p MetadataProvider, key string) error {
+			if p.Name() == details.Source {
+				// Already asked
+				return ErrNotSupported
+			}
+			rated, err := p.MediaDetails(ctx, key, full, mediaType)
+			if err != nil {
+				return err
+			}
+			if len(rated.Ratings) == 0 {
+				return ErrNotSupported
+			}
+			details.Ratings = rated.Ratings
+			return nil
+		})
+	}
+	if details.Ratings == nil {
+		details.Ratings = []Rating{}
+	}
+	return details, nil
+}
+
+// MediaOverview is MediaDetails without the ratings lookup, for lists that
+// only show titles and artwork
+func (r *MetadataResolver) MediaOverview(ctx context.Context, ids MediaIDs, mediaType string) (*MediaDetails, error) {
+	var details *MediaDetails
+	source, err := r.try(MetadataDetails, func(p MetadataProvider, key string) error {
+		d, err := p.MediaDetails(ctx, key, ids, mediaType)
+		if errors.Is(err, ErrNotSupported) {
+			// The provider may know the title by an ID we haven't got yet
+			if full := r.LookupIDs(ctx, ids, mediaType); full != ids {
+				d, err = p.MediaDetails(ctx, key, full, mediaType)
+			}
+		}
+		details = d
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	details.Source = source
+	full := ids.Merge(details.IDs())
+	if mediaType == "" {
+		mediaType = details.Type
+	}
+
+	// The details provider already returned all the artwork it has, so it isn't
+	// asked again. Many titles have no logo, so a missing logo alone is only
+	// looked for when that provider isn't an images source itself.
+	sourceHasImages := slices.ContainsFunc(r.candidates(MetadataImages), func(p MetadataProvider) bool { return p.Name() == source })
+	if details.Poster == "" || details.Backdrop == "" || (details.Logo == "" && !sourceHasImages) {
+		if images, err := r.mediaImages(ctx, full, mediaType, source); err == nil {
+			if details.Poster == "" {
+				details.Poster = images.Poster
+			}
+			if details.Backdrop == "" {
+				details.Backdrop = images.Backdrop
+			}
+			if details.Logo == "" {
+				details.Logo = images.Logo
+			}
+		}
+	}
+	return details, nil
+}
+
+// MediaImages returns artwork for a title, combining providers until each image is found
+func (r *MetadataResolver) MediaImages(ctx context.Context, ids MediaIDs, mediaType string) (*MediaImages, error) {
+	return r.mediaImages(ctx, ids, mediaType, "")
+}
+
+// mediaImages is MediaImages without asking the provider named skip
+func (r *MetadataResolver) mediaImages(ctx context.Context, ids MediaIDs, mediaType, skip string) (*MediaImages, error) {
+	merged := &MediaImages{}
+	full := ids
+	_, err := r.try(MetadataImages, func(p MetadataProvider, key string) error {
+		if p.Name() == skip {
+			return ErrNotSupported
+		}
+		images, err := p.MediaImages(ctx, key, full, mediaType)
+		if errors.Is(err, ErrNotSupported) && full == ids {
+			full = r.LookupIDs(ctx, ids, mediaType)
+			if full != ids {
+				images, err = p.MediaImages(ctx, key, full, mediaType)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if merged.Poster == "" {
+			merged.Poster = images.Poster
+		}
+		if merged.Backdrop == "" {
+			merged.Backdrop = images.Backdrop
+		}
+		if merged.Logo == "" {
+			merged.Logo = images.Logo
+		}
+		if merged.Poster == "" || merged.Backdrop == "" || merged.Logo == "" {
+			return ErrNotSupported
+		}
+		return nil
+	})
+	if err != nil && *merged == (MediaImages{}) {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// ShowSeasons lists a show's seasons
+func (r *MetadataResolver) ShowSeasons(ctx context.Context, ids MediaIDs) ([]SeasonSummary, error) {
+	var seasons []SeasonSummary
+	source, err := r.try(MetadataSeasons, func(p MetadataProvider, key string) error {
+		var err error
+		seasons, err = p.ShowSeasons(ctx, key, ids)
+		if errors.Is(err, ErrNotSupported) {
+			if full := r.LookupIDs(ctx, ids, "show"); full != ids {
+				seasons, err = p.ShowSeasons(ctx, key, full)
+			}
+		}
+		return err
+	})
+	for i := range seasons {
+		seasons[i].Source = source
+	}
+	return seasons, err
+}
+
+// SeasonEpisodes lists the episodes of one season
+func (r *MetadataResolver) SeasonEpisodes(ctx context.Context, ids MediaIDs, season int) (*SeasonDetails, error) {
+	var details *SeasonDetails
+	source, err := r.try(MetadataEpisodes, func(p MetadataProvider, key string) error {
+		d, err := p.SeasonEpisodes(ctx, key, ids, season)
+		if errors.Is(err, ErrNotSupported) {
+			if full := r.LookupIDs(ctx, ids, "show"); full != ids {
+				d, err = p.SeasonEpisodes(ctx, key, full, season)
+			}
+		}
+		details = d
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	details.Source = source
+	return details, nil
+}
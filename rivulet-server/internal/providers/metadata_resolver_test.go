@@ -0,0 +1,254 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stubMetadata answers from fixed data; nil fields mean the call isn't supported
+type stubMetadata struct {
+	name    string
+	details *MediaDetails
+	images  *MediaImages
+	seasons []SeasonSummary
+	ids     *MediaIDs
+	err     error // Returned by every call when set
+	calls   int
+	// imageCalls counts MediaImages calls separately, since details already carry artwork
+	imageCalls int
+}
+
+func (s *stubMetadata) Name() string { return s.name }
+
+func (s *stubMetadata) SearchMedia(ctx context.Context, apiKey, query string) ([]MediaSummary, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *stubMetadata) MediaDetails(ctx context.Context, apiKey string, ids MediaIDs, mediaType string) (*MediaDetails, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.details == nil {
+		return nil, ErrNotSupported
+	}
+	d := *s.details
+	return &d, nil
+}
+
+func (s *stubMetadata) ShowSeasons(ctx context.Context, apiKey string, ids MediaIDs) ([]SeasonSummary, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	// Like TMDB, seasons need the TMDB ID
+	if s.seasons == nil || ids.Tmdb == 0 {
+		return nil, ErrNotSupported
+	}
+	return s.seasons, nil
+}
+
+func (s *stubMetadata) SeasonEpisodes(ctx context.Context, apiKey string, ids MediaIDs, season int) (*SeasonDetails, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *stubMetadata) MediaImages(ctx context.Context, apiKey string, ids MediaIDs, mediaType string) (*MediaImages, error) {
+	s.imageCalls++
+	if s.images == nil {
+		return nil, ErrNotSupported
+	}
+	return s.images, nil
+}
+
+func (s *stubMetadata) LookupIDs(ctx context.Context, apiKey string, ids MediaIDs, mediaType string) (MediaIDs, error) {
+	if s.ids == nil {
+		return ids, ErrNotSupported
+	}
+	return ids.Merge(*s.ids), nil
+}
+
+func newResolver(plan map[string][]string, stubs ...*stubMetadata) *MetadataResolver {
+	r := &MetadataResolver{Providers: map[string]MetadataProvider{}, Plan: plan, Keys: map[string]string{}}
+	for _, s := range stubs {
+		r.Providers[s.name] = s
+		r.Keys[s.name] = "key"
+	}
+	return r
+}
+
+func TestResolverFallsBackOnFailure(t *testing.T) {
+	broken := &stubMetadata{name: "primary", err: errors.New("timeout")}
+	backup := &stubMetadata{name: "backup", details: &MediaDetails{Title: "Inception", Poster: "p", Backdrop: "b", Logo: "l"}}
+	r := newResolver(map[string][]string{MetadataDetails: {"primary", "backup"}}, broken, backup)
+
+	details, err := r.MediaDetails(context.Background(), MediaIDs{Imdb: "tt1375666"}, "movie")
+	if err != nil {
+		t.Fatalf("MediaDetails: %v", err)
+	}
+	if details.Title != "Inception" || details.Source != "backup" {
+		t.Errorf("got %q from %q, want Inception from backup", details.Title, details.Source)
+	}
+	if broken.calls != 1 {
+		t.Errorf("primary called %d times, want 1", broken.calls)
+	}
+}
+
+func TestResolverSkipsProvidersWithoutKey(t *testing.T) {
+	primary := &stubMetadata{name: "primary", details: &MediaDetails{Title: "A"}}
+	backup := &stubMetadata{name: "backup", details: &MediaDetails{Title: "B"}}
+	r := newResolver(map[string][]string{MetadataDetails: {"primary", "backup"}}, primary, backup)
+	delete(r.Keys, "primary")
+
+	details, err := r.MediaDetails(context.Background(), MediaIDs{Imdb: "tt1"}, "movie")
+	if err != nil || details.Source != "backup" || primary.calls != 0 {
+		t.Fatalf("got %+v, %v; primary called %d times", details, err, primary.calls)
+	}
+
+	delete(r.Keys, "backup")
+	if _, err := r.MediaDetails(context.Background(), MediaIDs{Imdb: "tt1"}, "movie"); !errors.Is(err, ErrMetadataUnavailable) {
+		t.Errorf("no keys: err = %v, want ErrMetadataUnavailable", err)
+	}
+}
+
+func TestResolverFillsArtworkPerField(t *testing.T) {
+	details := &stubMetadata{name: "details", details: &MediaDetails{Title: "Show", Poster: "details-poster"}}
+	art := &stubMetadata{name: "art", images: &MediaImages{Backdrop: "art-backdrop"}}
+	logos := &stubMetadata{name: "logos", images: &MediaImages{Poster: "logos-poster", Logo: "logos-logo"}}
+	r := newResolver(map[string][]string{
+		MetadataDetails: {"details"},
+		MetadataImages:  {"art", "logos"},
+	}, details, art, logos)
+
+	got, err := r.MediaDetails(context.Background(), MediaIDs{Imdb: "tt1"}, "show")
+	if err != nil {
+		t.Fatalf("MediaDetails: %v", err)
+	}
+	if got.Poster != "details-poster" || got.Backdrop != "art-backdrop" || got.Logo != "logos-logo" {
+		t.Errorf("artwork = %q / %q / %q", got.Poster, got.Backdrop, got.Logo)
+	}
+}
+
+func TestResolverLooksUpMissingIDs(t *testing.T) {
+	seasons := &stubMetadata{name: "seasons", seasons: []SeasonSummary{{SeasonNumber: 1}}}
+	mapper := &stubMetadata{name: "mapper", ids: &MediaIDs{Tmdb: 1396}}
+	r := newResolver(map[string][]string{
+		MetadataSeasons: {"seasons"},
+		MetadataIDs:     {"mapper"},
+	}, seasons, mapper)
+
+	got, err := r.ShowSeasons(context.Background(), MediaIDs{Imdb: "tt0903747"})
+	if err != nil {
+		t.Fatalf("ShowSeasons: %v", err)
+	}
+	if len(got) != 1 || got[0].Source != "seasons" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseMetadataPlan(t *testing.T) {
+	plan, err := ParseMetadataPlan("details = tmdb, mdblist ; images=tmdb")
+	if err != nil {
+		t.Fatalf("ParseMetadataPlan: %v", err)
+	}
+	if got := plan[MetadataDetails]; len(got) != 2 || got[0] != "tmdb" || got[1] != "mdblist" {
+		t.Errorf("details = %v", got)
+	}
+	if got := plan[MetadataSeasons]; len(got) != 1 || got[0] != "tmdb" {
+		t.Errorf("seasons kept default, got %v", got)
+	}
+	if _, err := ParseMetadataPlan("posters=tmdb"); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+func TestParseMediaID(t *testing.T) {
+	tests := map[string]MediaIDs{
+		"tt1375666":      {Imdb: "tt1375666"},
+		"imdb:tt1375666": {Imdb: "tt1375666"},
+		"tmdb:27205":     {Tmdb: 27205},
+		"tm27205":        {Tmdb: 27205},
+		"27205":          {Tmdb: 27205},
+		"trakt:1":        {},
+		"garbage":        {},
+	}
+	for in, want := range tests {
+		if got := ParseMediaID(in); got != want {
+			t.Errorf("ParseMediaID(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+}
+
+func TestResolverMergesRatingsWhenConfigured(t *testing.T) {
+	tmdb := &stubMetadata{name: "tmdb", details: &MediaDetails{Title: "Inception", Poster: "p", Backdrop: "b", Logo: "l"}}
+	mdblist := &stubMetadata{name: "mdblist", details: &MediaDetails{Title: "Inception", Ratings: []Rating{{Source: "imdb", Value: 8.8}}}}
+	plan := map[string][]string{
+		MetadataDetails: {"tmdb", "mdblist"},
+		MetadataRatings: {"mdblist"},
+	}
+
+	r := newResolver(plan, tmdb, mdblist)
+	got, err := r.MediaDetails(context.Background(), MediaIDs{Tmdb: 27205}, "movie")
+	if err != nil {
+		t.Fatalf("MediaDetails: %v", err)
+	}
+	if got.Source != "tmdb" || len(got.Ratings) != 1 || got.Ratings[0].Source != "imdb" {
+		t.Errorf("got %+v, want TMDB details with MDBList ratings", got)
+	}
+
+	// Without an MDBList key the TMDB record is returned as is
+	delete(r.Keys, "mdblist")
+	mdblist.calls = 0
+	got, err = r.MediaDetails(context.Background(), MediaIDs{Tmdb: 27205}, "movie")
+	if err != nil {
+		t.Fatalf("MediaDetails without MDBList: %v", err)
+	}
+	if got.Ratings == nil || len(got.Ratings) != 0 || mdblist.calls != 0 {
+		t.Errorf("got ratings %v and %d MDBList calls, want none", got.Ratings, mdblist.calls)
+	}
+}
+
+func TestResolverDoesNotReaskForArtwork(t *testing.T) {
+	tmdb := &stubMetadata{name: "tmdb", details: &MediaDetails{Title: "Inception", Poster: "p", Backdrop: "b"}, images: &MediaImages{Poster: "p", Backdrop: "b"}}
+	mdblist := &stubMetadata{name: "mdblist", images: &MediaImages{Poster: "mp", Backdrop: "mb", Logo: "ml"}}
+	r := newResolver(map[string][]string{
+		MetadataDetails: {"tmdb"},
+		MetadataImages:  {"tmdb", "mdblist"},
+	}, tmdb, mdblist)
+
+	// TMDB's details already carried all its artwork; a missing logo alone isn't looked for elsewhere
+	got, err := r.MediaDetails(context.Background(), MediaIDs{Tmdb: 27205}, "movie")
+	if err != nil {
+		t.Fatalf("MediaDetails: %v", err)
+	}
+	if got.Logo != "" || tmdb.imageCalls != 0 || mdblist.imageCalls != 0 {
+		t.Errorf("logo %q after %d/%d image calls, want no lookups", got.Logo, tmdb.imageCalls, mdblist.imageCalls)
+	}
+
+	// A missing poster is looked for, but only from the other images providers
+	tmdb.details.Poster = ""
+	got, err = r.MediaDetails(context.Background(), MediaIDs{Tmdb: 27205}, "movie")
+	if err != nil {
+		t.Fatalf("MediaDetails: %v", err)
+	}
+	if got.Poster != "mp" || got.Backdrop != "b" || got.Logo != "ml" || tmdb.imageCalls != 0 || mdblist.imageCalls != 1 {
+		t.Errorf("got %+v after %d/%d image calls", got, tmdb.imageCalls, mdblist.imageCalls)
+	}
+}
+
+func TestMediaOverviewSkipsRatings(t *testing.T) {
+	tmdb := &stubMetadata{name: "tmdb", details: &MediaDetails{Title: "Inception", Poster: "p", Backdrop: "b", Logo: "l"}}
+	mdblist := &stubMetadata{name: "mdblist", details: &MediaDetails{Ratings: []Rating{{Source: "imdb", Value: 8.8}}}}
+	r := newResolver(map[string][]string{
+		MetadataDetails: {"tmdb"},
+		MetadataRatings: {"mdblist"},
+	}, tmdb, mdblist)
+
+	got, err := r.MediaOverview(context.Background(), MediaIDs{Tmdb: 27205}, "movie")
+	if err != nil {
+		t.Fatalf("MediaOverview: %v", err)
+	}
+	if got.Title != "Inception" || len(got.Ratings) != 0 || mdblist.calls != 0 {
+		t.Errorf("got %+v after %d MDBList calls, want no ratings lookup", got, mdblist.calls)
+	}
+}
@@ -0,0 +1,244 @@
+package opensubtitles
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/release"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.opensubtitles.com/api/v1"
+
+// Client is a providers.SubtitleProvider for the OpenSubtitles.com REST API.
+// Downloads without a user login are limited per API key and IP.
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	UserAgent  string // OpenSubtitles rejects requests without an app User-Agent
+	HttpClient *http.Client
+}
+
+func NewClient(apiKey string) *Client {
+	return &Client{
+		BaseURL:   DefaultBaseURL,
+		APIKey:    apiKey,
+		UserAgent: "Rivulet v1.0",
+		HttpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+var _ providers.SubtitleProvider = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "opensubtitles"
+}
+
+// --- Models ---
+
+type searchResponse struct {
+	TotalCount int      `json:"total_count"`
+	Data       []result `json:"data"`
+}
+
+type result struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Language          string `json:"language"`
+		Release           string `json:"release"`
+		DownloadCount     int    `json:"download_count"`
+		HearingImpaired   bool   `json:"hearing_impaired"`
+		MachineTranslated bool   `json:"machine_translated"`
+		AITranslated      bool   `json:"ai_translated"`
+		MoviehashMatch    bool   `json:"moviehash_match"`
+		Files             []struct {
+			FileID   int    `json:"file_id"`
+			FileName string `json:"file_name"`
+		} `json:"files"`
+	} `json:"attributes"`
+}
+
+type downloadResponse struct {
+	Link      string `json:"link"`
+	FileName  string `json:"file_name"`
+	Remaining int    `json:"remaining"`
+	Message   string `json:"message"`
+}
+
+// --- SubtitleProvider ---
+
+func (c *Client) Search(ctx context.Context, q providers.SubtitleQuery) ([]providers.Subtitle, error) {
+	imdbID := imdbNumber(q.ImdbID)
+	if imdbID == "" && q.MovieHash == "" {
+		return nil, fmt.Errorf("OpenSubtitles: an IMDb ID or movie hash is required")
+	}
+
+	// The API redirects unless parameters are sorted and lowercase; Encode sorts them
+	params := url.Values{}
+	if imdbID != "" {
+		if q.Season > 0 {
+			params.Set("parent_imdb_id", imdbID)
+			params.Set("season_number", strconv.Itoa(q.Season))
+			if q.Episode > 0 {
+				params.Set("episode_number", strconv.Itoa(q.Episode))
+			}
+		} else {
+			params.Set("imdb_id", imdbID)
+		}
+	}
+	if len(q.Languages) > 0 {
+		langs := make([]string, len(q.Languages))
+		for i, lang := range q.Languages {
+			langs[i] = strings.ToLower(lang)
+		}
+		sort.Strings(langs)
+		params.Set("languages", strings.Join(langs, ","))
+	}
+	if q.MovieHash != "" {
+		params.Set("moviehash", strings.ToLower(q.MovieHash))
+	}
+
+	var res searchResponse
+	if err := c.do(ctx, "GET", "/subtitles?"+params.Encode(), nil, &res); err != nil {
+		return nil, err
+	}
+
+	var wanted release.Info
+	if q.Release != "" {
+		wanted = release.Parse(q.Release)
+	}
+
+	var subs []providers.Subtitle
+	for _, r := range res.Data {
+		a := r.Attributes
+		// Multi-CD subtitles list one file per CD; each is offered on its own
+		for _, f := range a.Files {
+			subs = append(subs, providers.Subtitle{
+				ID:                strconv.Itoa(f.FileID),
+				Provider:          c.Name(),
+				Language:          a.Language,
+				Release:           a.Release,
+				FileName:          f.FileName,
+				Format:            "srt",
+				HearingImpaired:   a.HearingImpaired,
+				MachineTranslated: a.MachineTranslated || a.AITranslated,
+				Downloads:         a.DownloadCount,
+				HashMatch:         a.MoviehashMatch,
+				ReleaseMatch:      q.Release != "" && sameRelease(wanted, a.Release),
+			})
+		}
+	}
+	return subs, nil
+}
+
+func (c *Client) Download(ctx context.Context, id string) ([]byte, error) {
+	fileID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, providers.ErrSubtitleNotFound
+	}
+
+	body, _ := json.Marshal(map[string]any{"file_id": fileID, "sub_format": "srt"})
+	var res downloadResponse
+	if err := c.do(ctx, "POST", "/download", bytes.NewReader(body), &res); err != nil {
+		return nil, err
+	}
+	if res.Link == "" {
+		return nil, fmt.Errorf("OpenSubtitles download: %s", res.Message)
+	}
+	if res.Remaining < 5 {
+		log.Printf("⚠️ OpenSubtitles: %d downloads left today", res.Remaining)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", res.Link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenSubtitles file: %s", resp.Status)
+	}
+	// Subtitles are small; anything bigger isn't one
+	return io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+}
+
+// --- Helpers ---
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body io.Reader, out any) error {
+	u := c.BaseURL + endpoint
+	log.Printf("OS Request: %s %s", method, strings.SplitN(u, "?", 2)[0])
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Api-Key", c.APIKey)
+	req.Header.Set("User-Agent", c.UserAgent)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	path := strings.SplitN(endpoint, "?", 2)[0]
+	if resp.StatusCode == http.StatusNotFound {
+		return providers.ErrSubtitleNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		// Errors carry a message, e.g. the daily download quota running out (406)
+		var apiErr struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		msg := apiErr.Message
+		if msg == "" && len(apiErr.Errors) > 0 {
+			msg = strings.Join(apiErr.Errors, "; ")
+		}
+		if msg == "" {
+			msg = resp.Status
+		}
+		return fmt.Errorf("OpenSubtitles %s: %s", path, msg)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// imdbNumber turns "tt0903747" into "903747", the form OpenSubtitles expects
+func imdbNumber(imdbID string) string {
+	n := strings.TrimLeft(strings.TrimPrefix(strings.ToLower(imdbID), "tt"), "0")
+	if _, err := strconv.Atoi(n); err != nil {
+		return ""
+	}
+	return n
+}
+
+// sameRelease reports whether a subtitle's release name is the same encode as the stream,
+// so its timing will line up
+func sameRelease(wanted release.Info, name string) bool {
+	if wanted.Group == "" || name == "" {
+		return false
+	}
+	got := release.Parse(name)
+	if !strings.EqualFold(got.Group, wanted.Group) {
+		return false
+	}
+	return got.Source == "" || wanted.Source == "" || got.Source == wanted.Source
+}
@@ -0,0 +1,150 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeOpenSubtitles records the searches it gets and hands out files while
+// the daily quota lasts
+type fakeOpenSubtitles struct {
+	url       string
+	results   string         // Raw /subtitles data array
+	files     map[int]string // File ID to contents
+	remaining int
+	searches  []url.Values
+}
+
+func (f *fakeOpenSubtitles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Temporary download links work without the API key
+	if id, ok := strings.CutPrefix(r.URL.Path, "/files/"); ok {
+		n, _ := strconv.Atoi(id)
+		contents, ok := f.files[n]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(contents))
+		return
+	}
+	if r.Header.Get("Api-Key") != "test-key" || r.Header.Get("User-Agent") == "" {
+		http.Error(w, `{"message":"invalid api key"}`, http.StatusUnauthorized)
+		return
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /subtitles":
+		f.searches = append(f.searches, r.URL.Query())
+		w.Write([]byte(`{"total_count": 1, "data": ` + f.results + `}`))
+	case "POST /download":
+		var body struct {
+			FileID int `json:"file_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := f.files[body.FileID]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if f.remaining == 0 {
+			w.WriteHeader(http.StatusNotAcceptable)
+			w.Write([]byte(`{"message":"You have downloaded your allowed 5 subtitles for 24h"}`))
+			return
+		}
+		f.remaining--
+		json.NewEncoder(w).Encode(map[string]any{"link": f.url + "/files/" + strconv.Itoa(body.FileID), "remaining": f.remaining})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeOpenSubtitles) client(t *testing.T) *Client {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	f.url = srv.URL
+	c := NewClient("test-key")
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestSearchEpisode(t *testing.T) {
+	fake := &fakeOpenSubtitles{results: `[
+		{"id": "1", "attributes": {"language": "en", "release": "Breaking.Bad.S01E02.1080p.BluRay.x264-ROVERS",
+			"download_count": 900, "hearing_impaired": true, "moviehash_match": true,
+			"files": [{"file_id": 101, "file_name": "bb.s01e02.srt"}]}},
+		{"id": "2", "attributes": {"language": "fr", "release": "Breaking.Bad.S01E02.720p.HDTV.x264-CTU",
+			"download_count": 50, "ai_translated": true,
+			"files": [{"file_id": 201, "file_name": "cd1.srt"}, {"file_id": 202, "file_name": "cd2.srt"}]}}
+	]`}
+
+	subs, err := fake.client(t).Search(context.Background(), providers.SubtitleQuery{
+		ImdbID:    "tt0903747",
+		Season:    1,
+		Episode:   2,
+		Languages: []string{"FR", "en"},
+		MovieHash: "8e245d9679d31e12",
+		Release:   "Breaking.Bad.S01E02.2160p.BluRay.x265-ROVERS",
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	if len(fake.searches) != 1 {
+		t.Fatalf("sent %d searches, want 1", len(fake.searches))
+	}
+	q := fake.searches[0]
+	if q.Get("parent_imdb_id") != "903747" || q.Get("season_number") != "1" || q.Get("episode_number") != "2" || q.Has("imdb_id") {
+		t.Errorf("episode params = %v", q)
+	}
+	if q.Get("languages") != "en,fr" || q.Get("moviehash") != "8e245d9679d31e12" {
+		t.Errorf("languages %q, hash %q; want sorted lowercase languages and the hash", q.Get("languages"), q.Get("moviehash"))
+	}
+
+	if len(subs) != 3 {
+		t.Fatalf("got %d subtitles, want one per file (3)", len(subs))
+	}
+	en := subs[0]
+	if en.ID != "101" || en.Language != "en" || en.Provider != "opensubtitles" || en.Format != "srt" {
+		t.Errorf("unexpected subtitle: %+v", en)
+	}
+	if !en.HashMatch || !en.ReleaseMatch || !en.HearingImpaired || en.Downloads != 900 {
+		t.Errorf("flags not mapped: %+v", en)
+	}
+	if fr := subs[1]; fr.ReleaseMatch || !fr.MachineTranslated || fr.ID != "201" || subs[2].ID != "202" {
+		t.Errorf("unexpected fr subtitles: %+v %+v", fr, subs[2])
+	}
+}
+
+func TestSearchRequiresID(t *testing.T) {
+	fake := &fakeOpenSubtitles{results: `[]`}
+	if _, err := fake.client(t).Search(context.Background(), providers.SubtitleQuery{ImdbID: "nope"}); err == nil {
+		t.Fatal("expected an error without an IMDb ID or hash")
+	}
+	if len(fake.searches) != 0 {
+		t.Error("searched without an ID")
+	}
+}
+
+func TestDownloadUntilQuotaRunsOut(t *testing.T) {
+	const srt = "1\n00:00:01,000 --> 00:00:02,000\nHello\n"
+	fake := &fakeOpenSubtitles{files: map[int]string{101: srt}, remaining: 1}
+	c := fake.client(t)
+	ctx := context.Background()
+
+	data, err := c.Download(ctx, "101")
+	if err != nil || string(data) != srt {
+		t.Fatalf("Download = %q, %v", data, err)
+	}
+	if _, err := c.Download(ctx, "404"); !errors.Is(err, providers.ErrSubtitleNotFound) {
+		t.Errorf("missing file: err = %v, want ErrSubtitleNotFound", err)
+	}
+	if _, err := c.Download(ctx, "101"); err == nil || errors.Is(err, providers.ErrSubtitleNotFound) {
+		t.Errorf("quota: err = %v, want the API message", err)
+	}
+}
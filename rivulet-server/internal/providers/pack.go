@@ -0,0 +1,57 @@
+package providers
+
+// Pack kinds describing how much of a show a stream covers
+const (
+	PackEpisode      = "episode"       // A single episode
+	PackMultiEpisode = "multi_episode" // A range of episodes, e.g. "S01E01-E03"
+	PackSeason       = "season"        // One full season
+	PackComplete     = "complete"      // Several seasons or the whole series
+)
+
+// Coverage describes which seasons and episodes a show stream contains
+type Coverage struct {
+	Kind     string `json:"kind"`
+	Seasons  []int  `json:"seasons,omitempty"`
+	Episodes []int  `json:"episodes,omitempty"` // Empty for season and complete packs
+	// FileMapped means the addon already pointed FileIndex at the requested
+	// episode inside a pack, so resolving doesn't need to search the torrent
+	FileMapped bool `json:"file_mapped,omitempty"`
+}
+
+// IsPack reports whether the stream covers more than one episode
+func (c *Coverage) IsPack() bool {
+	return c != nil && c.Kind != PackEpisode
+}
+
+// ClassifyCoverage works out what a show stream covers from its parsed release name.
+// Returns nil when the name carries no season or episode markers.
+func ClassifyCoverage(s *Stream) *Coverage {
+	info := s.Release
+	cov := &Coverage{Seasons: info.Seasons, Episodes: info.Episodes}
+
+	switch {
+	case len(info.Seasons) > 1:
+		cov.Kind = PackComplete
+		cov.Episodes = nil
+	case len(info.Episodes) > 1:
+		cov.Kind = PackMultiEpisode
+	case len(info.Episodes) == 1:
+		cov.Kind = PackEpisode
+	case len(info.Seasons) == 1:
+		cov.Kind = PackSeason
+	case info.Complete:
+		cov.Kind = PackComplete
+	default:
+		return nil
+	}
+
+	cov.FileMapped = cov.IsPack() && s.FileIndex != nil
+	return cov
+}
+
+// ClassifyPacks fills Coverage on every stream of a show scrape
+func ClassifyPacks(streams []*Stream) {
+	for _, s := range streams {
+		s.Coverage = ClassifyCoverage(s)
+	}
+}
@@ -0,0 +1,290 @@
+package premiumize
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"rivulet_server/internal/providers"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	BaseURL    string
+	HttpClient *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{
+		BaseURL: "https://www.premiumize.me/api",
+		HttpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+var _ providers.DebridService = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "premiumize"
+}
+
+// --- Models ---
+
+// Every Premiumize response carries a status and, on failure, a message
+type status struct {
+	Status  string `json:"status"` // "success" or "error"
+	Message string `json:"message"`
+}
+
+type Transfer struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`   // "waiting", "queued", "running", "seeding", "finished", "error", ...
+	Progress float64 `json:"progress"` // 0-1
+	Src      string  `json:"src"`      // The magnet it was created from
+	Message  string  `json:"message"`
+}
+
+type ContentFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Link string `json:"link"`
+}
+
+var btihRegex = regexp.MustCompile(`(?i)btih:([a-z0-9]+)`)
+
+// --- DebridService ---
+
+func (c *Client) AddTorrent(ctx context.Context, token, magnet string) (string, error) {
+	form := url.Values{}
+	form.Set("src", magnet)
+
+	var res struct {
+		status
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, "POST", "/transfer/create", token, form, &res); err != nil {
+		return "", err
+	}
+	return res.ID, nil
+}
+
+// TorrentStatus looks the transfer up in the account's list. Finished transfers
+// are resolved through directdl, which returns every file with a direct link.
+func (c *Client) TorrentStatus(ctx context.Context, token, id string) (*providers.DebridTorrent, error) {
+	var list struct {
+		status
+		Transfers []Transfer `json:"transfers"`
+	}
+	if err := c.do(ctx, "GET", "/transfer/list", token, nil, &list); err != nil {
+		return nil, err
+	}
+
+	var transfer *Transfer
+	for i := range list.Transfers {
+		if list.Transfers[i].ID == id {
+			transfer = &list.Transfers[i]
+			break
+		}
+	}
+	if transfer == nil {
+		// Deleted transfers drop out of the list
+		return nil, fmt.Errorf("%w: premiumize transfer %s", providers.ErrDebridTorrentNotFound, id)
+	}
+
+	t := &providers.DebridTorrent{
+		ID:       transfer.ID,
+		Name:     transfer.Name,
+		Status:   providers.DebridStatusDownloading,
+		Progress: int(transfer.Progress * 100),
+	}
+	if m := btihRegex.FindStringSubmatch(transfer.Src); len(m) > 1 {
+		t.Hash = strings.ToLower(m[1])
+	}
+
+	switch transfer.Status {
+	case "finished", "seeding":
+		t.Status = providers.DebridStatusDownloaded
+		t.Progress = 100
+	case "error", "deleted", "banned", "timeout":
+		t.Status = providers.DebridStatusError
+		return t, nil
+	default:
+		return t, nil
+	}
+
+	files, err := c.directDL(ctx, token, transfer.Src)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range files {
+		t.Files = append(t.Files, providers.DebridFile{
+			ID:       i + 1,
+			Path:     f.Path,
+			Bytes:    f.Size,
+			Selected: true,
+			Link:     f.Link,
+		})
+	}
+	return t, nil
+}
+
+// SelectTorrentFiles is a no-op: Premiumize always downloads the whole torrent
+func (c *Client) SelectTorrentFiles(ctx context.Context, token, id string, fileIDs []int) error {
+	return nil
+}
+
+// UnrestrictFile returns the link unchanged: directdl links are already direct
+func (c *Client) UnrestrictFile(ctx context.Context, token, link string) (*providers.DebridLink, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	filename := u.Path
+	if i := strings.LastIndex(filename, "/"); i >= 0 {
+		filename = filename[i+1:]
+	}
+	return &providers.DebridLink{Filename: filename, Download: link}, nil
+}
+
+func (c *Client) CheckAvailability(ctx context.Context, token string, hashes []string) (map[string][]providers.CachedFile, error) {
+	available := make(map[string][]providers.CachedFile)
+	if len(hashes) == 0 {
+		return available, nil
+	}
+
+	params := url.Values{}
+	for _, h := range hashes {
+		params.Add("items[]", h)
+	}
+
+	// Parallel arrays, one entry per requested item
+	var res struct {
+		status
+		Response []bool   `json:"response"`
+		Filename []string `json:"filename"`
+		Filesize []any    `json:"filesize"` // Numbers or numeric strings
+	}
+	if err := c.do(ctx, "GET", "/cache/check", token, params, &res); err != nil {
+		return nil, err
+	}
+
+	for i, cached := range res.Response {
+		if !cached || i >= len(hashes) {
+			continue
+		}
+		// Only the main file is reported
+		file := providers.CachedFile{ID: 1}
+		if i < len(res.Filename) {
+			file.Path = res.Filename[i]
+		}
+		if i < len(res.Filesize) {
+			file.Bytes = toInt64(res.Filesize[i])
+		}
+		available[strings.ToLower(hashes[i])] = []providers.CachedFile{file}
+	}
+	return available, nil
+}
+
+func (c *Client) AccountInfo(ctx context.Context, token string) (*providers.DebridAccount, error) {
+	var res struct {
+		status
+		CustomerID   any   `json:"customer_id"`
+		PremiumUntil int64 `json:"premium_until"` // Unix seconds, 0 for free accounts
+	}
+	if err := c.do(ctx, "GET", "/account/info", token, nil, &res); err != nil {
+		return nil, err
+	}
+
+	account := &providers.DebridAccount{
+		Service:  c.Name(),
+		Username: fmt.Sprint(res.CustomerID),
+	}
+	if res.PremiumUntil > 0 {
+		account.ExpiresAt = time.Unix(res.PremiumUntil, 0)
+		account.Premium = time.Now().Before(account.ExpiresAt)
+		account.PremiumDays = max(0, int(time.Until(account.ExpiresAt).Hours()/24))
+	}
+	return account, nil
+}
+
+func (c *Client) directDL(ctx context.Context, token, src string) ([]ContentFile, error) {
+	form := url.Values{}
+	form.Set("src", src)
+
+	var res struct {
+		status
+		Content []ContentFile `json:"content"`
+	}
+	if err := c.do(ctx, "POST", "/transfer/directdl", token, form, &res); err != nil {
+		return nil, err
+	}
+	return res.Content, nil
+}
+
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case float64:
+		return int64(n)
+	case string:
+		var i int64
+		fmt.Sscan(n, &i)
+		return i
+	}
+	return 0
+}
+
+// do sends an authenticated request and decodes the response into out,
+// which must embed status so API errors can be detected
+func (c *Client) do(ctx context.Context, method, endpoint, token string, params url.Values, out any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	// API keys go in the query string, for POSTs too
+	query := url.Values{}
+	body := strings.NewReader("")
+	if method == "POST" {
+		body = strings.NewReader(params.Encode())
+	} else {
+		query = params
+	}
+	query.Set("apikey", token)
+
+	log.Printf("PM Request: %s %s", method, c.BaseURL+endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+endpoint+"?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw := json.NewDecoder(resp.Body)
+	var payload json.RawMessage
+	if err := raw.Decode(&payload); err != nil {
+		return fmt.Errorf("Premiumize %s: %s", endpoint, resp.Status)
+	}
+
+	var st status
+	if err := json.Unmarshal(payload, &st); err != nil {
+		return err
+	}
+	if st.Status != "success" {
+		if st.Message != "" {
+			return fmt.Errorf("Premiumize %s: %s", endpoint, st.Message)
+		}
+		return fmt.Errorf("Premiumize %s: %s", endpoint, resp.Status)
+	}
+	return json.Unmarshal(payload, out)
+}
@@ -0,0 +1,168 @@
+package premiumize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"strings"
+	"testing"
+)
+
+const testMagnet = "magnet:?xt=urn:btih:ABCDEF&dn=Show.S01"
+
+// canned answers requests by "METHOD /endpoint" without a network round trip
+// and keeps each request's form for inspection
+type canned struct {
+	replies map[string]string
+	seen    map[string]url.Values
+}
+
+func (c *canned) RoundTrip(r *http.Request) (*http.Response, error) {
+	endpoint := strings.TrimPrefix(r.URL.Path, "/api")
+	form := r.URL.Query()
+	if r.Body != nil {
+		body, _ := io.ReadAll(r.Body)
+		posted, _ := url.ParseQuery(string(body))
+		for k, v := range posted {
+			form[k] = v
+		}
+	}
+	c.seen[r.Method+" "+endpoint] = form
+
+	reply, ok := c.replies[r.Method+" "+endpoint]
+	status := http.StatusOK
+	if !ok {
+		status, reply = http.StatusNotFound, "404 page not found"
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(reply)),
+		Request:    r,
+	}, nil
+}
+
+func cannedClient(replies map[string]string) (*Client, *canned) {
+	transport := &canned{replies: replies, seen: map[string]url.Values{}}
+	c := NewClient()
+	c.HttpClient = &http.Client{Transport: transport}
+	return c, transport
+}
+
+const transfers = `{"status": "success", "transfers": [
+	{"id": "done", "name": "Show.S01", "status": "finished", "progress": 1, "src": "` + testMagnet + `"},
+	{"id": "busy", "name": "Movie", "status": "running", "progress": 0.4, "src": "magnet:?xt=urn:btih:123"},
+	{"id": "dead", "name": "Old", "status": "error", "message": "Could not download"}
+]}`
+
+func TestFinishedTransferListsDirectDLFiles(t *testing.T) {
+	c, transport := cannedClient(map[string]string{
+		"GET /transfer/list": transfers,
+		"POST /transfer/directdl": `{"status": "success", "content": [
+			{"path": "Show.S01/E01.mkv", "size": 100, "link": "https://cdn.premiumize.me/dl/E01.mkv"},
+			{"path": "Show.S01/E02.mkv", "size": 200, "link": "https://cdn.premiumize.me/dl/E02.mkv"}
+		]}`,
+	})
+	ctx := context.Background()
+
+	torrent, err := c.TorrentStatus(ctx, "test-key", "done")
+	if err != nil {
+		t.Fatalf("TorrentStatus: %v", err)
+	}
+	if torrent.Status != providers.DebridStatusDownloaded || torrent.Hash != "abcdef" || len(torrent.Files) != 2 {
+		t.Fatalf("got status %q, hash %q, %d files", torrent.Status, torrent.Hash, len(torrent.Files))
+	}
+	for i, f := range torrent.Files {
+		if f.ID != i+1 || !f.Selected {
+			t.Errorf("file %d = %+v, want ID %d", i, f, i+1)
+		}
+	}
+
+	// The API key goes in the query string for POSTs too
+	directdl := transport.seen["POST /transfer/directdl"]
+	if directdl.Get("apikey") != "test-key" || directdl.Get("src") != testMagnet {
+		t.Errorf("directdl form = %v", directdl)
+	}
+
+	// directdl links are already direct
+	link, err := c.UnrestrictFile(ctx, "test-key", torrent.Files[1].Link)
+	if err != nil || link.Download != "https://cdn.premiumize.me/dl/E02.mkv" || link.Filename != "E02.mkv" {
+		t.Errorf("UnrestrictFile = %+v, %v", link, err)
+	}
+}
+
+func TestTransferStates(t *testing.T) {
+	c, _ := cannedClient(map[string]string{"GET /transfer/list": transfers})
+	ctx := context.Background()
+
+	busy, err := c.TorrentStatus(ctx, "test-key", "busy")
+	if err != nil || busy.Status != providers.DebridStatusDownloading || busy.Progress != 40 || len(busy.Files) != 0 {
+		t.Errorf("running transfer: %+v, %v", busy, err)
+	}
+	dead, err := c.TorrentStatus(ctx, "test-key", "dead")
+	if err != nil || dead.Status != providers.DebridStatusError {
+		t.Errorf("failed transfer: %+v, %v", dead, err)
+	}
+	if _, err := c.TorrentStatus(ctx, "test-key", "deleted"); !errors.Is(err, providers.ErrDebridTorrentNotFound) {
+		t.Errorf("deleted transfer: err = %v, want ErrDebridTorrentNotFound", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := cannedClient(map[string]string{
+		"GET /transfer/list":    `{"status": "error", "message": "Not logged in."}`,
+		"POST /transfer/create": `{"status": "error", "message": "Invalid magnet."}`,
+	})
+	ctx := context.Background()
+
+	_, err := c.TorrentStatus(ctx, "wrong", "done")
+	if err == nil || !strings.Contains(err.Error(), "Not logged in") || errors.Is(err, providers.ErrDebridTorrentNotFound) {
+		t.Errorf("bad key: err = %v", err)
+	}
+	if _, err := c.AddTorrent(ctx, "test-key", testMagnet); err == nil || !strings.Contains(err.Error(), "Invalid magnet") {
+		t.Errorf("rejected magnet: err = %v", err)
+	}
+	if _, err := c.AccountInfo(ctx, "test-key"); err == nil {
+		t.Error("missing endpoint didn't fail")
+	}
+}
+
+func TestAddTorrent(t *testing.T) {
+	c, transport := cannedClient(map[string]string{
+		"POST /transfer/create": `{"status": "success", "id": "new-transfer", "type": "torrent"}`,
+	})
+
+	id, err := c.AddTorrent(context.Background(), "test-key", testMagnet)
+	if err != nil || id != "new-transfer" {
+		t.Errorf("AddTorrent = %q, %v", id, err)
+	}
+	if src := transport.seen["POST /transfer/create"].Get("src"); src != testMagnet {
+		t.Errorf("create src = %q", src)
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	c, transport := cannedClient(map[string]string{
+		"GET /cache/check": `{"status": "success", "response": [true, false], "filename": ["Movie.mkv", null], "filesize": ["1234", null]}`,
+	})
+
+	available, err := c.CheckAvailability(context.Background(), "test-key", []string{"AAA", "bbb"})
+	if err != nil {
+		t.Fatalf("CheckAvailability: %v", err)
+	}
+	if len(available) != 1 {
+		t.Fatalf("got %+v, want only aaa", available)
+	}
+	files := available["aaa"]
+	if len(files) != 1 || files[0] != (providers.CachedFile{ID: 1, Path: "Movie.mkv", Bytes: 1234}) {
+		t.Errorf("files = %+v", files)
+	}
+	if items := transport.seen["GET /cache/check"]["items[]"]; len(items) != 2 {
+		t.Errorf("checked items = %v", items)
+	}
+}
@@ -2,22 +2,47 @@ package realdebrid
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
+	"rivulet_server/internal/providers"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Client struct {
 	BaseURL    string
+	OAuthURL   string
 	HttpClient *http.Client
+
+	// Refresher, when set, is asked for a new access token when RD rejects one
+	// as expired. The request is then retried once with the new token.
+	Refresher TokenRefresher
+
+	// Tokens the Refresher replaced, so callers still holding one switch to
+	// its replacement instead of each being rejected and refreshing again
+	refreshMu sync.Mutex
+	renewedMu sync.Mutex
+	renewed   map[string]renewal
 }
 
+type renewal struct {
+	token string
+	at    time.Time
+}
+
+// renewalTTL is how long a replaced token keeps being swapped for its replacement
+const renewalTTL = 24 * time.Hour
+
 func NewClient() *Client {
 	return &Client{
-		BaseURL: "https://api.real-debrid.com/rest/1.0",
+		BaseURL:  "https://api.real-debrid.com/rest/1.0",
+		OAuthURL: "https://api.real-debrid.com/oauth/v2",
 		HttpClient: &http.Client{
 			Timeout: 20 * time.Second,
 		},
@@ -30,8 +55,20 @@ type User struct {
 	ID       int    `json:"id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
-	Premium  int    `json:"premium"` // Days left
-	Type     string `json:"type"`    // "premium" or "free"
+	Premium    int    `json:"premium"` // Seconds of premium left
+	Type       string `json:"type"`    // "premium" or "free"
+	Expiration string `json:"expiration"` // RFC 3339, end of premium
+	Points     int    `json:"points"`     // Fidelity points
+}
+
+// Traffic is the quota RD applies to one hoster
+type Traffic struct {
+	Left  int64  `json:"left"`  // Remaining, in Type units
+	Bytes int64  `json:"bytes"` // Downloaded today
+	Links int    `json:"links"` // Links unrestricted today
+	Limit int64  `json:"limit"`
+	Type  string `json:"type"`  // "links", "gigabytes" or "bytes"
+	Reset string `json:"reset"` // "daily", "weekly" or "monthly"
 }
 
 type UnrestrictRequest struct {
@@ -53,6 +90,7 @@ type File struct {
 
 type TorrentInfo struct {
 	ID       string `json:"id"`
+	Hash     string `json:"hash"`
 	Filename string `json:"filename"`
 	Status   string `json:"status"`
 	Progress int    `json:"progress"`
@@ -63,11 +101,11 @@ type TorrentInfo struct {
 // --- Methods ---
 
 // AddMagnet adds the torrent and returns the ID
-func (c *Client) AddMagnet(token, magnet string) (string, error) {
+func (c *Client) AddMagnet(ctx context.Context, token, magnet string) (string, error) {
 	data := url.Values{}
 	data.Set("magnet", magnet)
 
-	resp, err := c.doRequest("POST", "/torrents/addMagnet", token, []byte(data.Encode()))
+	resp, err := c.doRequestContext(ctx, "POST", "/torrents/addMagnet", token, []byte(data.Encode()))
 	if err != nil {
 		return "", err
 	}
@@ -87,13 +125,21 @@ func (c *Client) AddMagnet(token, magnet string) (string, error) {
 }
 
 // GetTorrentInfo checks the status
-func (c *Client) GetTorrentInfo(token, id string) (*TorrentInfo, error) {
-	resp, err := c.doRequest("GET", "/torrents/info/"+id, token, nil)
+func (c *Client) GetTorrentInfo(ctx context.Context, token, id string) (*TorrentInfo, error) {
+	resp, err := c.doRequestContext(ctx, "GET", "/torrents/info/"+id, token, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	switch resp.StatusCode {
+	case 200:
+	case 404:
+		return nil, providers.ErrDebridTorrentNotFound
+	default:
+		return nil, fmt.Errorf("RD Torrent Info Failed: %s", resp.Status)
+	}
+
 	var info TorrentInfo
 	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
 		return nil, err
@@ -101,13 +147,36 @@ func (c *Client) GetTorrentInfo(token, id string) (*TorrentInfo, error) {
 	return &info, nil
 }
 
+// ListTorrents returns one page of the account's torrents, newest first
+func (c *Client) ListTorrents(ctx context.Context, token string, page, limit int) ([]TorrentInfo, error) {
+	resp, err := c.doRequestContext(ctx, "GET", fmt.Sprintf("/torrents?page=%d&limit=%d", page, limit), token, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 204 means the page is past the end of the list
+	if resp.StatusCode == 204 {
+		return nil, nil
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("RD List Torrents Failed: %s", resp.Status)
+	}
+
+	var torrents []TorrentInfo
+	if err := json.NewDecoder(resp.Body).Decode(&torrents); err != nil {
+		return nil, err
+	}
+	return torrents, nil
+}
+
 // SelectFiles is needed because sometimes a magnet stays in "waiting_files_selection"
 // status until you explicitly say "Download everything"
-func (c *Client) SelectFiles(token, id string, fileIDs string) error {
+func (c *Client) SelectFiles(ctx context.Context, token, id string, fileIDs string) error {
 	data := url.Values{}
 	data.Set("files", fileIDs) // "all" or "1,2,3"
 
-	resp, err := c.doRequest("POST", fmt.Sprintf("/torrents/selectFiles/%s", id), token, []byte(data.Encode()))
+	resp, err := c.doRequestContext(ctx, "POST", fmt.Sprintf("/torrents/selectFiles/%s", id), token, []byte(data.Encode()))
 	if err != nil {
 		return err
 	}
@@ -119,11 +188,124 @@ func (c *Client) SelectFiles(token, id string, fileIDs string) error {
 	return nil
 }
 
-// Helper to add Auth Header
-func (c *Client) doRequest(method, endpoint, token string, body []byte) (*http.Response, error) {
+// AvailableFile is a file RD can serve instantly from its cache
+type AvailableFile struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+	Filesize int64  `json:"filesize"`
+}
+
+// InstantAvailability checks which hashes are already cached on RD.
+// The result maps lowercase hashes to the files of the first cached variant.
+// RD has restricted this endpoint for many accounts, so callers should treat errors as "unknown".
+func (c *Client) InstantAvailability(ctx context.Context, token string, hashes []string) (map[string][]AvailableFile, error) {
+	available := make(map[string][]AvailableFile)
+	if len(hashes) == 0 {
+		return available, nil
+	}
+
+	resp, err := c.doRequestContext(ctx, "GET", "/torrents/instantAvailability/"+strings.Join(hashes, "/"), token, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("RD Availability Failed: %s", resp.Status)
+	}
+
+	// { "<hash>": { "rd": [ { "<fileId>": { "filename": "...", "filesize": 123 } } ] } }
+	// Uncached hashes come back as an empty array instead of an object.
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	for hash, entry := range raw {
+		var hosters map[string][]map[string]struct {
+			Filename string `json:"filename"`
+			Filesize int64  `json:"filesize"`
+		}
+		if err := json.Unmarshal(entry, &hosters); err != nil {
+			continue
+		}
+		variants := hosters["rd"]
+		if len(variants) == 0 {
+			continue
+		}
+
+		var files []AvailableFile
+		for id, f := range variants[0] {
+			fileID, err := strconv.Atoi(id)
+			if err != nil {
+				continue
+			}
+			files = append(files, AvailableFile{ID: fileID, Filename: f.Filename, Filesize: f.Filesize})
+		}
+		available[strings.ToLower(hash)] = files
+	}
+
+	return available, nil
+}
+
+// doRequestContext sends an authenticated request, refreshing an expired OAuth token once
+func (c *Client) doRequestContext(ctx context.Context, method, endpoint, token string, body []byte) (*http.Response, error) {
+	token = c.currentToken(token)
+	resp, err := c.send(ctx, method, endpoint, token, body)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.Refresher == nil {
+		return resp, err
+	}
+
+	// Expired OAuth token: refresh it, unless a request that was in flight
+	// alongside this one already did, and try again
+	c.refreshMu.Lock()
+	fresh := c.currentToken(token)
+	if fresh == token {
+		var refreshErr error
+		fresh, refreshErr = c.Refresher(ctx, token)
+		if refreshErr != nil || fresh == "" || fresh == token {
+			c.refreshMu.Unlock()
+			return resp, err
+		}
+		c.rememberRenewal(token, fresh)
+	}
+	c.refreshMu.Unlock()
+	resp.Body.Close()
+	return c.send(ctx, method, endpoint, fresh, body)
+}
+
+// currentToken follows token through the refreshes that replaced it
+func (c *Client) currentToken(token string) string {
+	c.renewedMu.Lock()
+	defer c.renewedMu.Unlock()
+	for range len(c.renewed) {
+		next, ok := c.renewed[token]
+		if !ok || time.Since(next.at) > renewalTTL {
+			break
+		}
+		token = next.token
+	}
+	return token
+}
+
+func (c *Client) rememberRenewal(expired, fresh string) {
+	c.renewedMu.Lock()
+	defer c.renewedMu.Unlock()
+	if c.renewed == nil {
+		c.renewed = make(map[string]renewal)
+	}
+	for old, r := range c.renewed {
+		if time.Since(r.at) > renewalTTL {
+			delete(c.renewed, old)
+		}
+	}
+	c.renewed[expired] = renewal{token: fresh, at: time.Now()}
+}
+
+func (c *Client) send(ctx context.Context, method, endpoint, token string, body []byte) (*http.Response, error) {
 	u := fmt.Sprintf("%s%s", c.BaseURL, endpoint)
 	log.Printf("RD Request: %s %s", method, u)
-	req, err := http.NewRequest(method, u, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -137,8 +319,8 @@ func (c *Client) doRequest(method, endpoint, token string, body []byte) (*http.R
 	return c.HttpClient.Do(req)
 }
 
-func (c *Client) GetUser(token string) (*User, error) {
-	resp, err := c.doRequest("GET", "/user", token, nil)
+func (c *Client) GetUser(ctx context.Context, token string) (*User, error) {
+	resp, err := c.doRequestContext(ctx, "GET", "/user", token, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -155,12 +337,31 @@ func (c *Client) GetUser(token string) (*User, error) {
 	return &user, nil
 }
 
-func (c *Client) UnrestrictLink(token, link string) (*UnrestrictResponse, error) {
+// GetTraffic returns the account's quota per limited hoster
+func (c *Client) GetTraffic(ctx context.Context, token string) (map[string]Traffic, error) {
+	resp, err := c.doRequestContext(ctx, "GET", "/traffic", token, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("RD Traffic Failed: %s", resp.Status)
+	}
+
+	var traffic map[string]Traffic
+	if err := json.NewDecoder(resp.Body).Decode(&traffic); err != nil {
+		return nil, err
+	}
+	return traffic, nil
+}
+
+func (c *Client) UnrestrictLink(ctx context.Context, token, link string) (*UnrestrictResponse, error) {
 	// RD expects form-urlencoded for POST
 	data := url.Values{}
 	data.Set("link", link)
 
-	resp, err := c.doRequest("POST", "/unrestrict/link", token, []byte(data.Encode()))
+	resp, err := c.doRequestContext(ctx, "POST", "/unrestrict/link", token, []byte(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,68 @@
+package realdebrid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRefreshedTokenReplacesStaleOne(t *testing.T) {
+	// Both calls get their 401 before either refreshes, like requests already in flight
+	var rejected sync.WaitGroup
+	rejected.Add(2)
+	var staleHits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer fresh":
+			w.Write([]byte(`{"id": 1, "username": "rivulet", "type": "premium"}`))
+		case "Bearer stale":
+			if staleHits.Add(1) <= 2 {
+				rejected.Done()
+				rejected.Wait()
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "bad_token", "error_code": 8}`))
+		default:
+			t.Errorf("unexpected token %q", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	var refreshes atomic.Int32
+	c := NewClient()
+	c.BaseURL = srv.URL
+	c.Refresher = func(ctx context.Context, expired string) (string, error) {
+		if expired != "stale" {
+			t.Errorf("refreshing %q", expired)
+		}
+		refreshes.Add(1)
+		return "fresh", nil
+	}
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetUser(context.Background(), "stale"); err != nil {
+				t.Errorf("GetUser: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The caller still holds the stale token for its next call
+	if _, err := c.GetUser(context.Background(), "stale"); err != nil {
+		t.Errorf("GetUser after refresh: %v", err)
+	}
+	if staleHits.Load() != 2 {
+		t.Errorf("stale token sent %d times, want only by the two in-flight calls", staleHits.Load())
+	}
+	if refreshes.Load() != 1 {
+		t.Errorf("refreshed %d times, want once", refreshes.Load())
+	}
+}
@@ -0,0 +1,149 @@
+package realdebrid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenSourceClientID is RD's shared client ID for open-source apps.
+// The device flow trades it for per-user credentials.
+const OpenSourceClientID = "X245A4XAIBGVM"
+
+// deviceGrantType is the grant used both to redeem a device code and to refresh
+const deviceGrantType = "http://oauth.net/grant_type/device/1.0"
+
+// ErrAuthorizationPending is returned while the user hasn't entered the code yet
+var ErrAuthorizationPending = errors.New("authorization pending")
+
+// TokenRefresher returns a fresh access token for one RD rejected as expired
+type TokenRefresher func(ctx context.Context, expired string) (string, error)
+
+// DeviceCode is the start of the device flow: show UserCode and VerificationURL to the user
+type DeviceCode struct {
+	DeviceCode            string `json:"device_code"`
+	UserCode              string `json:"user_code"`
+	Interval              int    `json:"interval"`   // Seconds between polls
+	ExpiresIn             int    `json:"expires_in"` // Seconds until the code is void
+	VerificationURL       string `json:"verification_url"`
+	DirectVerificationURL string `json:"direct_verification_url"`
+}
+
+// DeviceCredentials are the per-user client ID and secret issued once the user approves
+type DeviceCredentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// Token is an OAuth access token with its refresh token
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // Seconds
+	TokenType    string `json:"token_type"`
+}
+
+// ExpiresAt is when the access token stops working
+func (t *Token) ExpiresAt() time.Time {
+	return time.Now().Add(time.Duration(t.ExpiresIn) * time.Second)
+}
+
+// StartDeviceAuth asks RD for a user code
+func (c *Client) StartDeviceAuth(ctx context.Context) (*DeviceCode, error) {
+	q := url.Values{}
+	q.Set("client_id", OpenSourceClientID)
+	q.Set("new_credentials", "yes")
+
+	var code DeviceCode
+	if err := c.oauthGet(ctx, "/device/code?"+q.Encode(), &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// PollDeviceCredentials returns the user's credentials once they've entered the code,
+// or ErrAuthorizationPending until then
+func (c *Client) PollDeviceCredentials(ctx context.Context, deviceCode string) (*DeviceCredentials, error) {
+	q := url.Values{}
+	q.Set("client_id", OpenSourceClientID)
+	q.Set("code", deviceCode)
+
+	var creds DeviceCredentials
+	if err := c.oauthGet(ctx, "/device/credentials?"+q.Encode(), &creds); err != nil {
+		return nil, err
+	}
+	if creds.ClientID == "" {
+		return nil, ErrAuthorizationPending
+	}
+	return &creds, nil
+}
+
+// ExchangeDeviceCode redeems an approved device code for tokens
+func (c *Client) ExchangeDeviceCode(ctx context.Context, creds DeviceCredentials, deviceCode string) (*Token, error) {
+	return c.requestToken(ctx, creds, deviceCode)
+}
+
+// RefreshToken issues a new access token from a refresh token
+func (c *Client) RefreshToken(ctx context.Context, creds DeviceCredentials, refreshToken string) (*Token, error) {
+	return c.requestToken(ctx, creds, refreshToken)
+}
+
+func (c *Client) requestToken(ctx context.Context, creds DeviceCredentials, code string) (*Token, error) {
+	form := url.Values{}
+	form.Set("client_id", creds.ClientID)
+	form.Set("client_secret", creds.ClientSecret)
+	form.Set("code", code)
+	form.Set("grant_type", deviceGrantType)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.OAuthURL+"/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("RD Token Failed: %s", resp.Status)
+	}
+
+	var token Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("RD Token Failed: empty access token")
+	}
+	return &token, nil
+}
+
+func (c *Client) oauthGet(ctx context.Context, endpoint string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.OAuthURL+endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		// RD answers the credentials poll with an error until the user approves
+		if strings.HasPrefix(endpoint, "/device/credentials") && resp.StatusCode < 500 {
+			return ErrAuthorizationPending
+		}
+		return fmt.Errorf("RD OAuth Failed: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
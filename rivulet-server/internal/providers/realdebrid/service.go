@@ -0,0 +1,205 @@
+package realdebrid
+
+import (
+	"context"
+	"fmt"
+	"rivulet_server/internal/providers"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client implements providers.DebridService on top of the RD REST methods
+var _ providers.DebridService = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "realdebrid"
+}
+
+func (c *Client) AddTorrent(ctx context.Context, token, magnet string) (string, error) {
+	return c.AddMagnet(ctx, token, magnet)
+}
+
+func (c *Client) TorrentStatus(ctx context.Context, token, id string) (*providers.DebridTorrent, error) {
+	info, err := c.GetTorrentInfo(ctx, token, id)
+	if err != nil {
+		return nil, err
+	}
+	return info.toDebrid(), nil
+}
+
+func (c *Client) SelectTorrentFiles(ctx context.Context, token, id string, fileIDs []int) error {
+	if len(fileIDs) == 0 {
+		return c.SelectFiles(ctx, token, id, "all")
+	}
+	ids := make([]string, len(fileIDs))
+	for i, f := range fileIDs {
+		ids[i] = strconv.Itoa(f)
+	}
+	return c.SelectFiles(ctx, token, id, strings.Join(ids, ","))
+}
+
+func (c *Client) UnrestrictFile(ctx context.Context, token, link string) (*providers.DebridLink, error) {
+	res, err := c.UnrestrictLink(ctx, token, link)
+	if err != nil {
+		return nil, err
+	}
+	return &providers.DebridLink{Filename: res.Filename, Download: res.Download}, nil
+}
+
+func (c *Client) CheckAvailability(ctx context.Context, token string, hashes []string) (map[string][]providers.CachedFile, error) {
+	available, err := c.InstantAvailability(ctx, token, hashes)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]providers.CachedFile, len(available))
+	for hash, files := range available {
+		for _, f := range files {
+			out[hash] = append(out[hash], providers.CachedFile{ID: f.ID, Path: f.Filename, Bytes: f.Filesize})
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) AccountInfo(ctx context.Context, token string) (*providers.DebridAccount, error) {
+	user, err := c.GetUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	account := &providers.DebridAccount{
+		Service:     c.Name(),
+		Username:    user.Username,
+		Email:       user.Email,
+		Type:        user.Type,
+		Premium:     user.Type == "premium",
+		PremiumDays: user.Premium / 86400,
+		Points:      user.Points,
+	}
+	if t, err := time.Parse(time.RFC3339, user.Expiration); err == nil {
+		account.ExpiresAt = t
+	}
+
+	// Quotas are extra detail; the account is still useful without them
+	traffic, err := c.GetTraffic(ctx, token)
+	if err == nil {
+		hosts := make([]string, 0, len(traffic))
+		for host := range traffic {
+			hosts = append(hosts, host)
+		}
+		slices.Sort(hosts)
+		for _, host := range hosts {
+			t := traffic[host]
+			account.Traffic = append(account.Traffic, providers.DebridTraffic{
+				Host:  host,
+				Type:  t.Type,
+				Left:  t.Left,
+				Used:  t.Bytes,
+				Limit: t.Limit,
+				Reset: t.Reset,
+			})
+		}
+	}
+	return account, nil
+}
+
+// Pages of the torrents list scanned by FindTorrents; older torrents are ignored
+const (
+	findPageSize = 100
+	findMaxPages = 5
+)
+
+var (
+	_ providers.DebridTorrentFinder  = (*Client)(nil)
+	_ providers.DebridTorrentRemover = (*Client)(nil)
+)
+
+// FindTorrents scans the account's most recent torrents for a hash
+func (c *Client) FindTorrents(ctx context.Context, token, hash string) ([]string, error) {
+	var ids []string
+	for page := 1; page <= findMaxPages; page++ {
+		torrents, err := c.ListTorrents(ctx, token, page, findPageSize)
+		if err != nil {
+			return ids, err
+		}
+		for _, t := range torrents {
+			if strings.EqualFold(t.Hash, hash) {
+				ids = append(ids, t.ID)
+			}
+		}
+		if len(torrents) < findPageSize {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// DeleteTorrent removes a torrent from the account
+func (c *Client) DeleteTorrent(ctx context.Context, token, id string) error {
+	resp, err := c.doRequestContext(ctx, "DELETE", "/torrents/delete/"+id, token, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case 204:
+		return nil
+	case 404:
+		return providers.ErrDebridTorrentNotFound
+	default:
+		return fmt.Errorf("RD Delete Torrent Failed: %s", resp.Status)
+	}
+}
+
+// toDebrid normalizes the RD status and pairs links with files.
+// RD returns one link per selected file, in file order. When the counts
+// differ (RD packed files into an archive) no file gets a link, since any
+// pairing could point at the wrong episode.
+func (info *TorrentInfo) toDebrid() *providers.DebridTorrent {
+	t := &providers.DebridTorrent{
+		ID:       info.ID,
+		Hash:     strings.ToLower(info.Hash),
+		Name:     info.Filename,
+		Status:   normalizeStatus(info.Status),
+		Progress: info.Progress,
+	}
+
+	selected := 0
+	for _, f := range info.Files {
+		if f.Selected == 1 {
+			selected++
+		}
+	}
+	linked := selected == len(info.Links)
+	if !linked {
+		t.UnmappedLinks = len(info.Links)
+	}
+
+	i := 0
+	for _, f := range info.Files {
+		file := providers.DebridFile{ID: f.ID, Path: f.Path, Bytes: f.Bytes, Selected: f.Selected == 1}
+		if file.Selected {
+			if linked {
+				file.Link = info.Links[i]
+			}
+			i++
+		}
+		t.Files = append(t.Files, file)
+	}
+	return t
+}
+
+func normalizeStatus(status string) string {
+	switch status {
+	case "waiting_files_selection":
+		return providers.DebridStatusWaitingFiles
+	case "downloaded":
+		return providers.DebridStatusDownloaded
+	case "magnet_conversion", "queued", "downloading", "compressing", "uploading":
+		return providers.DebridStatusDownloading
+	case "magnet_error", "error", "virus", "dead":
+		return providers.DebridStatusError
+	}
+	return providers.DebridStatusDownloading
+}
@@ -0,0 +1,184 @@
+package realdebrid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"rivulet_server/internal/providers"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rdStub is one RD account: its torrents as raw /torrents/info bodies, and
+// the hoster links it can unrestrict
+type rdStub struct {
+	t        *testing.T
+	torrents map[string]string
+	links    map[string]string // Hoster link to download URL
+	selected map[string]string // Torrent ID to the files form value
+	hold     chan struct{}     // Closed when the test ends; addMagnet waits on it
+}
+
+func newRDStub(t *testing.T) *rdStub {
+	return &rdStub{t: t, torrents: map[string]string{}, links: map[string]string{}, selected: map[string]string{}, hold: make(chan struct{})}
+}
+
+func (s *rdStub) rdError(w http.ResponseWriter, status int, code string, n int) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error": %q, "error_code": %d}`, code, n)
+}
+
+func (s *rdStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		s.rdError(w, http.StatusUnauthorized, "bad_token", 8)
+		return
+	}
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	switch {
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/torrents/info/"):
+		body, ok := s.torrents[id]
+		if !ok {
+			s.rdError(w, http.StatusNotFound, "unknown_ressource", 7)
+			return
+		}
+		w.Write([]byte(body))
+	case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/torrents/delete/"):
+		if _, ok := s.torrents[id]; !ok {
+			s.rdError(w, http.StatusNotFound, "unknown_ressource", 7)
+			return
+		}
+		delete(s.torrents, id)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "POST" && strings.HasPrefix(r.URL.Path, "/torrents/selectFiles/"):
+		r.ParseForm()
+		s.selected[id] = r.PostForm.Get("files")
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "POST" && r.URL.Path == "/unrestrict/link":
+		r.ParseForm()
+		download, ok := s.links[r.PostForm.Get("link")]
+		if !ok {
+			s.rdError(w, http.StatusServiceUnavailable, "hoster_unavailable", 19)
+			return
+		}
+		w.Write([]byte(`{"id": "X", "filename": "` + download[strings.LastIndex(download, "/")+1:] + `", "download": "` + download + `"}`))
+	case r.Method == "POST" && r.URL.Path == "/torrents/addMagnet":
+		// Only a cancelled context gets the caller out
+		<-s.hold
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "new"}`))
+	default:
+		s.t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *rdStub) client() *Client {
+	srv := httptest.NewServer(s)
+	s.t.Cleanup(srv.Close)
+	s.t.Cleanup(func() { close(s.hold) })
+	c := NewClient()
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestTorrentStatusPairsLinksWithSelectedFiles(t *testing.T) {
+	stub := newRDStub(t)
+	stub.torrents["ready"] = `{"id": "ready", "hash": "ABCDEF", "filename": "Show.S01", "status": "downloaded", "progress": 100,
+		"files": [
+			{"id": 1, "path": "/Show.S01/E01.mkv", "bytes": 100, "selected": 1},
+			{"id": 2, "path": "/Show.S01/sample.mkv", "bytes": 5, "selected": 0},
+			{"id": 3, "path": "/Show.S01/E02.mkv", "bytes": 200, "selected": 1}
+		],
+		"links": ["https://real-debrid.com/d/E01", "https://real-debrid.com/d/E02"]}`
+	stub.torrents["archived"] = `{"id": "archived", "status": "downloaded",
+		"files": [{"id": 1, "path": "/a.mkv", "selected": 1}, {"id": 2, "path": "/b.mkv", "selected": 1}],
+		"links": ["https://real-debrid.com/d/archive.rar"]}`
+	stub.torrents["converting"] = `{"id": "converting", "status": "magnet_conversion", "files": [], "links": []}`
+	c := stub.client()
+
+	torrent, err := c.TorrentStatus(context.Background(), "test-token", "ready")
+	if err != nil {
+		t.Fatalf("TorrentStatus: %v", err)
+	}
+	if torrent.Status != providers.DebridStatusDownloaded || torrent.Hash != "abcdef" {
+		t.Errorf("got status %q, hash %q", torrent.Status, torrent.Hash)
+	}
+	if len(torrent.Files) != 3 {
+		t.Fatalf("got %d files, want 3", len(torrent.Files))
+	}
+	if torrent.Files[0].Link != "https://real-debrid.com/d/E01" || torrent.Files[1].Link != "" || torrent.Files[2].Link != "https://real-debrid.com/d/E02" {
+		t.Errorf("links = %q / %q / %q", torrent.Files[0].Link, torrent.Files[1].Link, torrent.Files[2].Link)
+	}
+
+	archived, err := c.TorrentStatus(context.Background(), "test-token", "archived")
+	if err != nil {
+		t.Fatalf("TorrentStatus: %v", err)
+	}
+	if archived.UnmappedLinks != 1 || archived.Files[0].Link != "" || archived.Files[1].Link != "" {
+		t.Errorf("archive links must not be paired: %+v", archived)
+	}
+
+	converting, err := c.TorrentStatus(context.Background(), "test-token", "converting")
+	if err != nil || converting.Status != providers.DebridStatusDownloading {
+		t.Errorf("converting torrent: %+v, %v", converting, err)
+	}
+}
+
+func TestSelectAndUnrestrict(t *testing.T) {
+	stub := newRDStub(t)
+	stub.links["https://real-debrid.com/d/E02"] = "https://cdn.real-debrid.com/E02.mkv"
+	c := stub.client()
+	ctx := context.Background()
+
+	if err := c.SelectTorrentFiles(ctx, "test-token", "ready", []int{1, 3}); err != nil {
+		t.Fatalf("SelectTorrentFiles: %v", err)
+	}
+	if stub.selected["ready"] != "1,3" {
+		t.Errorf("files = %q, want 1,3", stub.selected["ready"])
+	}
+	link, err := c.UnrestrictFile(ctx, "test-token", "https://real-debrid.com/d/E02")
+	if err != nil || link.Download != "https://cdn.real-debrid.com/E02.mkv" || link.Filename != "E02.mkv" {
+		t.Errorf("UnrestrictFile = %+v, %v", link, err)
+	}
+	if _, err := c.UnrestrictFile(ctx, "test-token", "https://real-debrid.com/d/other"); err == nil {
+		t.Error("hoster error didn't fail")
+	}
+}
+
+func TestDeletedTorrentIsNotFound(t *testing.T) {
+	stub := newRDStub(t)
+	stub.torrents["old"] = `{"id": "old", "status": "downloaded"}`
+	c := stub.client()
+	ctx := context.Background()
+
+	if err := c.DeleteTorrent(ctx, "test-token", "old"); err != nil {
+		t.Fatalf("DeleteTorrent: %v", err)
+	}
+	if _, err := c.TorrentStatus(ctx, "test-token", "old"); !errors.Is(err, providers.ErrDebridTorrentNotFound) {
+		t.Errorf("status after delete: err = %v, want ErrDebridTorrentNotFound", err)
+	}
+	if err := c.DeleteTorrent(ctx, "test-token", "old"); !errors.Is(err, providers.ErrDebridTorrentNotFound) {
+		t.Errorf("second delete: err = %v, want ErrDebridTorrentNotFound", err)
+	}
+	if _, err := c.TorrentStatus(ctx, "wrong", "old"); err == nil || errors.Is(err, providers.ErrDebridTorrentNotFound) {
+		t.Errorf("bad token: err = %v", err)
+	}
+}
+
+func TestCallsFollowTheContext(t *testing.T) {
+	c := newRDStub(t).client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.AddTorrent(ctx, "test-token", "magnet:?xt=urn:btih:abcdef"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("AddTorrent ignored the deadline, took %v", elapsed)
+	}
+}
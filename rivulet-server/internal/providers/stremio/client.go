@@ -0,0 +1,305 @@
+package stremio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TitleParser extracts size (bytes), seeds and quality from an addon's stream title.
+// Addons format their titles differently, so the parser is pluggable per addon.
+type TitleParser func(rawTitle string) (int64, int, string)
+
+var (
+	parsersMu    sync.RWMutex
+	titleParsers = map[string]TitleParser{}
+)
+
+// RegisterTitleParser associates a parser with an addon manifest ID (e.g. "com.stremio.torrentio.addon")
+func RegisterTitleParser(manifestID string, parser TitleParser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	titleParsers[manifestID] = parser
+}
+
+func lookupTitleParser(manifestID string) TitleParser {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	return titleParsers[manifestID]
+}
+
+// Client is a providers.Scraper for any addon speaking the Stremio
+// /stream/{type}/{id}.json protocol (Torrentio, Comet, MediaFusion, Knightcrawler...)
+type Client struct {
+	ManifestURL string
+	HttpClient  *http.Client
+	// ParseTitle overrides the parser registered for the addon's manifest ID
+	ParseTitle TitleParser
+
+	mu       sync.Mutex
+	manifest *Manifest
+}
+
+func NewClient(manifestURL string) *Client {
+	return &Client{
+		ManifestURL: manifestURL,
+		HttpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+// --- Manifest Models ---
+
+type Manifest struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	Version    string            `json:"version"`
+	Types      []string          `json:"types"`
+	IDPrefixes []string          `json:"idPrefixes"`
+	Resources  []json.RawMessage `json:"resources"` // Either "stream" or {"name": "stream", ...}
+}
+
+type Resource struct {
+	Name       string   `json:"name"`
+	Types      []string `json:"types"`
+	IDPrefixes []string `json:"idPrefixes"`
+}
+
+// StreamResource returns the "stream" resource declaration, if the addon has one.
+// Short-form resources inherit types and prefixes from the manifest root.
+func (m *Manifest) StreamResource() (*Resource, bool) {
+	for _, raw := range m.Resources {
+		var name string
+		if err := json.Unmarshal(raw, &name); err == nil {
+			if name == "stream" {
+				return &Resource{Name: name, Types: m.Types, IDPrefixes: m.IDPrefixes}, true
+			}
+			continue
+		}
+
+		var res Resource
+		if err := json.Unmarshal(raw, &res); err != nil || res.Name != "stream" {
+			continue
+		}
+		if len(res.Types) == 0 {
+			res.Types = m.Types
+		}
+		if len(res.IDPrefixes) == 0 {
+			res.IDPrefixes = m.IDPrefixes
+		}
+		return &res, true
+	}
+	return nil, false
+}
+
+// Supports reports whether the addon serves streams for this type and ID
+func (m *Manifest) Supports(mediaType, id string) bool {
+	res, ok := m.StreamResource()
+	if !ok {
+		return false
+	}
+
+	typeOK := false
+	for _, t := range res.Types {
+		if t == mediaType {
+			typeOK = true
+			break
+		}
+	}
+	if !typeOK {
+		return false
+	}
+
+	// No prefixes declared means any ID is accepted
+	if len(res.IDPrefixes) == 0 {
+		return true
+	}
+	for _, p := range res.IDPrefixes {
+		if strings.HasPrefix(id, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Stream Response Models ---
+
+type Response struct {
+	Streams []StreamItem `json:"streams"`
+}
+
+type StreamItem struct {
+	Name          string `json:"name"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	InfoHash      string `json:"infoHash"`
+	FileIdx       *int   `json:"fileIdx"`
+	URL           string `json:"url"`
+	BehaviorHints *struct {
+		BingeGroup string `json:"bingeGroup"`
+		Filename   string `json:"filename"`
+		VideoSize  int64  `json:"videoSize"`
+	} `json:"behaviorHints,omitempty"`
+}
+
+// --- Scraper ---
+
+func (c *Client) Name() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.manifest != nil && c.manifest.Name != "" {
+		return c.manifest.Name
+	}
+	if u, err := url.Parse(c.ManifestURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "Stremio Addon"
+}
+
+// ID is the manifest URL: Name changes once the manifest has been fetched
+func (c *Client) ID() string {
+	return c.ManifestURL
+}
+
+// baseURL strips the trailing /manifest.json so endpoints can be appended
+func (c *Client) baseURL() string {
+	base := strings.TrimSuffix(c.ManifestURL, "/")
+	return strings.TrimSuffix(base, "/manifest.json")
+}
+
+// Manifest fetches the addon manifest once and caches it.
+// Failed fetches are not cached so the next scrape retries.
+func (c *Client) Manifest(ctx context.Context) (*Manifest, error) {
+	c.mu.Lock()
+	if c.manifest != nil {
+		m := c.manifest
+		c.mu.Unlock()
+		return m, nil
+	}
+	c.mu.Unlock()
+
+	var manifest Manifest
+	if err := c.getJSON(ctx, c.baseURL()+"/manifest.json", &manifest); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	c.mu.Lock()
+	c.manifest = &manifest
+	c.mu.Unlock()
+	return &manifest, nil
+}
+
+func (c *Client) Scrape(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int) ([]*providers.Stream, error) {
+	manifest, err := c.Manifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stremio calls shows "series"
+	stremioType := mediaType
+	if mediaType == "show" || mediaType == "tv" {
+		stremioType = "series"
+	}
+
+	if !manifest.Supports(stremioType, imdbID) {
+		return nil, providers.ErrNotSupported
+	}
+
+	targetID := imdbID
+	if stremioType == "series" {
+		targetID = fmt.Sprintf("%s:%d:%d", imdbID, season, episode)
+	}
+
+	u := fmt.Sprintf("%s/stream/%s/%s.json", c.baseURL(), stremioType, url.PathEscape(targetID))
+	log.Print(u)
+
+	var result Response
+	if err := c.getJSON(ctx, u, &result); err != nil {
+		return nil, err
+	}
+
+	parse := c.ParseTitle
+	if parse == nil {
+		parse = lookupTitleParser(manifest.ID)
+	}
+	if parse == nil {
+		// Most addons follow Torrentio's emoji conventions
+		parse = providers.ParseAddonTitle
+	}
+
+	source := manifest.Name
+	if source == "" {
+		source = c.Name()
+	}
+
+	var streams []*providers.Stream
+	for _, item := range result.Streams {
+		// Only torrent streams can be resolved through debrid
+		if item.InfoHash == "" {
+			continue
+		}
+
+		rawTitle := item.Title
+		if rawTitle == "" {
+			rawTitle = item.Description
+		}
+		// Quality is often only in the addon "name" column (e.g. "Comet\n1080p")
+		size, seeds, quality := parse(rawTitle + "\n" + item.Name)
+		if size == 0 && item.BehaviorHints != nil {
+			size = item.BehaviorHints.VideoSize
+		}
+
+		cleanTitle := strings.Split(rawTitle, "\n")[0]
+		if cleanTitle == "" && item.BehaviorHints != nil {
+			cleanTitle = item.BehaviorHints.Filename
+		}
+
+		hash := strings.ToLower(item.InfoHash)
+		stream := &providers.Stream{
+			Title:     cleanTitle,
+			Size:      size,
+			Hash:      hash,
+			Magnet:    fmt.Sprintf("magnet:?xt=urn:btih:%s", hash),
+			Seeds:     seeds,
+			Quality:   quality,
+			Source:    source,
+			FileIndex: item.FileIdx,
+		}
+		stream.ParseRelease(rawTitle)
+		// Only set when the manifest URL includes the user's debrid key
+		stream.MarkCachedFromHint(item.Name)
+		streams = append(streams, stream)
+	}
+
+	return streams, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+
+	// Spoof User-Agent to avoid 403
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("addon returned status: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,165 @@
+package stremio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rivulet_server/internal/providers"
+	"strings"
+	"testing"
+)
+
+// addon serves a manifest and one stream list, remembering which stream paths were asked for
+type addon struct {
+	manifest string
+	streams  string
+	asked    []string
+}
+
+func (a *addon) start(t *testing.T) *Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/manifest.json":
+			w.Write([]byte(a.manifest))
+		case strings.HasPrefix(r.URL.Path, "/stream/"):
+			a.asked = append(a.asked, r.URL.Path)
+			w.Write([]byte(`{"streams": ` + a.streams + `}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewClient(srv.URL + "/manifest.json")
+}
+
+func TestSupports(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifest  string
+		mediaType string
+		id        string
+		want      bool
+	}{
+		{"short form inherits root", `{"types": ["movie"], "idPrefixes": ["tt"], "resources": ["stream"]}`, "movie", "tt0133093", true},
+		{"short form, other type", `{"types": ["movie"], "idPrefixes": ["tt"], "resources": ["stream"]}`, "series", "tt0903747", false},
+		{"object overrides root", `{"types": ["movie"], "resources": ["catalog", {"name": "stream", "types": ["series"], "idPrefixes": ["kitsu"]}]}`, "series", "kitsu:1", true},
+		{"object prefix mismatch", `{"types": ["series"], "resources": [{"name": "stream", "idPrefixes": ["kitsu"]}]}`, "series", "tt0903747", false},
+		{"no prefixes accepts any ID", `{"types": ["series"], "resources": [{"name": "stream"}]}`, "series", "tt0903747", true},
+		{"no stream resource", `{"types": ["movie"], "resources": ["catalog", "meta"]}`, "movie", "tt0133093", false},
+	}
+	for _, tt := range tests {
+		var m Manifest
+		if err := json.Unmarshal([]byte(tt.manifest), &m); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := m.Supports(tt.mediaType, tt.id); got != tt.want {
+			t.Errorf("%s: Supports(%q, %q) = %v, want %v", tt.name, tt.mediaType, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestScrapeUnsupportedType(t *testing.T) {
+	a := &addon{manifest: `{"id": "org.example.movies", "name": "Movies Only", "types": ["movie"], "resources": ["stream"]}`}
+	c := a.start(t)
+
+	if _, err := c.Scrape(context.Background(), "show", "tt0903747", "", 1, 2); !errors.Is(err, providers.ErrNotSupported) {
+		t.Errorf("err = %v, want ErrNotSupported", err)
+	}
+	if len(a.asked) != 0 {
+		t.Errorf("asked for streams anyway: %v", a.asked)
+	}
+}
+
+func TestScrapeEpisode(t *testing.T) {
+	a := &addon{
+		manifest: `{"id": "org.example.episodes", "name": "Episodes", "types": ["series"], "idPrefixes": ["tt"], "resources": ["stream"]}`,
+		streams: `[
+			{"name": "Episodes\n1080p", "title": "Breaking.Bad.S01E02.1080p.BluRay.x264\n👤 12 💾 1.5 GB", "infoHash": "ABCDEF", "fileIdx": 3},
+			{"name": "Episodes\nDirect", "title": "Web player", "url": "https://example.com/video.mp4"}
+		]`,
+	}
+
+	streams, err := a.start(t).Scrape(context.Background(), "show", "tt0903747", "", 1, 2)
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if len(a.asked) != 1 || a.asked[0] != "/stream/series/tt0903747:1:2.json" {
+		t.Errorf("asked for %v", a.asked)
+	}
+	// URL-only streams can't go through debrid
+	if len(streams) != 1 {
+		t.Fatalf("got %d streams, want 1", len(streams))
+	}
+	s := streams[0]
+	if s.Hash != "abcdef" || s.Magnet != "magnet:?xt=urn:btih:abcdef" || s.Source != "Episodes" {
+		t.Errorf("stream = %+v", s)
+	}
+	if s.Title != "Breaking.Bad.S01E02.1080p.BluRay.x264" || s.Seeds != 12 || s.Quality != "1080p" || s.FileIndex == nil || *s.FileIndex != 3 {
+		t.Errorf("title %q, seeds %d, quality %q, file %v", s.Title, s.Seeds, s.Quality, s.FileIndex)
+	}
+}
+
+func TestTitleParserSelection(t *testing.T) {
+	const manifest = `{"id": "org.example.custom", "name": "Custom", "types": ["movie"], "resources": ["stream"]}`
+	const streams = `[{"name": "Custom", "title": "The.Matrix.1999.2160p\n👤 5", "infoHash": "abc"}]`
+	fixed := func(quality string) TitleParser {
+		return func(string) (int64, int, string) { return 42, 7, quality }
+	}
+
+	scrape := func(c *Client) *providers.Stream {
+		t.Helper()
+		got, err := c.Scrape(context.Background(), "movie", "tt0133093", "", 0, 0)
+		if err != nil || len(got) != 1 {
+			t.Fatalf("Scrape = %v, %v", got, err)
+		}
+		return got[0]
+	}
+
+	// Torrentio's conventions by default
+	if s := scrape((&addon{manifest: manifest, streams: streams}).start(t)); s.Quality != "4k" || s.Seeds != 5 {
+		t.Errorf("default parser: quality %q, seeds %d", s.Quality, s.Seeds)
+	}
+
+	RegisterTitleParser("org.example.custom", fixed("registered"))
+	t.Cleanup(func() { RegisterTitleParser("org.example.custom", nil) })
+	if s := scrape((&addon{manifest: manifest, streams: streams}).start(t)); s.Quality != "registered" || s.Size != 42 {
+		t.Errorf("registered parser not used: %+v", s)
+	}
+
+	c := (&addon{manifest: manifest, streams: streams}).start(t)
+	c.ParseTitle = fixed("override")
+	if s := scrape(c); s.Quality != "override" {
+		t.Errorf("ParseTitle override not used: %+v", s)
+	}
+}
+
+func TestNameFallsBackToHost(t *testing.T) {
+	a := &addon{manifest: `{"id": "org.example.unnamed", "types": ["movie"], "resources": ["stream"]}`, streams: `[{"title": "Movie", "infoHash": "abc"}]`}
+	c := a.start(t)
+	host := strings.TrimPrefix(strings.TrimSuffix(c.ManifestURL, "/manifest.json"), "http://")
+
+	if c.Name() != host {
+		t.Errorf("before the manifest: Name = %q, want %q", c.Name(), host)
+	}
+	streams, err := c.Scrape(context.Background(), "movie", "tt0133093", "", 0, 0)
+	if err != nil || len(streams) != 1 {
+		t.Fatalf("Scrape = %v, %v", streams, err)
+	}
+	if c.Name() != host || streams[0].Source != host {
+		t.Errorf("unnamed manifest: Name %q, source %q; want %q", c.Name(), streams[0].Source, host)
+	}
+
+	named := (&addon{manifest: `{"id": "org.example.named", "name": "Named", "types": ["movie"], "resources": ["stream"]}`}).start(t)
+	if _, err := named.Manifest(context.Background()); err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	if named.Name() != "Named" || named.ID() != named.ManifestURL {
+		t.Errorf("Name %q, ID %q", named.Name(), named.ID())
+	}
+
+	if got := NewClient("not a url").Name(); got != "Stremio Addon" {
+		t.Errorf("unparseable URL: Name = %q", got)
+	}
+}
@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSubtitleNotFound is returned when a subtitle file no longer exists on the provider
+var ErrSubtitleNotFound = errors.New("subtitle not found")
+
+// SubtitleQuery describes the video subtitles are wanted for
+type SubtitleQuery struct {
+	ImdbID    string   // Movie or show IMDb ID, e.g. "tt0903747"
+	Season    int      // 0 for movies
+	Episode   int      // 0 for movies
+	Languages []string // ISO 639-1 codes; empty means any language
+	MovieHash string   // OpenSubtitles hash of the video file, matches subtitles synced to that exact file
+	Release   string   // Release name of the stream, e.g. "Show.S01E01.1080p.WEB.h264-GRP"
+}
+
+// Subtitle is a downloadable subtitle file
+type Subtitle struct {
+	ID                string `json:"id"` // Provider-scoped file ID, passed back to Download
+	Provider          string `json:"provider"`
+	Language          string `json:"language"` // ISO 639-1
+	Release           string `json:"release,omitempty"`
+	FileName          string `json:"file_name,omitempty"`
+	Format            string `json:"format"` // Format Download returns, e.g. "srt"
+	HearingImpaired   bool   `json:"hearing_impaired"`
+	MachineTranslated bool   `json:"machine_translated"`
+	Downloads         int    `json:"downloads"`
+	HashMatch         bool   `json:"hash_match"`    // Synced to the file matching SubtitleQuery.MovieHash
+	ReleaseMatch      bool   `json:"release_match"` // Made for the same release as SubtitleQuery.Release
+}
+
+// SubtitleProvider is a subtitle source (OpenSubtitles, ...)
+type SubtitleProvider interface {
+	// Name is the stable provider ID, e.g. "opensubtitles"
+	Name() string
+	// Search lists subtitles for a movie or episode
+	Search(ctx context.Context, q SubtitleQuery) ([]Subtitle, error)
+	// Download returns the contents of a Subtitle.ID from Search, in its Format
+	Download(ctx context.Context, id string) ([]byte, error)
+}
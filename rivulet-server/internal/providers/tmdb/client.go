@@ -1,10 +1,13 @@
 package tmdb
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"rivulet_server/internal/providers"
+	"strings"
 	"time"
 )
 
@@ -55,6 +58,10 @@ type Image struct {
 }
 
 type TVShowDetails struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Overview     string `json:"overview"`
+	FirstAirDate string `json:"first_air_date"`
 	PosterPath   string `json:"poster_path"`
 	BackdropPath string `json:"backdrop_path"`
 	Seasons      []struct {
@@ -256,6 +263,7 @@ func (c *Client) GetEpisodeDetails(apiKey string, tmdbID, seasonNum, episodeNum
 
 type MovieDetails struct {
 	ID           int    `json:"id"`
+	ImdbID       string `json:"imdb_id"`
 	Title        string `json:"title"`
 	PosterPath   string `json:"poster_path"`
 	BackdropPath string `json:"backdrop_path"`
@@ -281,3 +289,65 @@ func (c *Client) GetMovieDetails(apiKey string, tmdbID int) (*MovieDetails, erro
 
 	return &details, nil
 }
+
+// FindResponse lists the titles matching an external ID
+type FindResponse struct {
+	MovieResults []Result `json:"movie_results"`
+	TVResults    []Result `json:"tv_results"`
+}
+
+// ExternalIDs are the IDs other sites use for a movie or show
+type ExternalIDs struct {
+	ID     int    `json:"id"`
+	ImdbID string `json:"imdb_id"`
+	TvdbID int    `json:"tvdb_id"`
+}
+
+// FindByImdbID looks up the movie or show with an IMDb ID
+func (c *Client) FindByImdbID(ctx context.Context, apiKey, imdbID string) (*FindResponse, error) {
+	u := fmt.Sprintf("%s/find/%s?api_key=%s&external_source=imdb_id", BaseURL, url.PathEscape(imdbID), apiKey)
+
+	var response FindResponse
+	if err := c.get(ctx, "FindByImdbID", u, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetExternalIDs fetches the IMDb and TVDB IDs of a movie or show
+func (c *Client) GetExternalIDs(ctx context.Context, apiKey string, tmdbID int, mediaType string) (*ExternalIDs, error) {
+	endpointType := "movie"
+	if providers.IsShow(mediaType) {
+		endpointType = "tv"
+	}
+	u := fmt.Sprintf("%s/%s/%d/external_ids?api_key=%s", BaseURL, endpointType, tmdbID, apiKey)
+
+	var ids ExternalIDs
+	if err := c.get(ctx, "GetExternalIDs", u, &ids); err != nil {
+		return nil, err
+	}
+	return &ids, nil
+}
+
+// get fetches a TMDB endpoint into out. A 404 is reported as providers.ErrMetadataNotFound.
+func (c *Client) get(ctx context.Context, name, u string, out any) error {
+	fmt.Printf("TMDB Request [%s]: %s\n", name, strings.SplitN(u, "?", 2)[0])
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return providers.ErrMetadataNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("TMDB %s: %s", name, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
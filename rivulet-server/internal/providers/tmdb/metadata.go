@@ -0,0 +1,231 @@
+package tmdb
+
+import (
+	"context"
+	"rivulet_server/internal/providers"
+	"strconv"
+)
+
+var _ providers.MetadataProvider = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "tmdb"
+}
+
+func (c *Client) SearchMedia(ctx context.Context, apiKey, query string) ([]providers.MediaSummary, error) {
+	var results []Result
+	var err error
+	if query == "" {
+		results, err = c.GetTrending(apiKey)
+	} else {
+		results, err = c.Search(apiKey, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]providers.MediaSummary, 0, len(results))
+	for _, r := range results {
+		out = append(out, providers.MediaSummary(r))
+	}
+	return out, nil
+}
+
+// MediaDetails describes a movie or show; an IMDb ID is looked up with /find first
+func (c *Client) MediaDetails(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (*providers.MediaDetails, error) {
+	ids, mediaType, err := c.withTmdbID(ctx, apiKey, ids, mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	// The logo comes from a separate endpoint; details are still useful without it
+	logo, _ := c.GetLogo(apiKey, ids.Tmdb, mediaType)
+
+	if providers.IsShow(mediaType) {
+		show, err := c.GetTVShowDetails(apiKey, ids.Tmdb)
+		if err != nil {
+			return nil, err
+		}
+		if show.ID == 0 {
+			return nil, providers.ErrMetadataNotFound
+		}
+		// Shows don't carry their IMDb ID
+		if ids.Imdb == "" {
+			if external, err := c.GetExternalIDs(ctx, apiKey, show.ID, "tv"); err == nil {
+				ids.Imdb = external.ImdbID
+			}
+		}
+		return &providers.MediaDetails{
+			Title:       show.Name,
+			Year:        year(show.FirstAirDate),
+			Description: show.Overview,
+			ImdbID:      ids.Imdb,
+			TmdbID:      show.ID,
+			Type:        "show",
+			Poster:      imageURL(show.PosterPath),
+			Backdrop:    imageURL(show.BackdropPath),
+			Logo:        logo,
+		}, nil
+	}
+
+	movie, err := c.GetMovieDetails(apiKey, ids.Tmdb)
+	if err != nil {
+		return nil, err
+	}
+	if movie.ID == 0 {
+		return nil, providers.ErrMetadataNotFound
+	}
+	if ids.Imdb == "" {
+		ids.Imdb = movie.ImdbID
+	}
+	return &providers.MediaDetails{
+		Title:       movie.Title,
+		Year:        year(movie.ReleaseDate),
+		Description: movie.Overview,
+		ImdbID:      ids.Imdb,
+		TmdbID:      movie.ID,
+		Type:        "movie",
+		Poster:      imageURL(movie.PosterPath),
+		Backdrop:    imageURL(movie.BackdropPath),
+		Logo:        logo,
+	}, nil
+}
+
+func (c *Client) ShowSeasons(ctx context.Context, apiKey string, ids providers.MediaIDs) ([]providers.SeasonSummary, error) {
+	ids, _, err := c.withTmdbID(ctx, apiKey, ids, "show")
+	if err != nil {
+		return nil, err
+	}
+	show, err := c.GetTVShowDetails(apiKey, ids.Tmdb)
+	if err != nil {
+		return nil, err
+	}
+
+	seasons := make([]providers.SeasonSummary, 0, len(show.Seasons))
+	for _, s := range show.Seasons {
+		seasons = append(seasons, providers.SeasonSummary{
+			ID:           s.ID,
+			Name:         s.Name,
+			Overview:     s.Overview,
+			PosterPath:   imageURL(s.PosterPath),
+			SeasonNumber: s.SeasonNumber,
+			EpisodeCount: s.EpisodeCount,
+			AirDate:      s.AirDate,
+		})
+	}
+	return seasons, nil
+}
+
+func (c *Client) SeasonEpisodes(ctx context.Context, apiKey string, ids providers.MediaIDs, season int) (*providers.SeasonDetails, error) {
+	ids, _, err := c.withTmdbID(ctx, apiKey, ids, "show")
+	if err != nil {
+		return nil, err
+	}
+	s, err := c.GetSeasonDetails(apiKey, ids.Tmdb, season)
+	if err != nil {
+		return nil, err
+	}
+	if s.ID == 0 {
+		return nil, providers.ErrMetadataNotFound
+	}
+
+	details := &providers.SeasonDetails{
+		ID:           s.ID,
+		AirDate:      s.AirDate,
+		Name:         s.Name,
+		Overview:     s.Overview,
+		PosterPath:   s.PosterPath,
+		SeasonNumber: s.SeasonNumber,
+		Episodes:     make([]providers.EpisodeDetails, 0, len(s.Episodes)),
+	}
+	for _, e := range s.Episodes {
+		details.Episodes = append(details.Episodes, providers.EpisodeDetails(e))
+	}
+	return details, nil
+}
+
+func (c *Client) MediaImages(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (*providers.MediaImages, error) {
+	ids, mediaType, err := c.withTmdbID(ctx, apiKey, ids, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	images := &providers.MediaImages{}
+	if providers.IsShow(mediaType) {
+		show, err := c.GetTVShowDetails(apiKey, ids.Tmdb)
+		if err != nil {
+			return nil, err
+		}
+		images.Poster, images.Backdrop = imageURL(show.PosterPath), imageURL(show.BackdropPath)
+	} else {
+		movie, err := c.GetMovieDetails(apiKey, ids.Tmdb)
+		if err != nil {
+			return nil, err
+		}
+		images.Poster, images.Backdrop = imageURL(movie.PosterPath), imageURL(movie.BackdropPath)
+	}
+
+	logo, err := c.GetLogo(apiKey, ids.Tmdb, mediaType)
+	if err == nil {
+		images.Logo = logo
+	}
+	return images, nil
+}
+
+// LookupIDs maps IMDb IDs with /find and TMDB IDs with /external_ids
+func (c *Client) LookupIDs(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (providers.MediaIDs, error) {
+	if ids.Tmdb == 0 {
+		found, _, err := c.withTmdbID(ctx, apiKey, ids, mediaType)
+		return found, err
+	}
+	if mediaType == "" {
+		// Without a type the ID could be a movie or a show
+		return ids, providers.ErrNotSupported
+	}
+	external, err := c.GetExternalIDs(ctx, apiKey, ids.Tmdb, mediaType)
+	if err != nil {
+		return ids, err
+	}
+	return ids.Merge(providers.MediaIDs{Imdb: external.ImdbID}), nil
+}
+
+// withTmdbID finds the TMDB ID for an IMDb ID when it's missing, and the media
+// type when the caller didn't know it
+func (c *Client) withTmdbID(ctx context.Context, apiKey string, ids providers.MediaIDs, mediaType string) (providers.MediaIDs, string, error) {
+	if ids.Tmdb != 0 {
+		return ids, mediaType, nil
+	}
+	if ids.Imdb == "" {
+		return ids, mediaType, providers.ErrNotSupported
+	}
+
+	found, err := c.FindByImdbID(ctx, apiKey, ids.Imdb)
+	if err != nil {
+		return ids, mediaType, err
+	}
+	switch {
+	case len(found.TVResults) > 0 && (providers.IsShow(mediaType) || mediaType == "" || len(found.MovieResults) == 0):
+		ids.Tmdb, mediaType = found.TVResults[0].ID, "show"
+	case len(found.MovieResults) > 0:
+		ids.Tmdb, mediaType = found.MovieResults[0].ID, "movie"
+	default:
+		return ids, mediaType, providers.ErrMetadataNotFound
+	}
+	return ids, mediaType, nil
+}
+
+// year reads the year from a TMDB date like "2010-07-15"
+func year(date string) int {
+	if len(date) < 4 {
+		return 0
+	}
+	y, _ := strconv.Atoi(date[:4])
+	return y
+}
+
+// imageURL turns a TMDB image path into a full URL
+func imageURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	return ImageBase + path
+}
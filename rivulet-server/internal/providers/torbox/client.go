@@ -0,0 +1,263 @@
+package torbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	BaseURL    string
+	HttpClient *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{
+		BaseURL: "https://api.torbox.app/v1/api",
+		HttpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+var _ providers.DebridService = (*Client)(nil)
+
+func (c *Client) Name() string {
+	return "torbox"
+}
+
+// --- Models ---
+
+// envelope wraps every TorBox response
+type envelope struct {
+	Success bool            `json:"success"`
+	Error   string          `json:"error"`
+	Detail  string          `json:"detail"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// apiError is a request TorBox rejected
+type apiError struct {
+	Endpoint   string
+	HTTPStatus int
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("TorBox %s: %s", e.Endpoint, e.Message)
+}
+
+type Torrent struct {
+	ID               int     `json:"id"`
+	Hash             string  `json:"hash"`
+	Name             string  `json:"name"`
+	DownloadState    string  `json:"download_state"`
+	DownloadFinished bool    `json:"download_finished"`
+	DownloadPresent  bool    `json:"download_present"`
+	Progress         float64 `json:"progress"` // 0-1
+	Files            []File  `json:"files"`
+}
+
+type File struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// TorBox has no restricted hoster links; files are fetched with requestdl.
+// Links handed out in DebridFile.Link use this form so UnrestrictFile can resolve them.
+const linkScheme = "torbox://"
+
+func fileLink(torrentID, fileID int) string {
+	return fmt.Sprintf("%s%d/%d", linkScheme, torrentID, fileID)
+}
+
+// --- DebridService ---
+
+func (c *Client) AddTorrent(ctx context.Context, token, magnet string) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("magnet", magnet)
+	w.Close()
+
+	var data struct {
+		TorrentID int `json:"torrent_id"`
+	}
+	if err := c.do(ctx, "POST", "/torrents/createtorrent", token, &body, w.FormDataContentType(), &data); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(data.TorrentID), nil
+}
+
+func (c *Client) TorrentStatus(ctx context.Context, token, id string) (*providers.DebridTorrent, error) {
+	var torrent Torrent
+	if err := c.do(ctx, "GET", "/torrents/mylist?bypass_cache=true&id="+url.QueryEscape(id), token, nil, "", &torrent); err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusNotFound {
+			return nil, providers.ErrDebridTorrentNotFound
+		}
+		return nil, err
+	}
+	if torrent.ID == 0 {
+		// No data: the torrent isn't on the account
+		return nil, providers.ErrDebridTorrentNotFound
+	}
+
+	t := &providers.DebridTorrent{
+		ID:       strconv.Itoa(torrent.ID),
+		Hash:     strings.ToLower(torrent.Hash),
+		Name:     torrent.Name,
+		Status:   providers.DebridStatusDownloading,
+		Progress: int(torrent.Progress * 100),
+	}
+
+	ready := torrent.DownloadFinished && torrent.DownloadPresent
+	switch {
+	case ready:
+		t.Status = providers.DebridStatusDownloaded
+		t.Progress = 100
+	case strings.Contains(torrent.DownloadState, "error") || torrent.DownloadState == "stalled (no seeds)":
+		t.Status = providers.DebridStatusError
+	}
+
+	// TorBox file IDs are 0-based; shift them to match RD's 1-based IDs
+	for _, f := range torrent.Files {
+		file := providers.DebridFile{ID: f.ID + 1, Path: f.Name, Bytes: f.Size, Selected: true}
+		if ready {
+			file.Link = fileLink(torrent.ID, f.ID)
+		}
+		t.Files = append(t.Files, file)
+	}
+	return t, nil
+}
+
+// SelectTorrentFiles is a no-op: TorBox always downloads the whole torrent
+func (c *Client) SelectTorrentFiles(ctx context.Context, token, id string, fileIDs []int) error {
+	return nil
+}
+
+func (c *Client) UnrestrictFile(ctx context.Context, token, link string) (*providers.DebridLink, error) {
+	torrentID, fileID, ok := strings.Cut(strings.TrimPrefix(link, linkScheme), "/")
+	if !strings.HasPrefix(link, linkScheme) || !ok {
+		return nil, fmt.Errorf("not a TorBox file link: %s", link)
+	}
+
+	params := url.Values{}
+	params.Set("token", token) // requestdl authenticates by query parameter
+	params.Set("torrent_id", torrentID)
+	params.Set("file_id", fileID)
+
+	var download string
+	if err := c.do(ctx, "GET", "/torrents/requestdl?"+params.Encode(), token, nil, "", &download); err != nil {
+		return nil, err
+	}
+
+	filename := ""
+	if u, err := url.Parse(download); err == nil {
+		filename = u.Path[strings.LastIndex(u.Path, "/")+1:]
+	}
+	return &providers.DebridLink{Filename: filename, Download: download}, nil
+}
+
+func (c *Client) CheckAvailability(ctx context.Context, token string, hashes []string) (map[string][]providers.CachedFile, error) {
+	available := make(map[string][]providers.CachedFile)
+	if len(hashes) == 0 {
+		return available, nil
+	}
+
+	params := url.Values{}
+	params.Set("hash", strings.Join(hashes, ","))
+	params.Set("format", "object")
+	params.Set("list_files", "true")
+
+	// Uncached hashes are missing from the object
+	var data map[string]struct {
+		Name  string `json:"name"`
+		Files []struct {
+			Name string `json:"name"`
+			Size int64  `json:"size"`
+		} `json:"files"`
+	}
+	if err := c.do(ctx, "GET", "/torrents/checkcached?"+params.Encode(), token, nil, "", &data); err != nil {
+		return nil, err
+	}
+
+	for hash, entry := range data {
+		files := []providers.CachedFile{}
+		for i, f := range entry.Files {
+			files = append(files, providers.CachedFile{ID: i + 1, Path: f.Name, Bytes: f.Size})
+		}
+		available[strings.ToLower(hash)] = files
+	}
+	return available, nil
+}
+
+func (c *Client) AccountInfo(ctx context.Context, token string) (*providers.DebridAccount, error) {
+	var data struct {
+		Email            string `json:"email"`
+		Plan             int    `json:"plan"` // 0 = free
+		PremiumExpiresAt string `json:"premium_expires_at"`
+	}
+	if err := c.do(ctx, "GET", "/user/me", token, nil, "", &data); err != nil {
+		return nil, err
+	}
+
+	account := &providers.DebridAccount{
+		Service:  c.Name(),
+		Username: data.Email,
+		Email:    data.Email,
+		Premium:  data.Plan > 0,
+	}
+	if t, err := time.Parse(time.RFC3339, data.PremiumExpiresAt); err == nil {
+		account.ExpiresAt = t
+		account.PremiumDays = max(0, int(time.Until(t).Hours()/24))
+	}
+	return account, nil
+}
+
+// do sends an authenticated request and decodes the "data" member into out
+func (c *Client) do(ctx context.Context, method, endpoint, token string, body io.Reader, contentType string, out any) error {
+	u := c.BaseURL + endpoint
+	log.Printf("TB Request: %s %s", method, strings.SplitN(u, "?", 2)[0])
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("TorBox %s: %s", strings.SplitN(endpoint, "?", 2)[0], resp.Status)
+	}
+	if !env.Success {
+		msg := env.Detail
+		if msg == "" {
+			msg = env.Error
+		}
+		return &apiError{Endpoint: strings.SplitN(endpoint, "?", 2)[0], HTTPStatus: resp.StatusCode, Message: msg}
+	}
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
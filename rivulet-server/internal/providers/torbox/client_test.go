@@ -0,0 +1,132 @@
+package torbox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"rivulet_server/internal/providers"
+	"strings"
+	"testing"
+)
+
+// replying returns a client whose every request gets status and body back.
+// The last request's query is stored in query.
+func replying(t *testing.T, status int, body string, query *url.Values) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if query != nil {
+			*query = r.URL.Query()
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient()
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestTorrentStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     int
+		body     string
+		status   string
+		progress int
+		err      error
+	}{
+		{"cached", 200, `{"success": true, "data": {"id": 7, "download_state": "cached", "download_finished": true, "download_present": true}}`,
+			providers.DebridStatusDownloaded, 100, nil},
+		{"downloading", 200, `{"success": true, "data": {"id": 8, "download_state": "downloading", "progress": 0.5}}`,
+			providers.DebridStatusDownloading, 50, nil},
+		{"stalled", 200, `{"success": true, "data": {"id": 9, "download_state": "stalled (no seeds)"}}`,
+			providers.DebridStatusError, 0, nil},
+		{"deleted", 404, `{"success": false, "error": "DATABASE_ERROR", "detail": "Torrent not found."}`,
+			"", 0, providers.ErrDebridTorrentNotFound},
+		{"no data", 200, `{"success": true, "data": null}`,
+			"", 0, providers.ErrDebridTorrentNotFound},
+		{"bad token", 403, `{"success": false, "error": "BAD_TOKEN", "detail": "Invalid API token."}`,
+			"", 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			torrent, err := replying(t, tt.code, tt.body, nil).TorrentStatus(context.Background(), "token", "7")
+			switch {
+			case tt.err != nil:
+				if !errors.Is(err, tt.err) {
+					t.Errorf("err = %v, want %v", err, tt.err)
+				}
+			case tt.status == "":
+				if err == nil || errors.Is(err, providers.ErrDebridTorrentNotFound) {
+					t.Errorf("err = %v, want the API's error", err)
+				}
+			case err != nil:
+				t.Fatalf("TorrentStatus: %v", err)
+			case torrent.Status != tt.status || torrent.Progress != tt.progress:
+				t.Errorf("status %q, progress %d; want %q, %d", torrent.Status, torrent.Progress, tt.status, tt.progress)
+			}
+		})
+	}
+}
+
+func TestFileIDsShiftToOneBased(t *testing.T) {
+	c := replying(t, 200, `{"success": true, "data": {
+		"id": 7, "hash": "ABCDEF", "download_finished": true, "download_present": true,
+		"files": [{"id": 0, "name": "Show.S01/E01.mkv", "size": 100}, {"id": 1, "name": "Show.S01/E02.mkv", "size": 200}]}}`, nil)
+
+	torrent, err := c.TorrentStatus(context.Background(), "token", "7")
+	if err != nil {
+		t.Fatalf("TorrentStatus: %v", err)
+	}
+	if torrent.Hash != "abcdef" || len(torrent.Files) != 2 || torrent.Files[0].ID != 1 || torrent.Files[1].ID != 2 {
+		t.Fatalf("hash %q, files %+v; want IDs 1 and 2", torrent.Hash, torrent.Files)
+	}
+	// The link keeps TorBox's own 0-based ID
+	if torrent.Files[1].Link != "torbox://7/1" {
+		t.Errorf("link = %q", torrent.Files[1].Link)
+	}
+
+	unfinished := replying(t, 200, `{"success": true, "data": {"id": 8, "files": [{"id": 0, "name": "Movie.mkv"}]}}`, nil)
+	if torrent, err := unfinished.TorrentStatus(context.Background(), "token", "8"); err != nil || torrent.Files[0].Link != "" {
+		t.Errorf("unfinished files must not have links: %+v, %v", torrent, err)
+	}
+}
+
+func TestUnrestrictFile(t *testing.T) {
+	var query url.Values
+	c := replying(t, 200, `{"success": true, "data": "https://cdn.torbox.app/dl/Show.S01E02.mkv?token=x"}`, &query)
+
+	link, err := c.UnrestrictFile(context.Background(), "token", "torbox://7/1")
+	if err != nil {
+		t.Fatalf("UnrestrictFile: %v", err)
+	}
+	if link.Filename != "Show.S01E02.mkv" || !strings.HasPrefix(link.Download, "https://cdn.torbox.app/") {
+		t.Errorf("link = %+v", link)
+	}
+	// requestdl takes the token as a query parameter, not a header
+	if query.Get("token") != "token" || query.Get("torrent_id") != "7" || query.Get("file_id") != "1" {
+		t.Errorf("requestdl query = %v", query)
+	}
+
+	if _, err := c.UnrestrictFile(context.Background(), "token", "https://example.com/file"); err == nil {
+		t.Error("foreign link accepted")
+	}
+}
+
+func TestCheckAvailability(t *testing.T) {
+	c := replying(t, 200, `{"success": true, "data": {"AAA": {"name": "Movie", "files": [{"name": "Movie.mkv", "size": 100}, {"name": "Movie.srt", "size": 1}]}}}`, nil)
+
+	available, err := c.CheckAvailability(context.Background(), "token", []string{"aaa", "bbb"})
+	if err != nil {
+		t.Fatalf("CheckAvailability: %v", err)
+	}
+	if len(available) != 1 {
+		t.Fatalf("got %+v, want only aaa", available)
+	}
+	files := available["aaa"]
+	if len(files) != 2 || files[0].ID != 1 || files[1].ID != 2 || files[0].Path != "Movie.mkv" {
+		t.Errorf("files = %+v", files)
+	}
+}
@@ -1,13 +1,12 @@
 package torrentio
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"regexp"
 	"rivulet_server/internal/providers"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -30,12 +29,18 @@ func (c *Client) Name() string {
 	return "Torrentio"
 }
 
+// ID is the instance URL, so a self-hosted Torrentio doesn't share the public one's cache
+func (c *Client) ID() string {
+	return c.BaseURL
+}
+
 // --- Internal JSON Response Models ---
 type Response struct {
 	Streams []StreamItem `json:"streams"`
 }
 
 type StreamItem struct {
+	Name     string `json:"name"` // "Torrentio\n4k"; no cache marker since the URL carries no debrid key
 	Title    string `json:"title"`
 	InfoHash string `json:"infoHash"`
 	FileIdx  int    `json:"fileIdx"`
@@ -44,49 +49,7 @@ type StreamItem struct {
 	} `json:"behaviorHints,omitempty"`
 }
 
-// --- Regex Parsers ---
-// Extract size: "💾 1.2 GB"
-var sizeRegex = regexp.MustCompile(`💾\s+(\d+(?:\.\d+)?)\s+(GB|MB)`)
-// Extract seeds: "👤 123"
-var seedsRegex = regexp.MustCompile(`👤\s*(\d+)`)
-// Extract resolution: "4k", "2160p", "1080p"
-var resRegex = regexp.MustCompile(`(?i)\b(2160p|4k|1080p|720p|480p)\b`)
-
-func parseMetadata(rawTitle string) (int64, int, string) {
-	// 1. Size
-	var bytes int64
-	sizeMatch := sizeRegex.FindStringSubmatch(rawTitle)
-	if len(sizeMatch) >= 3 {
-		val, _ := strconv.ParseFloat(sizeMatch[1], 64)
-		unit := sizeMatch[2]
-		if unit == "GB" {
-			bytes = int64(val * 1024 * 1024 * 1024)
-		} else if unit == "MB" {
-			bytes = int64(val * 1024 * 1024)
-		}
-	}
-
-	// 2. Seeds
-	seeds := 0
-	seedMatch := seedsRegex.FindStringSubmatch(rawTitle)
-	if len(seedMatch) >= 2 {
-		seeds, _ = strconv.Atoi(seedMatch[1])
-	}
-
-	// 3. Quality (Resolution)
-	quality := "Unknown"
-	resMatch := resRegex.FindStringSubmatch(rawTitle)
-	if len(resMatch) >= 2 {
-		quality = strings.ToLower(resMatch[1])
-		if quality == "2160p" {
-			quality = "4k"
-		}
-	}
-
-	return bytes, seeds, quality
-}
-
-func (c *Client) Scrape(mediaType, imdbID, rdKey string, season, episode int) ([]*providers.Stream, error) {
+func (c *Client) Scrape(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int) ([]*providers.Stream, error) {
 	targetID := imdbID
 	if mediaType == "series" || mediaType == "show" {
 		targetID = fmt.Sprintf("%s:%d:%d", imdbID, season, episode)
@@ -101,7 +64,7 @@ func (c *Client) Scrape(mediaType, imdbID, rdKey string, season, episode int) ([
 
 	log.Print(url)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -126,24 +89,27 @@ func (c *Client) Scrape(mediaType, imdbID, rdKey string, season, episode int) ([
 
 	var streams []*providers.Stream
 	for _, item := range result.Streams {
-		size, seeds, quality := parseMetadata(item.Title)
+		size, seeds, quality := providers.ParseAddonTitle(item.Title)
 		cleanTitle := strings.Split(item.Title, "\n")[0]
-		magnet := fmt.Sprintf("magnet:?xt=urn:btih:%s", item.InfoHash)
+		hash := strings.ToLower(item.InfoHash)
+		magnet := fmt.Sprintf("magnet:?xt=urn:btih:%s", hash)
 
 		var fIdx *int
 		idx := item.FileIdx
 		fIdx = &idx
 
-		streams = append(streams, &providers.Stream{
+		stream := &providers.Stream{
 			Title:     cleanTitle,
 			Size:      size,
-			Hash:      item.InfoHash,
+			Hash:      hash,
 			Magnet:    magnet,
 			Seeds:     seeds,
 			Quality:   quality,
 			Source:    "Torrentio",
 			FileIndex: fIdx,
-		})
+		}
+		stream.ParseRelease(item.Title)
+		streams = append(streams, stream)
 	}
 
 	return streams, nil
@@ -0,0 +1,243 @@
+package torznab
+
+import (
+	"context"
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"rivulet_server/internal/providers"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client is a providers.Scraper for a single Torznab endpoint (Jackett, Prowlarr, ...).
+// Register one Client per indexer; each carries its own API key.
+type Client struct {
+	Label      string // Display name, defaults to the endpoint host and indexer
+	BaseURL    string // e.g. http://localhost:9117/api/v2.0/indexers/all/results/torznab
+	APIKey     string
+	Categories string // Optional comma separated Torznab categories, e.g. "2000,5000"
+	HttpClient *http.Client
+}
+
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		APIKey:  apiKey,
+		HttpClient: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+func (c *Client) Name() string {
+	if c.Label != "" {
+		return c.Label
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || u.Host == "" {
+		return "Torznab"
+	}
+	if indexer := indexerName(u.Path); indexer != "" {
+		return "Torznab (" + u.Host + "/" + indexer + ")"
+	}
+	return "Torznab (" + u.Host + ")"
+}
+
+// indexerName picks the indexer out of an aggregator's endpoint path:
+// Jackett's "/api/v2.0/indexers/{name}/results/torznab" or Prowlarr's "/{id}/api"
+func indexerName(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range parts {
+		if part == "indexers" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	if len(parts) == 2 && parts[1] == "api" {
+		return parts[0]
+	}
+	return ""
+}
+
+// ID is the endpoint and categories: one Jackett or Prowlarr host serves many indexers
+func (c *Client) ID() string {
+	return c.BaseURL + "?cat=" + c.Categories
+}
+
+// --- Internal XML Response Models ---
+
+type rss struct {
+	Channel struct {
+		Items []item `xml:"item"`
+	} `xml:"channel"`
+}
+
+type item struct {
+	Title     string `xml:"title"`
+	GUID      string `xml:"guid"`
+	Link      string `xml:"link"`
+	Size      int64  `xml:"size"`
+	Enclosure struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	Attrs []attr `xml:"attr"` // <torznab:attr name="..." value="..."/>
+}
+
+type attr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// apiError is returned in place of <rss> when a request is rejected
+type apiError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        string   `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+func (it *item) attr(name string) string {
+	for _, a := range it.Attrs {
+		if a.Name == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Extract the info hash from a magnet link: "xt=urn:btih:<hash>"
+var btihRegex = regexp.MustCompile(`(?i)xt=urn:btih:([a-z0-9]+)`)
+
+// normalizeHash lowercases hex hashes and converts base32 (32 char) hashes to hex
+func normalizeHash(h string) string {
+	switch len(h) {
+	case 40:
+		return strings.ToLower(h)
+	case 32:
+		raw, err := base32.StdEncoding.DecodeString(strings.ToUpper(h))
+		if err != nil {
+			return ""
+		}
+		return hex.EncodeToString(raw)
+	}
+	return ""
+}
+
+func (it *item) hashAndMagnet() (string, string) {
+	magnet := it.attr("magneturl")
+	if magnet == "" && strings.HasPrefix(it.Link, "magnet:") {
+		magnet = it.Link
+	}
+	if magnet == "" && strings.HasPrefix(it.Enclosure.URL, "magnet:") {
+		magnet = it.Enclosure.URL
+	}
+
+	hash := normalizeHash(it.attr("infohash"))
+	if hash == "" && magnet != "" {
+		if m := btihRegex.FindStringSubmatch(magnet); len(m) >= 2 {
+			hash = normalizeHash(m[1])
+		}
+	}
+
+	if hash != "" && magnet == "" {
+		magnet = fmt.Sprintf("magnet:?xt=urn:btih:%s", hash)
+	}
+	return hash, magnet
+}
+
+func (c *Client) Scrape(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int) ([]*providers.Stream, error) {
+	if !strings.HasPrefix(imdbID, "tt") {
+		return nil, providers.ErrNotSupported
+	}
+
+	q := url.Values{}
+	q.Set("apikey", c.APIKey)
+	// Torznab expects the numeric part of the IMDB ID
+	q.Set("imdbid", strings.TrimPrefix(imdbID, "tt"))
+	if c.Categories != "" {
+		q.Set("cat", c.Categories)
+	}
+
+	if mediaType == "series" || mediaType == "show" || mediaType == "tv" {
+		q.Set("t", "tvsearch")
+		if season > 0 {
+			q.Set("season", strconv.Itoa(season))
+		}
+		if episode > 0 {
+			q.Set("ep", strconv.Itoa(episode))
+		}
+	} else {
+		q.Set("t", "movie")
+	}
+
+	u := fmt.Sprintf("%s/api?%s", c.BaseURL, q.Encode())
+	log.Printf("Torznab Request [%s]: t=%s imdbid=%s", c.Name(), q.Get("t"), imdbID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var apiErr apiError
+	if xml.Unmarshal(body, &apiErr) == nil && apiErr.Code != "" {
+		return nil, fmt.Errorf("torznab error %s: %s", apiErr.Code, apiErr.Description)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("torznab returned status: %d", resp.StatusCode)
+	}
+
+	var feed rss
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+
+	var streams []*providers.Stream
+	for _, it := range feed.Channel.Items {
+		hash, magnet := it.hashAndMagnet()
+		// Items without a hash would need the .torrent downloaded; skip them
+		if hash == "" {
+			continue
+		}
+
+		size := it.Size
+		if v, err := strconv.ParseInt(it.attr("size"), 10, 64); err == nil && v > 0 {
+			size = v
+		}
+		if size == 0 {
+			size = it.Enclosure.Length
+		}
+
+		seeds, _ := strconv.Atoi(it.attr("seeders"))
+
+		stream := &providers.Stream{
+			Title:  it.Title,
+			Size:   size,
+			Hash:   hash,
+			Magnet: magnet,
+			Seeds:  seeds,
+			Source: c.Name(),
+		}
+		stream.ParseRelease(it.Title)
+		streams = append(streams, stream)
+	}
+
+	return streams, nil
+}
@@ -0,0 +1,161 @@
+package torznab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"rivulet_server/internal/providers"
+	"testing"
+)
+
+const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+<channel>
+	<item>
+		<title>Breaking.Bad.S01E02.1080p.BluRay.x264-ROVERS</title>
+		<link>http://indexer/dl/1.torrent</link>
+		<size>1000</size>
+		<torznab:attr name="infohash" value="AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"/>
+		<torznab:attr name="magneturl" value="magnet:?xt=urn:btih:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA&amp;dn=bb"/>
+		<torznab:attr name="seeders" value="42"/>
+		<torznab:attr name="size" value="2147483648"/>
+	</item>
+	<item>
+		<title>Breaking.Bad.S01E02.720p.HDTV.x264-CTU</title>
+		<link>magnet:?xt=urn:btih:AERUKZ4JVPG66AJDIVTYTK6N54ASGRLH&amp;dn=bb</link>
+		<torznab:attr name="seeders" value="3"/>
+	</item>
+	<item>
+		<title>Breaking.Bad.S01E02.480p.WEB-DL</title>
+		<enclosure url="http://indexer/dl/3.torrent" length="555" type="application/x-bittorrent"/>
+		<torznab:attr name="infohash" value="bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"/>
+	</item>
+	<item>
+		<title>No hash, .torrent only</title>
+		<link>http://indexer/dl/4.torrent</link>
+	</item>
+</channel>
+</rss>`
+
+// indexerStub serves canned Torznab responses; streams is the feed it answers with
+func indexerStub(t *testing.T, streams string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/api" {
+			http.NotFound(w, r)
+			return
+		}
+		if q.Get("apikey") != "secret" {
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Incorrect user credentials"/>`))
+			return
+		}
+		if q.Get("t") != "tvsearch" || q.Get("imdbid") != "0903747" || q.Get("season") != "1" || q.Get("ep") != "2" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(streams))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestScrapeParsesFeed(t *testing.T) {
+	srv := indexerStub(t, feed)
+	c := NewClient(srv.URL+"/", "secret")
+
+	streams, err := c.Scrape(context.Background(), "series", "tt0903747", "", 1, 2)
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if len(streams) != 3 {
+		t.Fatalf("got %d streams, want 3 (the hashless item is skipped)", len(streams))
+	}
+
+	// infohash and magneturl attributes; the size attribute beats <size>
+	s := streams[0]
+	if s.Hash != "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" || s.Seeds != 42 || s.Size != 2147483648 {
+		t.Errorf("first stream: hash %q, seeds %d, size %d", s.Hash, s.Seeds, s.Size)
+	}
+	if s.Magnet != "magnet:?xt=urn:btih:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA&dn=bb" {
+		t.Errorf("magnet = %q", s.Magnet)
+	}
+	if s.Source != c.Name() || s.Release.Resolution != "1080p" {
+		t.Errorf("source %q, resolution %q", s.Source, s.Release.Resolution)
+	}
+
+	// Base32 hash from a magnet <link>
+	if streams[1].Hash != "0123456789abcdef0123456789abcdef01234567" || streams[1].Seeds != 3 {
+		t.Errorf("base32 stream: hash %q, seeds %d", streams[1].Hash, streams[1].Seeds)
+	}
+
+	// Only an infohash: the magnet is built from it, the size comes from the enclosure
+	if streams[2].Magnet != "magnet:?xt=urn:btih:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb" || streams[2].Size != 555 {
+		t.Errorf("infohash-only stream: magnet %q, size %d", streams[2].Magnet, streams[2].Size)
+	}
+}
+
+func TestScrapeErrors(t *testing.T) {
+	srv := indexerStub(t, feed)
+	ctx := context.Background()
+
+	_, err := NewClient(srv.URL, "wrong").Scrape(ctx, "series", "tt0903747", "", 1, 2)
+	if err == nil || err.Error() != "torznab error 100: Incorrect user credentials" {
+		t.Errorf("bad key: err = %v", err)
+	}
+	if _, err := NewClient(srv.URL+"/missing", "secret").Scrape(ctx, "movie", "tt1375666", "", 0, 0); err == nil {
+		t.Error("404 didn't fail")
+	}
+	if _, err := NewClient(srv.URL, "secret").Scrape(ctx, "movie", "tmdb:27205", "", 0, 0); !errors.Is(err, providers.ErrNotSupported) {
+		t.Errorf("non-IMDb ID: err = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestMultipleIndexers(t *testing.T) {
+	first := NewClient(indexerStub(t, feed).URL, "secret")
+	second := NewClient(indexerStub(t, `<rss><channel><item>
+		<title>Breaking.Bad.S01E02.2160p.WEB-DL</title>
+		<torznab:attr xmlns:torznab="http://torznab.com/schemas/2015/feed" name="infohash" value="cccccccccccccccccccccccccccccccccccccccc"/>
+	</item></channel></rss>`).URL, "secret")
+	broken := NewClient(indexerStub(t, feed).URL, "wrong")
+	broken.Label = "Broken"
+
+	m := providers.NewManager(first, second, broken)
+	m.Cache = nil
+	result := m.ScrapeAll(context.Background(), "series", "tt0903747", "", 1, 2, false)
+
+	if len(result.Streams) != 4 {
+		t.Errorf("got %d streams, want 4", len(result.Streams))
+	}
+	if first.Name() == second.Name() {
+		t.Errorf("indexers share the name %q", first.Name())
+	}
+	statuses := map[string]string{}
+	for _, p := range result.Providers {
+		statuses[p.Name] = p.Status
+	}
+	if statuses[first.Name()] != providers.StatusOK || statuses[second.Name()] != providers.StatusOK || statuses["Broken"] != providers.StatusError {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
+
+func TestNameIncludesIndexer(t *testing.T) {
+	tests := map[string]string{
+		"http://jackett:9117/api/v2.0/indexers/1337x/results/torznab": "Torznab (jackett:9117/1337x)",
+		"http://jackett:9117/api/v2.0/indexers/rarbg/results/torznab": "Torznab (jackett:9117/rarbg)",
+		"http://prowlarr:9696/12/api":                                 "Torznab (prowlarr:9696/12)",
+		"http://indexer.example.com/api":                              "Torznab (indexer.example.com)",
+	}
+	for endpoint, want := range tests {
+		if got := NewClient(endpoint, "key").Name(); got != want {
+			t.Errorf("Name(%s) = %q, want %q", endpoint, got, want)
+		}
+	}
+
+	labeled := NewClient("http://jackett:9117/api/v2.0/indexers/all/results/torznab", "key")
+	labeled.Label = "Everything"
+	if labeled.Name() != "Everything" {
+		t.Errorf("labeled Name() = %q", labeled.Name())
+	}
+}
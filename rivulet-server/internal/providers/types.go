@@ -1,18 +1,107 @@
 package providers
 
+import (
+	"context"
+	"errors"
+	"regexp"
+	"rivulet_server/internal/release"
+)
+
+// ErrNotSupported is returned by scrapers that don't handle the requested type or ID
+var ErrNotSupported = errors.New("not supported by provider")
+
 type Stream struct {
-	Title       string `json:"title"`
-	Size        int64  `json:"size"` // In Bytes
-	Hash        string `json:"hash"`
-	Magnet      string `json:"magnet"`
-	Quality     string `json:"quality"` // "4k", "1080p"
-	Seeds       int    `json:"seeds"`
-	Source      string `json:"source"` // "torrentio", "knightcrawler"
-	FileIndex   *int   `json:"file_index,omitempty"`
+	Title     string `json:"title"`
+	Size      int64  `json:"size"` // In Bytes
+	Hash      string `json:"hash"`
+	Magnet    string `json:"magnet"`
+	Quality   string `json:"quality"` // "4k", "1080p"
+	Seeds     int    `json:"seeds"`
+	Source    string `json:"source"` // "torrentio", "knightcrawler"
+	FileIndex *int   `json:"file_index,omitempty"`
+
+	// Structured fields parsed from the release name
+	Release release.Info `json:"release"`
+
+	// Single episode, episode range or season/series pack; nil for movies
+	Coverage *Coverage `json:"coverage,omitempty"`
+
+	// Debrid cache status; CachedSource says how we know ("addon", "history", "debrid")
+	Cached       bool         `json:"cached"`
+	CachedSource string       `json:"cached_source,omitempty"`
+	CachedFiles  []CachedFile `json:"cached_files,omitempty"`
+
+	// Ranking output, filled when streams are sorted for a profile
+	Score          float64      `json:"score"`
+	ScoreBreakdown []ScoreEntry `json:"score_breakdown,omitempty"`
+}
+
+// CachedFile is a file the debrid service can serve without downloading
+type CachedFile struct {
+	ID    int    `json:"id"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Addons configured with a debrid key mark cached results, e.g. Torrentio "[RD+]" or Comet "⚡"
+var cachedHintRegex = regexp.MustCompile(`\[(?:RD|AD|PM|TB|DL|ED|OC)\+\]|⚡`)
+
+// MarkCachedFromHint flags the stream as cached when the addon text carries a cache marker.
+// Only addon URLs that embed the user's debrid key get these markers, so it's no
+// signal for the built-in Torrentio scraper, whose URL has none.
+func (s *Stream) MarkCachedFromHint(addonText string) {
+	if cachedHintRegex.MatchString(addonText) {
+		s.Cached = true
+		s.CachedSource = "addon"
+	}
+}
+
+// ScoreEntry explains how much a single ranking rule contributed to a stream's score
+type ScoreEntry struct {
+	Rule   string  `json:"rule"`
+	Points float64 `json:"points"`
+	Reason string  `json:"reason"`
+}
+
+// ParseRelease fills Release from the raw provider title and derives Quality
+// from the parsed resolution when the provider didn't report one.
+// Every scraper should call this so streams are described the same way.
+func (s *Stream) ParseRelease(rawTitle string) {
+	s.Release = release.Parse(rawTitle)
+	if s.Quality == "" || s.Quality == "Unknown" {
+		s.Quality = QualityFromResolution(s.Release.Resolution)
+	}
+}
+
+// QualityFromResolution maps a parsed resolution to the legacy Quality label
+func QualityFromResolution(resolution string) string {
+	switch resolution {
+	case "":
+		return "Unknown"
+	case "2160p":
+		return "4k"
+	}
+	return resolution
 }
 
 type Scraper interface {
 	Name() string
-	// Scrape fetches streams. type="movie"|"series", id="tt123", season/ep for shows
-	Scrape(mediaType, imdbID, rdKey string, season, episode int) ([]*Stream, error)
-}
\ No newline at end of file
+	// Scrape fetches streams. type="movie"|"series", id="tt123", season/ep for shows.
+	// Implementations must give up when ctx is cancelled or its deadline passes.
+	Scrape(ctx context.Context, mediaType, imdbID, rdKey string, season, episode int) ([]*Stream, error)
+}
+
+// ScraperID is implemented by scrapers whose Name isn't unique or stable,
+// e.g. several addons or indexers sharing a display name
+type ScraperID interface {
+	// ID identifies the scraper's source, such as its endpoint URL
+	ID() string
+}
+
+// scraperID is what the scrape cache keys a scraper's results on
+func scraperID(s Scraper) string {
+	if identified, ok := s.(ScraperID); ok {
+		return identified.ID()
+	}
+	return s.Name()
+}
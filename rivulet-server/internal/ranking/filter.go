@@ -0,0 +1,96 @@
+package ranking
+
+import (
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"slices"
+	"strings"
+)
+
+// Filter reasons reported in FilterReport.Reasons
+const (
+	ReasonMinSize         = "min_size"
+	ReasonMaxSize         = "max_size"
+	ReasonMinSeeds        = "min_seeds"
+	ReasonExcludedSource  = "excluded_source"
+	ReasonExcludedKeyword = "excluded_keyword"
+	ReasonExcludedGroup   = "excluded_group"
+	ReasonMissingLanguage = "missing_language"
+)
+
+// FilterReport summarizes how many streams were dropped and why.
+// Each stream is counted once, under the first rule it broke.
+type FilterReport struct {
+	Filtered int            `json:"filtered"`
+	Reasons  map[string]int `json:"reasons"`
+}
+
+// Filter returns the streams that pass every rule plus a report of what was dropped
+func Filter(rules models.FilterRules, streams []*providers.Stream) ([]*providers.Stream, FilterReport) {
+	report := FilterReport{Reasons: map[string]int{}}
+	kept := make([]*providers.Stream, 0, len(streams))
+
+	for _, s := range streams {
+		if reason := rejectReason(rules, s); reason != "" {
+			report.Filtered++
+			report.Reasons[reason]++
+			continue
+		}
+		kept = append(kept, s)
+	}
+
+	return kept, report
+}
+
+func rejectReason(rules models.FilterRules, s *providers.Stream) string {
+	const mb = 1024 * 1024
+
+	// Unknown sizes (0) can't be judged, so size rules only apply when we have one
+	if s.Size > 0 {
+		if rules.MinSizeMB > 0 && s.Size < rules.MinSizeMB*mb {
+			return ReasonMinSize
+		}
+		if rules.MaxSizeMB > 0 && s.Size > rules.MaxSizeMB*mb {
+			return ReasonMaxSize
+		}
+	}
+
+	// Many addons and indexers don't report seeders (0), so only known counts are judged
+	if rules.MinSeeds > 0 && s.Seeds > 0 && s.Seeds < rules.MinSeeds {
+		return ReasonMinSeeds
+	}
+
+	if s.Release.Source != "" && containsFold(rules.ExcludedSources, s.Release.Source) {
+		return ReasonExcludedSource
+	}
+
+	title := strings.ToLower(s.Title)
+	for _, kw := range rules.ExcludedKeywords {
+		if kw != "" && strings.Contains(title, strings.ToLower(kw)) {
+			return ReasonExcludedKeyword
+		}
+	}
+
+	if s.Release.Group != "" && containsFold(rules.ExcludedGroups, s.Release.Group) {
+		return ReasonExcludedGroup
+	}
+
+	if len(rules.RequiredLanguages) > 0 && len(s.Release.Languages) > 0 {
+		ok := slices.Contains(s.Release.Languages, "multi")
+		for _, lang := range rules.RequiredLanguages {
+			if containsFold(s.Release.Languages, lang) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return ReasonMissingLanguage
+		}
+	}
+
+	return ""
+}
+
+func containsFold(list []string, value string) bool {
+	return slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, value) })
+}
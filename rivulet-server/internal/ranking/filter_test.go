@@ -0,0 +1,49 @@
+package ranking
+
+import (
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	const mb = 1024 * 1024
+	stream := func(title string, size int64, seeds int) *providers.Stream {
+		s := &providers.Stream{Title: title, Size: size, Seeds: seeds}
+		s.ParseRelease(title)
+		return s
+	}
+
+	tests := []struct {
+		name   string
+		rules  models.FilterRules
+		stream *providers.Stream
+		reason string // "" when the stream is kept
+	}{
+		{"no rules keep cams", models.FilterRules{}, stream("Movie.2024.HDCAM.x264", 700*mb, 5), ""},
+		{"excluded source", models.FilterRules{ExcludedSources: []string{"cam"}}, stream("Movie.2024.HDCAM.x264", 700*mb, 5), ReasonExcludedSource},
+		{"too small", models.FilterRules{MinSizeMB: 1000}, stream("Movie.2024.1080p.WEB-DL", 700*mb, 5), ReasonMinSize},
+		{"too big", models.FilterRules{MaxSizeMB: 500}, stream("Movie.2024.1080p.WEB-DL", 700*mb, 5), ReasonMaxSize},
+		{"unknown size kept", models.FilterRules{MinSizeMB: 1000}, stream("Movie.2024.1080p.WEB-DL", 0, 5), ""},
+		{"too few seeds", models.FilterRules{MinSeeds: 10}, stream("Movie.2024.1080p.WEB-DL", 700*mb, 3), ReasonMinSeeds},
+		{"unknown seeds kept", models.FilterRules{MinSeeds: 10}, stream("Movie.2024.1080p.WEB-DL", 700*mb, 0), ""},
+		{"keyword", models.FilterRules{ExcludedKeywords: []string{"hardcoded"}}, stream("Movie.2024.1080p.HARDCODED.WEB-DL", 700*mb, 5), ReasonExcludedKeyword},
+		{"group", models.FilterRules{ExcludedGroups: []string{"yts"}}, stream("Movie.2024.1080p.WEBRip-YTS", 700*mb, 5), ReasonExcludedGroup},
+		{"language", models.FilterRules{RequiredLanguages: []string{"en"}}, stream("Movie.2024.1080p.FRENCH.WEB-DL", 700*mb, 5), ReasonMissingLanguage},
+		{"untagged language kept", models.FilterRules{RequiredLanguages: []string{"en"}}, stream("Movie.2024.1080p.WEB-DL", 700*mb, 5), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, report := Filter(tt.rules, []*providers.Stream{tt.stream})
+			if tt.reason == "" {
+				if len(kept) != 1 || report.Filtered != 0 {
+					t.Errorf("dropped: %v", report.Reasons)
+				}
+				return
+			}
+			if len(kept) != 0 || report.Reasons[tt.reason] != 1 {
+				t.Errorf("got %d kept, reasons %v, want dropped for %s", len(kept), report.Reasons, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package ranking
+
+import (
+	"fmt"
+	"math"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Points applied when a hard preference (max resolution, keywords) is violated.
+// Large enough to sink the stream below anything that respects the rules.
+const violationPenalty = -1000
+
+// Runtime assumed for the size rule when the caller doesn't know it
+const (
+	DefaultMovieRuntime   = 120
+	DefaultEpisodeRuntime = 45
+)
+
+const DefaultPreset = "quality"
+
+// Presets are the built-in ranking profiles
+var Presets = map[string]models.RankingRules{
+	// Best picture first: 4K HDR, then by availability
+	"quality": {
+		PreferredResolution: "2160p",
+		ResolutionWeight:    100,
+		PreferredCodecs:     []string{"HEVC", "AV1", "AVC"},
+		CodecWeight:         10,
+		HDR:                 "prefer",
+		HDRWeight:           20,
+		SeedsWeight:         15,
+		CachedWeight:        30,
+	},
+	// 1080p at sensible bitrates, no remuxes
+	"bandwidth_saver": {
+		PreferredResolution: "1080p",
+		MaxResolution:       "1080p",
+		ResolutionWeight:    60,
+		PreferredCodecs:     []string{"HEVC", "AV1", "AVC"},
+		CodecWeight:         30,
+		MinMBPerMinute:      5,
+		MaxMBPerMinute:      25,
+		SizeWeight:          40,
+		ForbiddenKeywords:   []string{"remux"},
+		SeedsWeight:         15,
+		CachedWeight:        50,
+	},
+	// Plays everywhere: H.264 SDR up to 1080p
+	"compatibility": {
+		PreferredResolution: "1080p",
+		MaxResolution:       "1080p",
+		ResolutionWeight:    60,
+		PreferredCodecs:     []string{"AVC", "HEVC"},
+		CodecWeight:         50,
+		HDR:                 "avoid",
+		HDRWeight:           40,
+		SeedsWeight:         20,
+		CachedWeight:        50,
+	},
+	// Whole seasons first so the next episodes are already on debrid
+	"binge": {
+		PreferredResolution: "1080p",
+		ResolutionWeight:    80,
+		PreferredCodecs:     []string{"HEVC", "AVC"},
+		CodecWeight:         10,
+		SeedsWeight:         15,
+		CachedWeight:        40,
+		Packs:               "prefer",
+		PackWeight:          50,
+	},
+}
+
+// PresetRules returns a copy of the named preset, falling back to the default
+func PresetRules(name string) (models.RankingRules, bool) {
+	rules, ok := Presets[name]
+	if !ok {
+		rules = Presets[DefaultPreset]
+	}
+	// Copying the struct still shares the slices with Presets
+	rules.PreferredCodecs = slices.Clone(rules.PreferredCodecs)
+	rules.RequiredKeywords = slices.Clone(rules.RequiredKeywords)
+	rules.ForbiddenKeywords = slices.Clone(rules.ForbiddenKeywords)
+	return rules, ok
+}
+
+// Highest weight a rule may have. Even with every weight at the maximum a
+// stream can't earn back a violationPenalty.
+const maxWeight = 100
+
+// ValidateRules checks custom rules for values the scorer can't use
+func ValidateRules(rules models.RankingRules) error {
+	weights := []struct {
+		name  string
+		value float64
+	}{
+		{"resolution_weight", rules.ResolutionWeight},
+		{"codec_weight", rules.CodecWeight},
+		{"hdr_weight", rules.HDRWeight},
+		{"size_weight", rules.SizeWeight},
+		{"seeds_weight", rules.SeedsWeight},
+		{"cached_weight", rules.CachedWeight},
+		{"pack_weight", rules.PackWeight},
+	}
+	for _, w := range weights {
+		if !(w.value >= 0 && w.value <= maxWeight) {
+			return fmt.Errorf("%s must be between 0 and %d", w.name, maxWeight)
+		}
+	}
+
+	if _, ok := resolutionRank[rules.PreferredResolution]; rules.PreferredResolution != "" && !ok {
+		return fmt.Errorf("unknown preferred_resolution %q", rules.PreferredResolution)
+	}
+	if _, ok := resolutionRank[rules.MaxResolution]; rules.MaxResolution != "" && !ok {
+		return fmt.Errorf("unknown max_resolution %q", rules.MaxResolution)
+	}
+	if !validPreference(rules.HDR) {
+		return fmt.Errorf(`hdr must be "prefer", "avoid" or empty`)
+	}
+	if !validPreference(rules.Packs) {
+		return fmt.Errorf(`packs must be "prefer", "avoid" or empty`)
+	}
+
+	if rules.MinMBPerMinute < 0 || rules.MaxMBPerMinute < 0 {
+		return fmt.Errorf("size limits must not be negative")
+	}
+	if rules.MaxMBPerMinute > 0 && rules.MinMBPerMinute > rules.MaxMBPerMinute {
+		return fmt.Errorf("min_mb_per_minute is larger than max_mb_per_minute")
+	}
+	return nil
+}
+
+func validPreference(p string) bool {
+	return p == "" || p == "prefer" || p == "avoid"
+}
+
+var resolutionRank = map[string]int{
+	"480p":  1,
+	"576p":  1,
+	"720p":  2,
+	"1080p": 3,
+	"2160p": 4,
+}
+
+// streamResolution prefers the parsed resolution and falls back to the legacy Quality label
+func streamResolution(s *providers.Stream) string {
+	if s.Release.Resolution != "" {
+		return s.Release.Resolution
+	}
+	switch strings.ToLower(s.Quality) {
+	case "4k", "2160p":
+		return "2160p"
+	case "1080p", "720p", "480p":
+		return strings.ToLower(s.Quality)
+	}
+	return ""
+}
+
+// Score computes a stream's score and fills Score/ScoreBreakdown.
+// runtimeMinutes is used by the size rule; pass 0 to skip it.
+func Score(rules models.RankingRules, s *providers.Stream, runtimeMinutes int) float64 {
+	var entries []providers.ScoreEntry
+	add := func(rule string, points float64, reason string) {
+		entries = append(entries, providers.ScoreEntry{Rule: rule, Points: math.Round(points*100) / 100, Reason: reason})
+	}
+
+	// 1. Resolution
+	res := streamResolution(s)
+	rank := resolutionRank[res]
+	if maxRank, ok := resolutionRank[rules.MaxResolution]; ok && rank > maxRank {
+		add("max_resolution", violationPenalty, fmt.Sprintf("%s is above the %s limit", res, rules.MaxResolution))
+	} else if prefRank, ok := resolutionRank[rules.PreferredResolution]; ok && rules.ResolutionWeight != 0 {
+		if rank == 0 {
+			add("resolution", 0, "unknown resolution")
+		} else {
+			distance := math.Abs(float64(rank - prefRank))
+			closeness := math.Max(0, 1-distance/3)
+			add("resolution", rules.ResolutionWeight*closeness, fmt.Sprintf("%s vs preferred %s", res, rules.PreferredResolution))
+		}
+	}
+
+	// 2. Codec
+	if len(rules.PreferredCodecs) > 0 && rules.CodecWeight != 0 {
+		codec := s.Release.VideoCodec
+		if i := slices.IndexFunc(rules.PreferredCodecs, func(c string) bool { return strings.EqualFold(c, codec) }); i >= 0 && codec != "" {
+			points := rules.CodecWeight * (1 - float64(i)/float64(len(rules.PreferredCodecs)))
+			add("codec", points, fmt.Sprintf("%s is preference #%d", codec, i+1))
+		} else if codec != "" {
+			add("codec", 0, fmt.Sprintf("%s is not a preferred codec", codec))
+		}
+	}
+
+	// 3. HDR
+	if rules.HDRWeight != 0 && rules.HDR != "" {
+		hasHDR := len(s.Release.HDR) > 0
+		switch {
+		case rules.HDR == "prefer" && hasHDR:
+			add("hdr", rules.HDRWeight, "has "+strings.Join(s.Release.HDR, "/"))
+		case rules.HDR == "avoid" && hasHDR:
+			add("hdr", -rules.HDRWeight, "has "+strings.Join(s.Release.HDR, "/")+", SDR preferred")
+		}
+	}
+
+	// 4. Size per runtime minute
+	if rules.SizeWeight != 0 && runtimeMinutes > 0 && s.Size > 0 && (rules.MinMBPerMinute > 0 || rules.MaxMBPerMinute > 0) {
+		mbPerMin := float64(s.Size) / (1024 * 1024) / float64(runtimeMinutes)
+		tooSmall := rules.MinMBPerMinute > 0 && mbPerMin < rules.MinMBPerMinute
+		tooBig := rules.MaxMBPerMinute > 0 && mbPerMin > rules.MaxMBPerMinute
+		switch {
+		case tooSmall:
+			add("size", -rules.SizeWeight, fmt.Sprintf("%.1f MB/min is below %.0f", mbPerMin, rules.MinMBPerMinute))
+		case tooBig:
+			add("size", -rules.SizeWeight, fmt.Sprintf("%.1f MB/min is above %.0f", mbPerMin, rules.MaxMBPerMinute))
+		default:
+			add("size", rules.SizeWeight, fmt.Sprintf("%.1f MB/min is within range", mbPerMin))
+		}
+	}
+
+	// 5. Keywords
+	title := strings.ToLower(s.Title)
+	for _, kw := range rules.RequiredKeywords {
+		if kw != "" && !strings.Contains(title, strings.ToLower(kw)) {
+			add("required_keyword", violationPenalty, fmt.Sprintf("missing %q", kw))
+		}
+	}
+	for _, kw := range rules.ForbiddenKeywords {
+		if kw != "" && strings.Contains(title, strings.ToLower(kw)) {
+			add("forbidden_keyword", violationPenalty, fmt.Sprintf("contains %q", kw))
+		}
+	}
+
+	// 6. Seeds (log scale, 1000+ seeds gets the full weight)
+	if rules.SeedsWeight != 0 && s.Seeds > 0 {
+		points := rules.SeedsWeight * math.Min(1, math.Log10(float64(s.Seeds)+1)/3)
+		add("seeds", points, fmt.Sprintf("%d seeds", s.Seeds))
+	}
+
+	// 7. Instant playback
+	if rules.CachedWeight != 0 && s.Cached {
+		add("cached", rules.CachedWeight, "cached on debrid")
+	}
+
+	// 8. Season packs
+	if rules.PackWeight != 0 && rules.Packs != "" && s.Coverage.IsPack() {
+		switch rules.Packs {
+		case "prefer":
+			add("pack", rules.PackWeight, s.Coverage.Kind+" pack")
+		case "avoid":
+			add("pack", -rules.PackWeight, s.Coverage.Kind+" pack, single episodes preferred")
+		}
+	}
+
+	total := 0.0
+	for _, e := range entries {
+		total += e.Points
+	}
+	s.Score = math.Round(total*100) / 100
+	s.ScoreBreakdown = entries
+	return s.Score
+}
+
+// Sort scores every stream and orders them best first.
+// Ties fall back to seeds, then size, like the old fixed ordering.
+func Sort(rules models.RankingRules, streams []*providers.Stream, runtimeMinutes int) {
+	for _, s := range streams {
+		Score(rules, s, runtimeMinutes)
+	}
+
+	sort.SliceStable(streams, func(i, j int) bool {
+		if streams[i].Score != streams[j].Score {
+			return streams[i].Score > streams[j].Score
+		}
+		if streams[i].Seeds != streams[j].Seeds {
+			return streams[i].Seeds > streams[j].Seeds
+		}
+		return streams[i].Size > streams[j].Size
+	})
+}
@@ -0,0 +1,173 @@
+package ranking
+
+import (
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"slices"
+	"testing"
+)
+
+const gb = 1024 * 1024 * 1024
+
+func newStream(title string, size int64, seeds int, cached bool) *providers.Stream {
+	s := &providers.Stream{Title: title, Size: size, Seeds: seeds, Cached: cached}
+	s.ParseRelease(title)
+	return s
+}
+
+// points returns what a rule contributed to the stream's score, and whether it applied at all
+func points(s *providers.Stream, rule string) (float64, bool) {
+	for _, e := range s.ScoreBreakdown {
+		if e.Rule == rule {
+			return e.Points, true
+		}
+	}
+	return 0, false
+}
+
+func TestScoreRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   models.RankingRules
+		stream  *providers.Stream
+		runtime int
+		rule    string
+		want    float64
+	}{
+		{"preferred resolution", models.RankingRules{PreferredResolution: "1080p", ResolutionWeight: 60},
+			newStream("Movie.2023.1080p.WEB-DL", gb, 0, false), 0, "resolution", 60},
+		{"one step from preferred", models.RankingRules{PreferredResolution: "2160p", ResolutionWeight: 90},
+			newStream("Movie.2023.1080p.WEB-DL", gb, 0, false), 0, "resolution", 60},
+		{"legacy quality label", models.RankingRules{PreferredResolution: "2160p", ResolutionWeight: 90},
+			&providers.Stream{Title: "Movie", Quality: "4k"}, 0, "resolution", 90},
+		{"unknown resolution", models.RankingRules{PreferredResolution: "1080p", ResolutionWeight: 60},
+			newStream("Movie.2023.WEB-DL", gb, 0, false), 0, "resolution", 0},
+		{"above max resolution", models.RankingRules{MaxResolution: "1080p", PreferredResolution: "1080p", ResolutionWeight: 60},
+			newStream("Movie.2023.2160p.WEB-DL", gb, 0, false), 0, "max_resolution", violationPenalty},
+		{"first codec", models.RankingRules{PreferredCodecs: []string{"HEVC", "AVC"}, CodecWeight: 30},
+			newStream("Movie.2023.1080p.WEB-DL.x265", gb, 0, false), 0, "codec", 30},
+		{"second codec", models.RankingRules{PreferredCodecs: []string{"HEVC", "AVC"}, CodecWeight: 30},
+			newStream("Movie.2023.1080p.WEB-DL.x264", gb, 0, false), 0, "codec", 15},
+		{"hdr preferred", models.RankingRules{HDR: "prefer", HDRWeight: 20},
+			newStream("Movie.2023.2160p.WEB-DL.HDR10.HEVC", gb, 0, false), 0, "hdr", 20},
+		{"hdr avoided", models.RankingRules{HDR: "avoid", HDRWeight: 40},
+			newStream("Movie.2023.2160p.WEB-DL.DV.HEVC", gb, 0, false), 0, "hdr", -40},
+		{"size in range", models.RankingRules{MinMBPerMinute: 5, MaxMBPerMinute: 25, SizeWeight: 40},
+			newStream("Movie.2023.1080p.WEB-DL", 2*gb, 0, false), 120, "size", 40},
+		{"size too big", models.RankingRules{MinMBPerMinute: 5, MaxMBPerMinute: 25, SizeWeight: 40},
+			newStream("Movie.2023.1080p.WEB-DL", 6*gb, 0, false), 120, "size", -40},
+		{"forbidden keyword", models.RankingRules{ForbiddenKeywords: []string{"remux"}},
+			newStream("Movie.2023.1080p.BluRay.REMUX", gb, 0, false), 0, "forbidden_keyword", violationPenalty},
+		{"missing required keyword", models.RankingRules{RequiredKeywords: []string{"atmos"}},
+			newStream("Movie.2023.1080p.BluRay", gb, 0, false), 0, "required_keyword", violationPenalty},
+		{"1000 seeds is the full weight", models.RankingRules{SeedsWeight: 15},
+			newStream("Movie.2023.1080p.WEB-DL", gb, 5000, false), 0, "seeds", 15},
+		{"cached", models.RankingRules{CachedWeight: 30},
+			newStream("Movie.2023.1080p.WEB-DL", gb, 0, true), 0, "cached", 30},
+		{"season pack preferred", models.RankingRules{Packs: "prefer", PackWeight: 50},
+			&providers.Stream{Title: "Show.S01.1080p.WEB-DL", Coverage: &providers.Coverage{Kind: providers.PackSeason}}, 0, "pack", 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Score(tt.rules, tt.stream, tt.runtime)
+			got, ok := points(tt.stream, tt.rule)
+			if !ok || got != tt.want {
+				t.Errorf("%s = %v (applied %v), want %v; breakdown %+v", tt.rule, got, ok, tt.want, tt.stream.ScoreBreakdown)
+			}
+		})
+	}
+}
+
+func TestPresetOrdering(t *testing.T) {
+	streams := func() []*providers.Stream {
+		return []*providers.Stream{
+			newStream("Movie.2023.720p.WEBRip.x264-YTS", 1*gb, 2000, false),
+			newStream("Movie.2023.1080p.BluRay.x265.10bit-GROUP", 5*gb/2, 100, false),
+			newStream("Movie.2023.1080p.WEB-DL.DDP5.1.H.264-FLUX", 6*gb, 500, true),
+			newStream("Movie.2023.2160p.UHD.BluRay.REMUX.HDR10.HEVC.TrueHD-FGT", 60*gb, 50, false),
+		}
+	}
+	tests := []struct {
+		preset string
+		want   []string // Release groups, best first
+	}{
+		{"quality", []string{"FGT", "FLUX", "GROUP", "YTS"}},
+		{"bandwidth_saver", []string{"GROUP", "YTS", "FLUX", "FGT"}},
+		{"compatibility", []string{"FLUX", "YTS", "GROUP", "FGT"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			rules, ok := PresetRules(tt.preset)
+			if !ok {
+				t.Fatalf("unknown preset %q", tt.preset)
+			}
+			list := streams()
+			Sort(rules, list, DefaultMovieRuntime)
+
+			var got []string
+			for _, s := range list {
+				got = append(got, s.Release.Group)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortBreaksTiesBySeedsThenSize(t *testing.T) {
+	list := []*providers.Stream{
+		{Title: "small", Seeds: 10, Size: 1},
+		{Title: "big", Seeds: 10, Size: 2},
+		{Title: "seeded", Seeds: 20, Size: 1},
+	}
+	Sort(models.RankingRules{}, list, 0)
+	if list[0].Title != "seeded" || list[1].Title != "big" || list[2].Title != "small" {
+		t.Errorf("order = %s, %s, %s", list[0].Title, list[1].Title, list[2].Title)
+	}
+}
+
+func TestPresetRulesReturnsCopy(t *testing.T) {
+	rules, _ := PresetRules("bandwidth_saver")
+	rules.PreferredCodecs[0] = "MPEG2"
+	rules.ForbiddenKeywords[0] = "changed"
+
+	if Presets["bandwidth_saver"].PreferredCodecs[0] != "HEVC" || Presets["bandwidth_saver"].ForbiddenKeywords[0] != "remux" {
+		t.Error("editing the returned rules changed the preset")
+	}
+
+	fallback, ok := PresetRules("nope")
+	if ok || fallback.PreferredResolution != Presets[DefaultPreset].PreferredResolution {
+		t.Errorf("unknown preset: ok %v, rules %+v", ok, fallback)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	for name := range Presets {
+		if err := ValidateRules(Presets[name]); err != nil {
+			t.Errorf("preset %s: %v", name, err)
+		}
+	}
+
+	base, _ := PresetRules(DefaultPreset)
+	tests := []struct {
+		name string
+		edit func(r *models.RankingRules)
+	}{
+		{"negative weight", func(r *models.RankingRules) { r.SeedsWeight = -5 }},
+		{"weight above the maximum", func(r *models.RankingRules) { r.ResolutionWeight = 5000 }},
+		{"unknown resolution", func(r *models.RankingRules) { r.PreferredResolution = "4k" }},
+		{"unknown max resolution", func(r *models.RankingRules) { r.MaxResolution = "8K" }},
+		{"unknown hdr preference", func(r *models.RankingRules) { r.HDR = "always" }},
+		{"unknown pack preference", func(r *models.RankingRules) { r.Packs = "yes" }},
+		{"negative size limit", func(r *models.RankingRules) { r.MinMBPerMinute = -1 }},
+		{"inverted size window", func(r *models.RankingRules) { r.MinMBPerMinute, r.MaxMBPerMinute = 30, 10 }},
+	}
+	for _, tt := range tests {
+		rules := base
+		tt.edit(&rules)
+		if err := ValidateRules(rules); err == nil {
+			t.Errorf("%s: accepted", tt.name)
+		}
+	}
+}
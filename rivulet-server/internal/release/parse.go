@@ -0,0 +1,392 @@
+package release
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Info holds everything we can read out of a scene/P2P release name
+type Info struct {
+	Title         string   `json:"title,omitempty"`
+	Year          int      `json:"year,omitempty"`
+	Resolution    string   `json:"resolution,omitempty"`     // "2160p", "1080p", "720p", "576p", "480p"
+	Source        string   `json:"source,omitempty"`         // "BluRay", "WEB-DL", "WEBRip", "HDTV", "DVDRip", "CAM", "TS", "TC", "SCR"
+	Remux         bool     `json:"remux,omitempty"`          // Untouched disc video
+	VideoCodec    string   `json:"video_codec,omitempty"`    // "HEVC", "AVC", "AV1", "VP9", "XviD", "MPEG-2"
+	BitDepth      int      `json:"bit_depth,omitempty"`      // 10 for "10bit"
+	HDR           []string `json:"hdr,omitempty"`            // "DV", "HDR10+", "HDR10", "HLG"
+	AudioCodec    string   `json:"audio_codec,omitempty"`    // "TrueHD", "DTS-HD MA", "DTS:X", "DTS", "DD+", "DD", "AAC", "FLAC", "Opus", "MP3"
+	Atmos         bool     `json:"atmos,omitempty"`          // Dolby Atmos object audio
+	AudioChannels string   `json:"audio_channels,omitempty"` // "7.1", "5.1", "2.0"
+	Languages     []string `json:"languages,omitempty"`      // ISO 639-1 codes, plus "multi"
+	Group         string   `json:"group,omitempty"`          // Release group, e.g. "NTb"
+	Edition       string   `json:"edition,omitempty"`        // "Extended", "Director's Cut", "IMAX", ...
+	Repack        bool     `json:"repack,omitempty"`
+	Proper        bool     `json:"proper,omitempty"`
+	Seasons       []int    `json:"seasons,omitempty"`  // Every season covered, e.g. [1 2 3] for "S01-S03"
+	Episodes      []int    `json:"episodes,omitempty"` // Every episode covered, e.g. [1 2] for "S01E01-E02"
+	Complete      bool     `json:"complete,omitempty"` // "Complete Series" / "Complete Season" releases
+}
+
+// --- Regexes ---
+// Release names use ".", "_" and " " interchangeably; "_" is normalized to " " before matching.
+
+var (
+	resolutionRegex = regexp.MustCompile(`(?i)\b(?:(2160|1080|720|576|480)[pi]|(4k|uhd)|(?:3840|1920|1280)x(2160|1080|720))\b`)
+	yearRegex       = regexp.MustCompile(`\b((?:19|20)\d{2})\b`)
+
+	remuxRegex = regexp.MustCompile(`(?i)\b(?:bd)?remux\b`)
+	// bare matches words that are also common in titles ("Charlotte's Web", "Cam"),
+	// so it's only tried on the tags after the title
+	sourceDefs = []struct {
+		name  string
+		regex *regexp.Regexp
+		bare  *regexp.Regexp
+	}{
+		// Order matters: more specific sources first
+		{"CAM", regexp.MustCompile(`(?i)\b(?:hdcam|(?:hd)?cam[ ._-]?rip)\b`), regexp.MustCompile(`(?i)\bcam\b`)},
+		{"TS", regexp.MustCompile(`(?i)\b(?:hdts|telesync|pdvd)\b`), regexp.MustCompile(`(?i)\bts\b`)},
+		{"TC", regexp.MustCompile(`(?i)\btelecine\b`), regexp.MustCompile(`(?i)\btc\b`)},
+		{"SCR", regexp.MustCompile(`(?i)\b(?:(?:dvd|bd|web)?scr|screener)\b`), nil},
+		{"WEBRip", regexp.MustCompile(`(?i)\bweb[ ._-]?rip\b`), nil},
+		{"WEB-DL", regexp.MustCompile(`(?i)\bweb[ ._-]?dl\b`), regexp.MustCompile(`(?i)\bweb\b`)},
+		{"BluRay", regexp.MustCompile(`(?i)\b(?:blu[ ._-]?ray|bd[ ._-]?rip|br[ ._-]?rip|bdremux|bd25|bd50|uhd[ ._-]?bluray)\b`), nil},
+		{"HDTV", regexp.MustCompile(`(?i)\b(?:hdtv|pdtv|sdtv|dsr|tvrip)\b`), nil},
+		{"DVDRip", regexp.MustCompile(`(?i)\b(?:dvd[ ._-]?rip|dvd(?:5|9)?)\b`), nil},
+	}
+
+	videoCodecDefs = []struct {
+		name  string
+		regex *regexp.Regexp
+	}{
+		{"HEVC", regexp.MustCompile(`(?i)\b(?:[xh][ .]?265|hevc)\b`)},
+		{"AVC", regexp.MustCompile(`(?i)\b(?:[xh][ .]?264|avc)\b`)},
+		{"AV1", regexp.MustCompile(`(?i)\bav1\b`)},
+		{"VP9", regexp.MustCompile(`(?i)\bvp9\b`)},
+		{"XviD", regexp.MustCompile(`(?i)\b(?:xvid|divx)\b`)},
+		{"MPEG-2", regexp.MustCompile(`(?i)\bmpeg[ ._-]?2\b`)},
+	}
+	bitDepthRegex = regexp.MustCompile(`(?i)\b(10|12)[ ._-]?bits?\b`)
+
+	dvRegex     = regexp.MustCompile(`(?i)\b(?:dv|dovi|dolby[ ._-]?vision)\b`)
+	hdr10pRegex = regexp.MustCompile(`(?i)\bhdr10(?:\+|[ ._-]?plus)`)
+	hdr10Regex  = regexp.MustCompile(`(?i)\bhdr(?:10)?\b`)
+	hlgRegex    = regexp.MustCompile(`(?i)\bhlg\b`)
+
+	audioCodecDefs = []struct {
+		name  string
+		regex *regexp.Regexp
+	}{
+		// Order matters: lossless/object formats before their lossy cores
+		{"TrueHD", regexp.MustCompile(`(?i)\btrue[ ._-]?hd\b`)},
+		{"DTS:X", regexp.MustCompile(`(?i)\bdts[ ._:-]?x\b`)},
+		{"DTS-HD MA", regexp.MustCompile(`(?i)\bdts[ ._-]?hd[ ._-]?ma\b`)},
+		{"DTS-HD", regexp.MustCompile(`(?i)\bdts[ ._-]?hd\b`)},
+		{"DTS", regexp.MustCompile(`(?i)\bdts\b`)},
+		{"DD+", regexp.MustCompile(`(?i)(?:\b(?:ddp|eac3|e-ac-3)|\bdd\+)`)},
+		{"DD", regexp.MustCompile(`(?i)\b(?:dd|ac3|ac-3|dolby[ ._-]?digital)(?:[ ._]?\d[ .]\d)?\b`)},
+		{"AAC", regexp.MustCompile(`(?i)\baac`)},
+		{"FLAC", regexp.MustCompile(`(?i)\bflac`)},
+		{"Opus", regexp.MustCompile(`(?i)\bopus\b`)},
+		{"MP3", regexp.MustCompile(`(?i)\bmp3\b`)},
+	}
+	atmosRegex = regexp.MustCompile(`(?i)\batmos\b`)
+	// Channels usually trail the codec: "DDP5.1", "DTS-HD.MA.7.1", "AAC2.0", "TrueHD 7.1"
+	channelsRegex = regexp.MustCompile(`(?i)(?:ddp|dd\+?|eac3|ac3|aac|dts(?:[ ._-]?hd)?(?:[ ._-]?ma)?|dts[ ._:-]?x|truehd|atmos|flac|opus|lpcm|pcm)[ ._-]?([1-9])[ ._]([0-2])\b`)
+	// Bare "5.1"/"7.1" are safe enough on their own; "2.0" isn't
+	bareChannelsRegex = regexp.MustCompile(`\b([57])[ .]1(?:ch)?\b`)
+
+	languageDefs = []struct {
+		code  string
+		regex *regexp.Regexp
+	}{
+		{"multi", regexp.MustCompile(`(?i)\b(?:multi|dual[ ._-]?audio)\b`)},
+		{"en", regexp.MustCompile(`(?i)\b(?:eng|english)\b|🇬🇧|🇺🇸`)},
+		{"fr", regexp.MustCompile(`(?i)\b(?:french|truefrench|vff|vfq|vf2|vostfr)\b|🇫🇷`)},
+		{"de", regexp.MustCompile(`(?i)\b(?:german|ger|deutsch)\b|🇩🇪`)},
+		{"es", regexp.MustCompile(`(?i)\b(?:spanish|esp|castellano|latino)\b|🇪🇸|🇲🇽`)},
+		{"it", regexp.MustCompile(`(?i)\b(?:ita|italian)\b|🇮🇹`)},
+		{"pt", regexp.MustCompile(`(?i)\b(?:portuguese|pt[ ._-]?br|dublado)\b|🇵🇹|🇧🇷`)},
+		{"ru", regexp.MustCompile(`(?i)\b(?:rus|russian)\b|🇷🇺`)},
+		{"ja", regexp.MustCompile(`(?i)\b(?:jap|jpn|japanese)\b|🇯🇵`)},
+		{"ko", regexp.MustCompile(`(?i)\b(?:kor|korean)\b|🇰🇷`)},
+		{"zh", regexp.MustCompile(`(?i)\b(?:chinese|mandarin|cantonese)\b|🇨🇳|🇹🇼|🇭🇰`)},
+		{"hi", regexp.MustCompile(`(?i)\bhindi\b|🇮🇳`)},
+		{"pl", regexp.MustCompile(`(?i)\b(?:pol|polish)\b|🇵🇱`)},
+		{"nl", regexp.MustCompile(`(?i)\bdutch\b|🇳🇱`)},
+		{"tr", regexp.MustCompile(`(?i)\bturkish\b|🇹🇷`)},
+		{"ar", regexp.MustCompile(`(?i)\barabic\b|🇸🇦`)},
+	}
+
+	editionDefs = []struct {
+		name  string
+		regex *regexp.Regexp
+	}{
+		{"Director's Cut", regexp.MustCompile(`(?i)\bdirector'?s?[ ._-]?cut\b`)},
+		{"Extended", regexp.MustCompile(`(?i)\bextended(?:[ ._-]?(?:cut|edition))?\b`)},
+		{"Theatrical", regexp.MustCompile(`(?i)\btheatrical(?:[ ._-]?(?:cut|edition))?\b`)},
+		{"Final Cut", regexp.MustCompile(`(?i)\bfinal[ ._-]?cut\b`)},
+		{"Unrated", regexp.MustCompile(`(?i)\bunrated\b`)},
+		{"Uncut", regexp.MustCompile(`(?i)\buncut\b`)},
+		{"IMAX", regexp.MustCompile(`(?i)\bimax\b`)},
+		{"Criterion", regexp.MustCompile(`(?i)\bcriterion\b`)},
+		{"Special Edition", regexp.MustCompile(`(?i)\bspecial[ ._-]?edition\b`)},
+		{"Remastered", regexp.MustCompile(`(?i)\bremastered\b`)},
+		{"Anniversary Edition", regexp.MustCompile(`(?i)\b\d+(?:th)?[ ._-]?anniversary(?:[ ._-]?edition)?\b`)},
+	}
+
+	repackRegex = regexp.MustCompile(`(?i)\b(?:repack\d?|rerip)\b`)
+	properRegex = regexp.MustCompile(`(?i)\bproper\b`)
+
+	// "S01E01", "S01E01E02", "S01E01-E03", "S01E01-03"
+	seasonEpisodeRegex = regexp.MustCompile(`(?i)\bS(\d{1,2})[ ._-]?E(\d{1,4})((?:[ ._]?-[ ._]?E?\d{1,4}|[ ._-]?E\d{1,4})*)\b`)
+	extraEpisodeRegex  = regexp.MustCompile(`(?i)(-)?[ ._-]?E?(\d{1,4})`)
+	// "1x01", "1x01-1x02"
+	crossEpisodeRegex = regexp.MustCompile(`(?i)\b(\d{1,2})x(\d{2,3})(?:[ ._-]*-[ ._-]*(?:\d{1,2}x)?(\d{2,3}))?\b`)
+	// "S01-S03", "S01-03", "S01 S02"
+	seasonRangeRegex = regexp.MustCompile(`(?i)\bS(\d{1,2})(?:[ ._]*-[ ._]*S?(\d{1,2}))?\b`)
+	// "Season 1", "Seasons 1-3", "Season 1 - 3"
+	seasonWordRegex = regexp.MustCompile(`(?i)\bseasons?[ ._]?(\d{1,2})(?:[ ._]*(?:-|to|&|and)[ ._]*(\d{1,2}))?\b`)
+	// Anime style absolute numbering: "[Group] Show - 1071 (1080p)"
+	absoluteEpisodeRegex = regexp.MustCompile(`^\[[^\]]+\][ ._]*.+?[ ._]-[ ._](\d{1,4})(?:v\d)?\b`)
+	completeRegex        = regexp.MustCompile(`(?i)\b(?:complete|integrale|all[ ._]seasons)\b`)
+
+	// "-GROUP" at the end, optionally followed by a tracker tag and extension
+	groupRegex = regexp.MustCompile(`-([A-Za-z0-9][A-Za-z0-9_]*?)(?:[ ._]?\[[^\]]*\])?(?:\.(?:mkv|mp4|avi|m2ts|ts|webm|mov))?$`)
+	// "-[YTS.MX]" style bracketed groups
+	bracketGroupRegex = regexp.MustCompile(`-\[([^\]]+)\]$`)
+	// Anime style "[SubsPlease] Show - 01"
+	leadingGroupRegex = regexp.MustCompile(`^\[([^\]]+)\]`)
+	extensionRegex    = regexp.MustCompile(`(?i)\.(?:mkv|mp4|avi|m2ts|ts|webm|mov)$`)
+)
+
+// Tokens that look like a "-GROUP" suffix but are part of other markers
+var notGroups = map[string]bool{
+	"dl": true, "hd": true, "rip": true, "ma": true, "x": true, "ac3": true,
+	"h264": true, "h265": true, "x264": true, "x265": true, "hevc": true,
+}
+
+// Parse extracts structured fields from a release name such as
+// "The.Matrix.1999.2160p.UHD.BluRay.REMUX.DV.HDR10.HEVC.TrueHD.Atmos.7.1-FGT".
+// Multi-line addon titles are accepted; only the first line is used for the name itself.
+func Parse(raw string) Info {
+	name := strings.TrimSpace(strings.Split(raw, "\n")[0])
+	name = extensionRegex.ReplaceAllString(name, "")
+	// "_" is a word character for \b, so treat it like the other separators
+	name = strings.ReplaceAll(name, "_", " ")
+
+	var info Info
+
+	// Title & year first: what follows them is where ambiguous source words count
+	var tags string
+	info.Title, info.Year, tags = parseTitle(name)
+
+	// Resolution
+	if m := resolutionRegex.FindStringSubmatch(name); m != nil {
+		switch {
+		case m[1] != "":
+			info.Resolution = m[1] + "p"
+		case m[2] != "":
+			info.Resolution = "2160p"
+		case m[3] != "":
+			info.Resolution = m[3] + "p"
+		}
+	}
+
+	// Source
+	info.Remux = remuxRegex.MatchString(name)
+	for _, def := range sourceDefs {
+		if def.regex.MatchString(name) || (def.bare != nil && def.bare.MatchString(tags)) {
+			info.Source = def.name
+			break
+		}
+	}
+	if info.Source == "" && info.Remux {
+		info.Source = "BluRay"
+	}
+
+	// Video
+	for _, def := range videoCodecDefs {
+		if def.regex.MatchString(name) {
+			info.VideoCodec = def.name
+			break
+		}
+	}
+	if m := bitDepthRegex.FindStringSubmatch(name); m != nil {
+		info.BitDepth, _ = strconv.Atoi(m[1])
+	}
+
+	// HDR
+	if dvRegex.MatchString(name) {
+		info.HDR = append(info.HDR, "DV")
+	}
+	if hdr10pRegex.MatchString(name) {
+		info.HDR = append(info.HDR, "HDR10+")
+	} else if hdr10Regex.MatchString(name) {
+		info.HDR = append(info.HDR, "HDR10")
+	}
+	if hlgRegex.MatchString(name) {
+		info.HDR = append(info.HDR, "HLG")
+	}
+
+	// Audio
+	for _, def := range audioCodecDefs {
+		if def.regex.MatchString(name) {
+			info.AudioCodec = def.name
+			break
+		}
+	}
+	info.Atmos = atmosRegex.MatchString(name)
+	if m := channelsRegex.FindStringSubmatch(name); m != nil {
+		info.AudioChannels = m[1] + "." + m[2]
+	} else if m := bareChannelsRegex.FindStringSubmatch(name); m != nil {
+		info.AudioChannels = m[1] + ".1"
+	}
+
+	// Languages are matched against the whole raw title so addon flag lines count too
+	for _, def := range languageDefs {
+		if def.regex.MatchString(raw) {
+			info.Languages = append(info.Languages, def.code)
+		}
+	}
+
+	// Edition & revisions
+	for _, def := range editionDefs {
+		if def.regex.MatchString(name) {
+			info.Edition = def.name
+			break
+		}
+	}
+	info.Repack = repackRegex.MatchString(name)
+	info.Proper = properRegex.MatchString(name)
+
+	// Seasons & episodes
+	parseEpisodes(name, &info)
+
+	// Release group
+	if m := groupRegex.FindStringSubmatch(name); m != nil && !notGroups[strings.ToLower(m[1])] {
+		info.Group = m[1]
+	} else if m := bracketGroupRegex.FindStringSubmatch(name); m != nil {
+		info.Group = m[1]
+	} else if m := leadingGroupRegex.FindStringSubmatch(name); m != nil {
+		info.Group = strings.TrimSpace(m[1])
+	}
+
+	return info
+}
+
+func parseEpisodes(name string, info *Info) {
+	if m := seasonEpisodeRegex.FindStringSubmatch(name); m != nil {
+		season, _ := strconv.Atoi(m[1])
+		first, _ := strconv.Atoi(m[2])
+		info.Seasons = []int{season}
+		info.Episodes = []int{first}
+
+		last := first
+		for _, extra := range extraEpisodeRegex.FindAllStringSubmatch(m[3], -1) {
+			n, _ := strconv.Atoi(extra[2])
+			if n <= last {
+				continue
+			}
+			if extra[1] == "-" {
+				// Range: fill in the gap
+				for e := last + 1; e <= n; e++ {
+					info.Episodes = append(info.Episodes, e)
+				}
+			} else {
+				info.Episodes = append(info.Episodes, n)
+			}
+			last = n
+		}
+		return
+	}
+
+	if m := crossEpisodeRegex.FindStringSubmatch(name); m != nil {
+		season, _ := strconv.Atoi(m[1])
+		first, _ := strconv.Atoi(m[2])
+		info.Seasons = []int{season}
+		info.Episodes = []int{first}
+		if m[3] != "" {
+			last, _ := strconv.Atoi(m[3])
+			for e := first + 1; e <= last; e++ {
+				info.Episodes = append(info.Episodes, e)
+			}
+		}
+		return
+	}
+
+	if m := absoluteEpisodeRegex.FindStringSubmatch(name); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		info.Episodes = []int{n}
+		return
+	}
+
+	info.Complete = completeRegex.MatchString(name)
+
+	// Season packs
+	seen := map[int]bool{}
+	addRange := func(from, to string) {
+		a, _ := strconv.Atoi(from)
+		b := a
+		if to != "" {
+			b, _ = strconv.Atoi(to)
+		}
+		if b < a || b-a > 50 {
+			b = a
+		}
+		for s := a; s <= b; s++ {
+			if !seen[s] {
+				seen[s] = true
+				info.Seasons = append(info.Seasons, s)
+			}
+		}
+	}
+	for _, m := range seasonRangeRegex.FindAllStringSubmatch(name, -1) {
+		addRange(m[1], m[2])
+	}
+	for _, m := range seasonWordRegex.FindAllStringSubmatch(name, -1) {
+		addRange(m[1], m[2])
+	}
+}
+
+// Everything before the first technical marker is the title
+var titleStopRegex = regexp.MustCompile(`(?i)[ ._\[(-]+(?:S\d{1,2}(?:E\d+|[ ._-]|$)|\d{1,2}x\d{2}|seasons?[ ._]?\d|complete|(?:2160|1080|720|576|480)[pi]|4k|uhd|web[ ._-]?(?:dl|rip)|blu|bd|hdtv|dvd|remux|extended|unrated|director|imax|proper|repack|multi|-[ ._]\d)`)
+
+// parseTitle returns the title, the release year and the rest of the name after them
+func parseTitle(name string) (string, int, string) {
+	title := name
+	if m := leadingGroupRegex.FindStringIndex(title); m != nil {
+		title = strings.TrimSpace(title[m[1]:])
+	}
+
+	end := len(title)
+	if loc := titleStopRegex.FindStringIndex(title); loc != nil && loc[0] > 0 {
+		end = loc[0]
+	}
+
+	// The release year is the last one before the technical markers,
+	// so "Blade.Runner.2049.2017.1080p" keeps "2049" in the title
+	year := 0
+	yearStart := -1
+	for _, m := range yearRegex.FindAllStringSubmatchIndex(title, -1) {
+		if m[0] >= end {
+			break
+		}
+		if m[0] == 0 {
+			continue // "1917.2019.1080p": a leading number is the title
+		}
+		year, _ = strconv.Atoi(title[m[2]:m[3]])
+		yearStart = m[0]
+	}
+	if yearStart > 0 {
+		end = yearStart
+	}
+
+	rest := title[end:]
+	title = title[:end]
+	title = strings.ReplaceAll(title, ".", " ")
+	title = strings.Join(strings.Fields(title), " ")
+	title = strings.Trim(title, " -([")
+	return title, year, rest
+}
@@ -0,0 +1,441 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Corpus of real-world release names. Each entry defines the full expected parse,
+// so adding a pattern to the parser means adding the names that motivated it here.
+var corpus = []struct {
+	name string
+	want Info
+}{
+	{
+		name: "The.Matrix.1999.2160p.UHD.BluRay.REMUX.DV.HDR10.HEVC.TrueHD.Atmos.7.1-FGT",
+		want: Info{
+			Title:         "The Matrix",
+			Year:          1999,
+			Resolution:    "2160p",
+			Source:        "BluRay",
+			Remux:         true,
+			VideoCodec:    "HEVC",
+			HDR:           []string{"DV", "HDR10"},
+			AudioCodec:    "TrueHD",
+			Atmos:         true,
+			AudioChannels: "7.1",
+			Group:         "FGT",
+		},
+	},
+	{
+		name: "Oppenheimer.2023.1080p.WEB-DL.DDP5.1.H.264-FLUX",
+		want: Info{
+			Title:         "Oppenheimer",
+			Year:          2023,
+			Resolution:    "1080p",
+			Source:        "WEB-DL",
+			VideoCodec:    "AVC",
+			AudioCodec:    "DD+",
+			AudioChannels: "5.1",
+			Group:         "FLUX",
+		},
+	},
+	{
+		name: "Blade.Runner.2049.2017.2160p.BluRay.x265.10bit.HDR10+.DTS-HD.MA.7.1-SWTYBLZ",
+		want: Info{
+			Title:         "Blade Runner 2049",
+			Year:          2017,
+			Resolution:    "2160p",
+			Source:        "BluRay",
+			VideoCodec:    "HEVC",
+			BitDepth:      10,
+			HDR:           []string{"HDR10+"},
+			AudioCodec:    "DTS-HD MA",
+			AudioChannels: "7.1",
+			Group:         "SWTYBLZ",
+		},
+	},
+	{
+		name: "1917.2019.720p.BluRay.x264.AAC-[YTS.MX]",
+		want: Info{
+			Title:      "1917",
+			Year:       2019,
+			Resolution: "720p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			AudioCodec: "AAC",
+			Group:      "YTS.MX",
+		},
+	},
+	{
+		name: "The.Lord.of.the.Rings.The.Fellowship.of.the.Ring.2001.EXTENDED.1080p.BluRay.x264.DTS-ES-SPARKS",
+		want: Info{
+			Title:      "The Lord of the Rings The Fellowship of the Ring",
+			Year:       2001,
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			AudioCodec: "DTS",
+			Group:      "SPARKS",
+			Edition:    "Extended",
+		},
+	},
+	{
+		name: "Breaking.Bad.S05E14.Ozymandias.1080p.BluRay.x264-ROVERS",
+		want: Info{
+			Title:      "Breaking Bad",
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			Group:      "ROVERS",
+			Seasons:    []int{5},
+			Episodes:   []int{14},
+		},
+	},
+	{
+		name: "The.Office.US.S03E01-E02.720p.WEB-DL.DD5.1.H.264-NTb",
+		want: Info{
+			Title:         "The Office US",
+			Resolution:    "720p",
+			Source:        "WEB-DL",
+			VideoCodec:    "AVC",
+			AudioCodec:    "DD",
+			AudioChannels: "5.1",
+			Group:         "NTb",
+			Seasons:       []int{3},
+			Episodes:      []int{1, 2},
+		},
+	},
+	{
+		name: "Game.of.Thrones.S08.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-PTer",
+		want: Info{
+			Title:      "Game of Thrones",
+			Resolution: "2160p",
+			Source:     "BluRay",
+			Remux:      true,
+			VideoCodec: "HEVC",
+			HDR:        []string{"HDR10"},
+			Atmos:      true,
+			Group:      "PTer",
+			Seasons:    []int{8},
+		},
+	},
+	{
+		name: "Friends.Complete.Series.S01-S10.1080p.BluRay.x265-HiQVE",
+		want: Info{
+			Title:      "Friends",
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "HEVC",
+			Group:      "HiQVE",
+			Seasons:    []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			Complete:   true,
+		},
+	},
+	{
+		name: "The Boys S04E03 We'll Keep the Red Flag Flying Here 1080p AMZN WEB-DL DDP5 1 H 264-NTb",
+		want: Info{
+			Title:         "The Boys",
+			Resolution:    "1080p",
+			Source:        "WEB-DL",
+			VideoCodec:    "AVC",
+			AudioCodec:    "DD+",
+			AudioChannels: "5.1",
+			Group:         "NTb",
+			Seasons:       []int{4},
+			Episodes:      []int{3},
+		},
+	},
+	{
+		name: "[SubsPlease] Frieren - 01 (1080p) [F02B9CEE].mkv",
+		want: Info{
+			Title:      "Frieren",
+			Resolution: "1080p",
+			Group:      "SubsPlease",
+			Episodes:   []int{1},
+		},
+	},
+	{
+		name: "Dune.Part.Two.2024.1080p.HDCAM.x264-AOC",
+		want: Info{
+			Title:      "Dune Part Two",
+			Year:       2024,
+			Resolution: "1080p",
+			Source:     "CAM",
+			VideoCodec: "AVC",
+			Group:      "AOC",
+		},
+	},
+	{
+		name: "Twisters.2024.CAM.x264-GRP",
+		want: Info{
+			Title:      "Twisters",
+			Year:       2024,
+			Source:     "CAM",
+			VideoCodec: "AVC",
+			Group:      "GRP",
+		},
+	},
+	{
+		// "Cam" is the title, not the source
+		name: "Cam.2018.1080p.NF.WEB-DL.DDP5.1.x264-NTG",
+		want: Info{
+			Title:         "Cam",
+			Year:          2018,
+			Resolution:    "1080p",
+			Source:        "WEB-DL",
+			VideoCodec:    "AVC",
+			AudioCodec:    "DD+",
+			AudioChannels: "5.1",
+			Group:         "NTG",
+		},
+	},
+	{
+		// Likewise "Web"
+		name: "Charlotte's.Web.2006.1080p.BluRay.x264-FOXM",
+		want: Info{
+			Title:      "Charlotte's Web",
+			Year:       2006,
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			Group:      "FOXM",
+		},
+	},
+	{
+		name: "The.Creator.2023.1080p.WEB.H264-SLOT",
+		want: Info{
+			Title:      "The Creator",
+			Year:       2023,
+			Resolution: "1080p",
+			Source:     "WEB-DL",
+			VideoCodec: "AVC",
+			Group:      "SLOT",
+		},
+	},
+	{
+		name: "Top.Gun.Maverick.2022.IMAX.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR.H.265-FLUX",
+		want: Info{
+			Title:         "Top Gun Maverick",
+			Year:          2022,
+			Resolution:    "2160p",
+			Source:        "WEB-DL",
+			VideoCodec:    "HEVC",
+			HDR:           []string{"DV", "HDR10"},
+			AudioCodec:    "DD+",
+			Atmos:         true,
+			AudioChannels: "5.1",
+			Group:         "FLUX",
+			Edition:       "IMAX",
+		},
+	},
+	{
+		name: "Severance.S02E01.REPACK.1080p.ATVP.WEB-DL.DDP5.1.Atmos.H.264-FLUX",
+		want: Info{
+			Title:         "Severance",
+			Resolution:    "1080p",
+			Source:        "WEB-DL",
+			VideoCodec:    "AVC",
+			AudioCodec:    "DD+",
+			Atmos:         true,
+			AudioChannels: "5.1",
+			Group:         "FLUX",
+			Repack:        true,
+			Seasons:       []int{2},
+			Episodes:      []int{1},
+		},
+	},
+	{
+		name: "Amelie.2001.FRENCH.1080p.BluRay.x264.DTS-FGT",
+		want: Info{
+			Title:      "Amelie",
+			Year:       2001,
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			AudioCodec: "DTS",
+			Languages:  []string{"fr"},
+			Group:      "FGT",
+		},
+	},
+	{
+		name: "Parasite.2019.KOREAN.2160p.BluRay.REMUX.HEVC.DTS-HD.MA.5.1-FGT",
+		want: Info{
+			Title:         "Parasite",
+			Year:          2019,
+			Resolution:    "2160p",
+			Source:        "BluRay",
+			Remux:         true,
+			VideoCodec:    "HEVC",
+			AudioCodec:    "DTS-HD MA",
+			AudioChannels: "5.1",
+			Languages:     []string{"ko"},
+			Group:         "FGT",
+		},
+	},
+	{
+		name: "Doctor.Who.2005.S00E10.The.Day.of.the.Doctor.1080p.BluRay.x264-SHORTBREHD",
+		want: Info{
+			Title:      "Doctor Who",
+			Year:       2005,
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			Group:      "SHORTBREHD",
+			Seasons:    []int{0},
+			Episodes:   []int{10},
+		},
+	},
+	{
+		name: "Stranger Things Season 1-4 Complete 1080p NF WEB-DL x264 MULTI",
+		want: Info{
+			Title:      "Stranger Things",
+			Resolution: "1080p",
+			Source:     "WEB-DL",
+			VideoCodec: "AVC",
+			Languages:  []string{"multi"},
+			Seasons:    []int{1, 2, 3, 4},
+			Complete:   true,
+		},
+	},
+	{
+		name: "The.Simpsons.1x01.Simpsons.Roasting.on.an.Open.Fire.DVDRip.XviD",
+		want: Info{
+			Title:      "The Simpsons",
+			Source:     "DVDRip",
+			VideoCodec: "XviD",
+			Seasons:    []int{1},
+			Episodes:   []int{1},
+		},
+	},
+	{
+		name: "Avatar.The.Way.of.Water.2022.PROPER.1080p.WEBRip.x264.AAC5.1-YTS",
+		want: Info{
+			Title:         "Avatar The Way of Water",
+			Year:          2022,
+			Resolution:    "1080p",
+			Source:        "WEBRip",
+			VideoCodec:    "AVC",
+			AudioCodec:    "AAC",
+			AudioChannels: "5.1",
+			Group:         "YTS",
+			Proper:        true,
+		},
+	},
+	{
+		name: "Spider-Man.No.Way.Home.2021.1080p.WEBRip.x265.10bit",
+		want: Info{
+			Title:      "Spider-Man No Way Home",
+			Year:       2021,
+			Resolution: "1080p",
+			Source:     "WEBRip",
+			VideoCodec: "HEVC",
+			BitDepth:   10,
+		},
+	},
+	{
+		name: "Aliens.1986.Special.Edition.Remastered.1080p.BluRay.x264.DTS-HD.MA.5.1-FGT",
+		want: Info{
+			Title:         "Aliens",
+			Year:          1986,
+			Resolution:    "1080p",
+			Source:        "BluRay",
+			VideoCodec:    "AVC",
+			AudioCodec:    "DTS-HD MA",
+			AudioChannels: "5.1",
+			Group:         "FGT",
+			Edition:       "Special Edition",
+		},
+	},
+	{
+		name: "House.of.the.Dragon.S02E08.2160p.MAX.WEB-DL.DDP5.1.DV.HDR.H.265-FLUX",
+		want: Info{
+			Title:         "House of the Dragon",
+			Resolution:    "2160p",
+			Source:        "WEB-DL",
+			VideoCodec:    "HEVC",
+			HDR:           []string{"DV", "HDR10"},
+			AudioCodec:    "DD+",
+			AudioChannels: "5.1",
+			Group:         "FLUX",
+			Seasons:       []int{2},
+			Episodes:      []int{8},
+		},
+	},
+	{
+		name: "Apocalypse.Now.1979.Final.Cut.1080p.BluRay.x264.TrueHD.5.1-SWTYBLZ",
+		want: Info{
+			Title:         "Apocalypse Now",
+			Year:          1979,
+			Resolution:    "1080p",
+			Source:        "BluRay",
+			VideoCodec:    "AVC",
+			AudioCodec:    "TrueHD",
+			AudioChannels: "5.1",
+			Group:         "SWTYBLZ",
+			Edition:       "Final Cut",
+		},
+	},
+	{
+		name: "Shogun.2024.S01E01.Anjin.HDTV.x264-TORRENTGALAXY",
+		want: Info{
+			Title:      "Shogun",
+			Year:       2024,
+			Source:     "HDTV",
+			VideoCodec: "AVC",
+			Group:      "TORRENTGALAXY",
+			Seasons:    []int{1},
+			Episodes:   []int{1},
+		},
+	},
+	{
+		name: "Fallout.S01E01.The.End.2160p.AMZN.WEB-DL.DDP5.1.HDR10+.H.265-FLUX",
+		want: Info{
+			Title:         "Fallout",
+			Resolution:    "2160p",
+			Source:        "WEB-DL",
+			VideoCodec:    "HEVC",
+			HDR:           []string{"HDR10+"},
+			AudioCodec:    "DD+",
+			AudioChannels: "5.1",
+			Group:         "FLUX",
+			Seasons:       []int{1},
+			Episodes:      []int{1},
+		},
+	},
+	{
+		name: "The_Shawshank_Redemption_1994_1080p_BluRay_x264_DTS",
+		want: Info{
+			Title:      "The Shawshank Redemption",
+			Year:       1994,
+			Resolution: "1080p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			AudioCodec: "DTS",
+		},
+	},
+	{
+		name: "Breaking.Bad.S01E01.720p.BluRay.x264-DEMAND\n👤 42 💾 1.1 GB ⚙️ ThePirateBay\nMulti Audio / 🇬🇧 / 🇮🇹",
+		want: Info{
+			Title:      "Breaking Bad",
+			Resolution: "720p",
+			Source:     "BluRay",
+			VideoCodec: "AVC",
+			Languages:  []string{"multi", "en", "it"},
+			Group:      "DEMAND",
+			Seasons:    []int{1},
+			Episodes:   []int{1},
+		},
+	},
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range corpus {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Parse(tc.name)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Parse(%q)\n got: %+v\nwant: %+v", tc.name, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"rivulet_server/internal/providers"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrPremiumExpired is returned when the debrid subscription has run out
+var ErrPremiumExpired = errors.New("debrid premium subscription has expired")
+
+// Warn this many days before premium runs out
+const PremiumWarningDays = 7
+
+// How long a fetched account status is reused by resolve and scrape
+const accountStatusTTL = 10 * time.Minute
+
+type accountStatus struct {
+	account   *providers.DebridAccount
+	fetchedAt time.Time
+}
+
+var (
+	accountStatusMu sync.Mutex
+	accountStatuses = make(map[string]accountStatus)
+)
+
+// DebridAccountInfo fetches the account's subscription state from the service
+// and remembers it for CheckPremium
+func DebridAccountInfo(ctx context.Context, debrid providers.DebridService, accountID uuid.UUID, token string) (*providers.DebridAccount, error) {
+	account, err := debrid.AccountInfo(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if account.Type == "" {
+		account.Type = "free"
+		if account.Premium {
+			account.Type = "premium"
+		}
+	}
+
+	accountStatusMu.Lock()
+	accountStatuses[accountID.String()+"|"+debrid.Name()] = accountStatus{account: account, fetchedAt: time.Now()}
+	accountStatusMu.Unlock()
+	return account, nil
+}
+
+// CheckPremium returns ErrPremiumExpired when the account has no premium left, or a
+// warning when it's about to run out. A service that can't be reached doesn't block anything.
+func CheckPremium(ctx context.Context, debrid providers.DebridService, accountID uuid.UUID, token string) (string, error) {
+	key := accountID.String() + "|" + debrid.Name()
+
+	accountStatusMu.Lock()
+	cached, ok := accountStatuses[key]
+	accountStatusMu.Unlock()
+
+	account := cached.account
+	if !ok || time.Since(cached.fetchedAt) > accountStatusTTL {
+		fresh, err := DebridAccountInfo(ctx, debrid, accountID, token)
+		if err != nil {
+			log.Printf("⚠️ %s account check failed: %v", debrid.Name(), err)
+			return "", nil
+		}
+		account = fresh
+	}
+
+	if !account.Premium {
+		return "", ErrPremiumExpired
+	}
+	return PremiumWarning(account), nil
+}
+
+// PremiumWarning describes an upcoming expiry, or returns "" if there's time left
+func PremiumWarning(account *providers.DebridAccount) string {
+	if !account.Premium {
+		return "premium has expired"
+	}
+	days := account.PremiumDays
+	if !account.ExpiresAt.IsZero() {
+		days = int(time.Until(account.ExpiresAt).Hours() / 24)
+	} else if days == 0 {
+		// The service didn't say when it ends
+		return ""
+	}
+	switch {
+	case days > PremiumWarningDays:
+		return ""
+	case days <= 0:
+		return "premium expires today"
+	case days == 1:
+		return "premium expires tomorrow"
+	default:
+		return fmt.Sprintf("premium expires in %d days", days)
+	}
+}
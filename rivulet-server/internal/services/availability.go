@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"log"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// RD evicts torrents nobody watches, so old sightings aren't trusted
+const CachedHashTTL = 14 * 24 * time.Hour
+
+// RD rejects very long availability URLs
+const availabilityBatchSize = 50
+
+// AnnotateAvailability flags streams that the debrid service can play instantly.
+// Sources, cheapest first: addon hints (already set by scrapers), hashes we've seen
+// downloaded before on this service, then the service's availability endpoint.
+// Failures just leave streams unflagged.
+func AnnotateAvailability(ctx context.Context, debrid providers.DebridService, token string, streams []*providers.Stream) {
+	pending := make(map[string][]*providers.Stream)
+	for _, s := range streams {
+		if s.Cached || s.Hash == "" {
+			continue
+		}
+		h := strings.ToLower(s.Hash)
+		pending[h] = append(pending[h], s)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	// 1. Server-side history
+	hashes := make([]string, 0, len(pending))
+	for h := range pending {
+		hashes = append(hashes, h)
+	}
+
+	var known []models.DebridCachedHash
+	db.DB.Where("service = ? AND hash IN ? AND last_seen_at > ?", debrid.Name(), hashes, time.Now().Add(-CachedHashTTL)).Find(&known)
+	for _, k := range known {
+		for _, s := range pending[k.Hash] {
+			s.Cached = true
+			s.CachedSource = "history"
+			s.CachedFiles = toProviderFiles(k.Files)
+		}
+		delete(pending, k.Hash)
+	}
+
+	// 2. Ask the service about the rest
+	remaining := make([]string, 0, len(pending))
+	for h := range pending {
+		remaining = append(remaining, h)
+	}
+
+	for start := 0; start < len(remaining); start += availabilityBatchSize {
+		end := min(start+availabilityBatchSize, len(remaining))
+		available, err := debrid.CheckAvailability(ctx, token, remaining[start:end])
+		if err != nil {
+			log.Printf("⚠️ %s availability check failed: %v", debrid.Name(), err)
+			return
+		}
+		for h, files := range available {
+			for _, s := range pending[h] {
+				s.Cached = true
+				s.CachedSource = "debrid"
+				s.CachedFiles = files
+			}
+		}
+	}
+}
+
+// RecordCachedHash remembers a torrent the service reported as "downloaded"
+func RecordCachedHash(service, hash string, files []providers.DebridFile) {
+	if hash == "" {
+		return
+	}
+
+	var cached []models.CachedFile
+	for _, f := range files {
+		if f.Selected {
+			cached = append(cached, models.CachedFile{ID: f.ID, Path: f.Path, Bytes: f.Bytes})
+		}
+	}
+
+	entry := models.DebridCachedHash{
+		Service:    service,
+		Hash:       strings.ToLower(hash),
+		Files:      cached,
+		LastSeenAt: time.Now(),
+	}
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "service"}, {Name: "hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"files", "last_seen_at", "updated_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to record cached hash %s: %v", hash, err)
+	}
+}
+
+func toProviderFiles(files []models.CachedFile) []providers.CachedFile {
+	out := make([]providers.CachedFile, 0, len(files))
+	for _, f := range files {
+		out = append(out, providers.CachedFile{ID: f.ID, Path: f.Path, Bytes: f.Bytes})
+	}
+	return out
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/matching"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrCleanupUnsupported is returned when the account's debrid service can't delete torrents
+var ErrCleanupUnsupported = errors.New("debrid service does not support deleting torrents")
+
+// DefaultCleanupInterval is how often every account is checked
+const DefaultCleanupInterval = time.Hour
+
+// Cleanup reasons
+const (
+	CleanupExpired = "expired" // Not played for longer than the retention period
+	CleanupWatched = "watched" // Everything played from it has been watched
+)
+
+// CleanupCandidate is a torrent the cleanup job would delete
+type CleanupCandidate struct {
+	TorrentID  string              `json:"torrent_id"`
+	Hash       string              `json:"hash"`
+	ImdbID     string              `json:"imdb_id,omitempty"`
+	Episodes   []models.EpisodeRef `json:"episodes,omitempty"`
+	LastUsedAt time.Time           `json:"last_used_at"`
+	Reason     string              `json:"reason"`
+}
+
+// CleanupPlan is what a cleanup run would do for one account
+type CleanupPlan struct {
+	Service        string             `json:"service"`
+	RetentionDays  int                `json:"retention_days"`
+	DeleteWatched  bool               `json:"delete_watched"`
+	Torrents       []CleanupCandidate `json:"torrents"`
+	RemainingCount int                `json:"remaining_count"` // Tracked torrents that are kept
+}
+
+// TorrentCleaner deletes torrents Rivulet added to debrid accounts once they
+// haven't been used for a while or have been watched. Torrents the user added
+// outside Rivulet aren't in the DebridTorrent table and are never touched.
+type TorrentCleaner struct {
+	Lookup   DebridLookup
+	Interval time.Duration
+}
+
+func NewTorrentCleaner(lookup DebridLookup) *TorrentCleaner {
+	return &TorrentCleaner{Lookup: lookup, Interval: DefaultCleanupInterval}
+}
+
+// Plan lists the account's torrents that are due for deletion, without deleting anything
+func (c *TorrentCleaner) Plan(accountID uuid.UUID) (*CleanupPlan, error) {
+	debrid, token, err := c.Lookup(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := debrid.(providers.DebridTorrentRemover); !ok {
+		return nil, ErrCleanupUnsupported
+	}
+
+	var account models.Account
+	err = db.DB.Select("id, torrent_retention_days, delete_watched_torrents").First(&account, accountID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &CleanupPlan{
+		Service:       debrid.Name(),
+		RetentionDays: account.TorrentRetentionDays,
+		DeleteWatched: account.DeleteWatchedTorrents,
+		Torrents:      []CleanupCandidate{},
+	}
+
+	var tracked []models.DebridTorrent
+	db.DB.Where("account_id = ? AND service = ?", accountID, debrid.Name()).Find(&tracked)
+
+	// Torrents still being polled for a profile stay until they finish
+	var pending []string
+	db.DB.Model(&models.PendingTorrent{}).
+		Where("account_id = ? AND service = ? AND status = ?", accountID, debrid.Name(), models.PendingDownloading).
+		Pluck("torrent_id", &pending)
+	inFlight := make(map[string]bool, len(pending))
+	for _, id := range pending {
+		inFlight[id] = true
+	}
+
+	retention := time.Duration(account.TorrentRetentionDays) * 24 * time.Hour
+	for _, t := range tracked {
+		lastUsed := t.LastUsedAt
+		if lastUsed.Before(t.CreatedAt) {
+			lastUsed = t.CreatedAt
+		}
+
+		reason := ""
+		switch {
+		case inFlight[t.TorrentID]:
+		case account.TorrentRetentionDays > 0 && time.Since(lastUsed) > retention:
+			reason = CleanupExpired
+		case account.DeleteWatchedTorrents && watched(accountID, t, func() []models.CachedFile {
+			return torrentFiles(debrid, token, t)
+		}):
+			reason = CleanupWatched
+		}
+		if reason == "" {
+			plan.RemainingCount++
+			continue
+		}
+
+		plan.Torrents = append(plan.Torrents, CleanupCandidate{
+			TorrentID:  t.TorrentID,
+			Hash:       t.Hash,
+			ImdbID:     t.ImdbID,
+			Episodes:   t.Episodes,
+			LastUsedAt: lastUsed,
+			Reason:     reason,
+		})
+	}
+	return plan, nil
+}
+
+// Clean deletes the torrents in the account's plan and returns the ones that were removed
+func (c *TorrentCleaner) Clean(ctx context.Context, accountID uuid.UUID) ([]CleanupCandidate, error) {
+	plan, err := c.Plan(accountID)
+	if err != nil {
+		return nil, err
+	}
+	debrid, token, err := c.Lookup(accountID)
+	if err != nil {
+		return nil, err
+	}
+	remover := debrid.(providers.DebridTorrentRemover)
+
+	deleted := []CleanupCandidate{}
+	for _, t := range plan.Torrents {
+		if ctx.Err() != nil {
+			break
+		}
+		err := remover.DeleteTorrent(ctx, token, t.TorrentID)
+		if err != nil && !errors.Is(err, providers.ErrDebridTorrentNotFound) {
+			log.Printf("⚠️ Failed to delete %s torrent %s: %v", debrid.Name(), t.TorrentID, err)
+			continue
+		}
+		ForgetTorrent(accountID, debrid.Name(), t.TorrentID)
+		ForgetLinks(accountID, debrid.Name(), t.Hash)
+		deleted = append(deleted, t)
+	}
+	return deleted, nil
+}
+
+// Run cleans every account with tracked torrents on each interval until ctx is cancelled
+func (c *TorrentCleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var accounts []uuid.UUID
+		db.DB.Model(&models.DebridTorrent{}).Distinct("account_id").Pluck("account_id", &accounts)
+		for _, accountID := range accounts {
+			if ctx.Err() != nil {
+				return
+			}
+			deleted, err := c.Clean(ctx, accountID)
+			if err != nil {
+				if !errors.Is(err, providers.ErrDebridNotConfigured) && !errors.Is(err, ErrCleanupUnsupported) {
+					log.Printf("⚠️ Torrent cleanup for %s failed: %v", accountID, err)
+				}
+				continue
+			}
+			if len(deleted) > 0 {
+				log.Printf("🧹 Deleted %d torrents for %s", len(deleted), accountID)
+			}
+		}
+	}
+}
+
+// watched reports whether everything the torrent holds is marked watched by
+// one of the account's profiles. files is only asked for series torrents.
+func watched(accountID uuid.UUID, t models.DebridTorrent, files func() []models.CachedFile) bool {
+	if t.ImdbID == "" {
+		// Nothing known about what it holds; only the retention period applies
+		return false
+	}
+
+	var held []models.CachedFile
+	if len(t.Episodes) > 0 {
+		held = files()
+	}
+
+	profiles := db.DB.Model(&models.Profile{}).Select("id").Where("account_id = ?", accountID)
+	return fullyWatched(t, held, func(ref models.EpisodeRef) bool {
+		var count int64
+		db.DB.Model(&models.MediaProgress{}).
+			Where("profile_id IN (?) AND imdb_id = ? AND season_number = ? AND episode_number = ? AND is_watched = ?",
+				profiles, t.ImdbID, ref.Season, ref.Episode, true).
+			Count(&count)
+		return count > 0
+	})
+}
+
+// fullyWatched reports whether isWatched holds for every episode the torrent's
+// files contain, not just the ones played from it, so a season pack stays until
+// the whole season is watched
+func fullyWatched(t models.DebridTorrent, files []models.CachedFile, isWatched func(models.EpisodeRef) bool) bool {
+	refs := t.Episodes
+	if len(refs) == 0 {
+		refs = []models.EpisodeRef{{}} // A movie is stored as season 0, episode 0
+	} else {
+		var ok bool
+		if refs, ok = heldEpisodes(refs, files); !ok {
+			return false
+		}
+	}
+
+	for _, ref := range refs {
+		if !isWatched(ref) {
+			return false
+		}
+	}
+	return true
+}
+
+// heldEpisodes lists the episodes in a torrent's video files along with the ones
+// played from it. ok is false when the files are unknown or one can't be placed
+// in a season, since the torrent might then hold something that hasn't been watched.
+func heldEpisodes(played []models.EpisodeRef, files []models.CachedFile) ([]models.EpisodeRef, bool) {
+	var videos []string
+	for _, f := range files {
+		if matching.IsVideo(f.Path) && !matching.IsJunk(f.Path) {
+			videos = append(videos, f.Path)
+		}
+	}
+	switch len(videos) {
+	case 0:
+		// Files unknown, so it might be a pack
+		return nil, false
+	case 1:
+		// A single episode: what was played is all it holds
+		return played, true
+	}
+
+	refs := slices.Clone(played)
+	for _, p := range videos {
+		seasons, episodes := matching.Episodes(p)
+		if len(seasons) != 1 || len(episodes) == 0 {
+			return nil, false
+		}
+		for _, e := range episodes {
+			ref := models.EpisodeRef{Season: seasons[0], Episode: e}
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs, true
+}
+
+// torrentFiles returns the files of a tracked torrent, from the files recorded
+// when it finished downloading or else from the debrid service
+func torrentFiles(debrid providers.DebridService, token string, t models.DebridTorrent) []models.CachedFile {
+	var known models.DebridCachedHash
+	err := db.DB.Where("service = ? AND hash = ?", debrid.Name(), t.Hash).Limit(1).Find(&known).Error
+	if err == nil && len(known.Files) > 0 {
+		return known.Files
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info, err := debrid.TorrentStatus(ctx, token, t.TorrentID)
+	if err != nil {
+		return nil
+	}
+	var files []models.CachedFile
+	for _, f := range info.Files {
+		if f.Selected {
+			files = append(files, models.CachedFile{ID: f.ID, Path: f.Path, Bytes: f.Bytes})
+		}
+	}
+	return files
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"rivulet_server/internal/models"
+	"testing"
+)
+
+func TestFullyWatched(t *testing.T) {
+	seasonPack := []models.CachedFile{
+		{ID: 1, Path: "/Show.S01.1080p.WEB-DL/Show.S01E01.1080p.WEB-DL.mkv"},
+		{ID: 2, Path: "/Show.S01.1080p.WEB-DL/Show.S01E02.1080p.WEB-DL.mkv"},
+		{ID: 3, Path: "/Show.S01.1080p.WEB-DL/Show.S01E03.1080p.WEB-DL.mkv"},
+		{ID: 4, Path: "/Show.S01.1080p.WEB-DL/Sample/Show.S01E01.sample.mkv"},
+		{ID: 5, Path: "/Show.S01.1080p.WEB-DL/Show.S01.nfo"},
+	}
+	episode := []models.CachedFile{{ID: 1, Path: "/Show.S01E01.1080p.WEB-DL.mkv"}}
+	firstPlayed := []models.EpisodeRef{{Season: 1, Episode: 1}}
+
+	watchedUpTo := func(last int) func(models.EpisodeRef) bool {
+		return func(ref models.EpisodeRef) bool { return ref.Season == 1 && ref.Episode <= last }
+	}
+
+	tests := []struct {
+		name      string
+		torrent   models.DebridTorrent
+		files     []models.CachedFile
+		isWatched func(models.EpisodeRef) bool
+		want      bool
+	}{
+		{"season pack with one episode watched", models.DebridTorrent{Episodes: firstPlayed}, seasonPack, watchedUpTo(1), false},
+		{"season pack fully watched", models.DebridTorrent{Episodes: firstPlayed}, seasonPack, watchedUpTo(3), true},
+		{"single episode watched", models.DebridTorrent{Episodes: firstPlayed}, episode, watchedUpTo(1), true},
+		{"files unknown", models.DebridTorrent{Episodes: firstPlayed}, nil, watchedUpTo(3), false},
+		{"unplaceable file", models.DebridTorrent{Episodes: firstPlayed}, append(seasonPack, models.CachedFile{ID: 6, Path: "/Show.S01.1080p.WEB-DL/Unaired Pilot.mkv"}), watchedUpTo(3), false},
+		{"movie watched", models.DebridTorrent{}, nil, func(ref models.EpisodeRef) bool { return ref == models.EpisodeRef{} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fullyWatched(tt.torrent, tt.files, tt.isWatched); got != tt.want {
+				t.Errorf("fullyWatched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
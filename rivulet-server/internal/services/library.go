@@ -1,11 +1,12 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"rivulet_server/internal/db"
 	"rivulet_server/internal/models"
-	"rivulet_server/internal/providers/mdblist"
-	"rivulet_server/internal/providers/tmdb"
+	"rivulet_server/internal/providers"
 	"strings"
 
 	"github.com/google/uuid"
@@ -13,8 +14,8 @@ import (
 )
 
 // AddToLibrary handles the entire flow of adding media
-func AddToLibrary(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey, tmdbApiKey, externalID, mediaType string, profileID uuid.UUID) error {
-	mediaID, err := EnsureMedia(mdbClient, tmdbClient, mdbApiKey, tmdbApiKey, externalID, mediaType, profileID)
+func AddToLibrary(ctx context.Context, metadata *providers.MetadataResolver, externalID, mediaType string, profileID uuid.UUID) error {
+	mediaID, err := EnsureMedia(ctx, metadata, externalID, mediaType, profileID)
 	if err != nil {
 		return err
 	}
@@ -22,7 +23,8 @@ func AddToLibrary(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 }
 
 // EnsureMedia checks if media exists for profile, if not fetches and creates it. Returns MediaID.
-func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey, tmdbApiKey, externalID, mediaType string, profileID uuid.UUID) (uuid.UUID, error) {
+// Each record remembers the metadata provider it came from.
+func EnsureMedia(ctx context.Context, metadata *providers.MetadataResolver, externalID, mediaType string, profileID uuid.UUID) (uuid.UUID, error) {
 	// 1. Determine Type and ID
 	cleanID := externalID
 	if strings.Contains(externalID, ":") {
@@ -44,8 +46,8 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 		}
 	}
 
-	// 3. Fetch from MDBList
-	details, err := mdbClient.GetDetails(mdbApiKey, cleanID, mediaType)
+	// 3. Fetch details, with artwork filled in from the image providers
+	details, err := metadata.MediaDetails(ctx, providers.ParseMediaID(externalID), mediaType)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("metadata not found: %v", err)
 	}
@@ -53,18 +55,7 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 	// 4. Download Images
 	posterPath, _ := DownloadImage(details.Poster)
 	backdropPath, _ := DownloadImage(details.Backdrop)
-
-	var logoPath string
-	if details.TmdbID != 0 {
-		tmType := "movie"
-		if mediaType != "movie" {
-			tmType = "tv"
-		}
-		logoURL, err := tmdbClient.GetLogo(tmdbApiKey, details.TmdbID, tmType)
-		if err == nil && logoURL != "" {
-			logoPath, _ = DownloadImage(logoURL)
-		}
-	}
+	logoPath, _ := DownloadImage(details.Logo)
 
 	// 5. Save to DB
 	if mediaType == "movie" {
@@ -72,7 +63,7 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 			ProfileID:      profileID,
 			Title:          details.Title,
 			Overview:       details.Description,
-			MetadataSource: "mdblist",
+			MetadataSource: details.Source,
 			ExternalIDs:    map[string]any{"imdb": details.ImdbID, "tmdb": details.TmdbID},
 		}
 
@@ -87,7 +78,7 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 				tx.Create(&models.Image{OwnerType: "Movie", OwnerID: movie.ID, Type: "backdrop", LocalPath: backdropPath, SourceURL: details.Backdrop})
 			}
 			if logoPath != "" {
-				tx.Create(&models.Image{OwnerType: "Movie", OwnerID: movie.ID, Type: "logo", LocalPath: logoPath, SourceURL: ""})
+				tx.Create(&models.Image{OwnerType: "Movie", OwnerID: movie.ID, Type: "logo", LocalPath: logoPath, SourceURL: details.Logo})
 			}
 			return nil
 		})
@@ -99,10 +90,17 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 	} else {
 		// Series
 		series := models.Series{
-			ProfileID:   profileID,
-			Title:       details.Title,
-			Overview:    details.Description,
-			ExternalIDs: map[string]any{"imdb": details.ImdbID, "tmdb": details.TmdbID},
+			ProfileID:      profileID,
+			Title:          details.Title,
+			Overview:       details.Description,
+			MetadataSource: details.Source,
+			ExternalIDs:    map[string]any{"imdb": details.ImdbID, "tmdb": details.TmdbID},
+		}
+
+		// Fetched before the transaction so a slow provider doesn't hold it open
+		seasons, err := metadata.ShowSeasons(ctx, details.IDs())
+		if err != nil {
+			log.Printf("⚠️ No seasons for %s: %v", details.Title, err)
 		}
 
 		err = db.DB.Transaction(func(tx *gorm.DB) error {
@@ -116,33 +114,24 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 				tx.Create(&models.Image{OwnerType: "Series", OwnerID: series.ID, Type: "backdrop", LocalPath: backdropPath, SourceURL: details.Backdrop})
 			}
 			if logoPath != "" {
-				tx.Create(&models.Image{OwnerType: "Series", OwnerID: series.ID, Type: "logo", LocalPath: logoPath, SourceURL: ""})
+				tx.Create(&models.Image{OwnerType: "Series", OwnerID: series.ID, Type: "logo", LocalPath: logoPath, SourceURL: details.Logo})
 			}
 
-			if details.TmdbID != 0 {
-				tmdbShow, err := tmdbClient.GetTVShowDetails(tmdbApiKey, details.TmdbID)
-				if err != nil || tmdbShow == nil {
-					return err // Warn?
+			for _, s := range seasons {
+				seasonPosterPath, _ := DownloadImage(s.PosterPath)
+				season := models.Season{
+					SeriesID:       series.ID,
+					SeasonNumber:   s.SeasonNumber,
+					Title:          s.Name,
+					Overview:       s.Overview,
+					ExternalIDs:    map[string]any{s.Source: s.ID},
+					MetadataSource: s.Source,
+				}
+				if err := tx.Create(&season).Error; err != nil {
+					continue
 				}
-				for _, s := range tmdbShow.Seasons {
-					var seasonPosterPath string
-					if s.PosterPath != "" {
-						fullUrl := "https://image.tmdb.org/t/p/w500" + s.PosterPath
-						seasonPosterPath, _ = DownloadImage(fullUrl)
-					}
-					season := models.Season{
-						SeriesID:     series.ID,
-						SeasonNumber: s.SeasonNumber,
-						Title:        s.Name,
-						Overview:     s.Overview,
-						ExternalIDs:  map[string]any{"tmdb": s.ID},
-					}
-					if err := tx.Create(&season).Error; err != nil {
-						continue
-					}
-					if seasonPosterPath != "" {
-						tx.Create(&models.Image{OwnerType: "Season", OwnerID: season.ID, Type: "poster", LocalPath: seasonPosterPath})
-					}
+				if seasonPosterPath != "" {
+					tx.Create(&models.Image{OwnerType: "Season", OwnerID: season.ID, Type: "poster", LocalPath: seasonPosterPath, SourceURL: s.PosterPath})
 				}
 			}
 			return nil
@@ -155,12 +144,12 @@ func EnsureMedia(mdbClient *mdblist.Client, tmdbClient *tmdb.Client, mdbApiKey,
 }
 
 // EnsureEpisode ensures an episode exists for the given series and identifiers.
-// Since EnsureMedia creates Seasons, this checks for the Episode record and fetches from TMDB if missing.
-func EnsureEpisode(tmdbClient *tmdb.Client, tmdbApiKey string, seriesID uuid.UUID, seasonNum, episodeNum int) (uuid.UUID, error) {
+// Since EnsureMedia creates Seasons, this checks for the Episode record and fetches it if missing.
+func EnsureEpisode(ctx context.Context, metadata *providers.MetadataResolver, seriesID uuid.UUID, seasonNum, episodeNum int) (uuid.UUID, error) {
 	// 1. Find Season
 	var season models.Season
 	if err := db.DB.Where("series_id = ? AND season_number = ?", seriesID, seasonNum).First(&season).Error; err != nil {
-		// Season missing? EnsureMedia should have created it if it exists on TMDB.
+		// Season missing? EnsureMedia should have created it if the provider knew it.
 		// If it's a new season not in DB, we might need to fetch season details?
 		// For now simple fail.
 		return uuid.Nil, fmt.Errorf("season %d not found for series", seasonNum)
@@ -172,45 +161,37 @@ func EnsureEpisode(tmdbClient *tmdb.Client, tmdbApiKey string, seriesID uuid.UUI
 		return episode.ID, nil
 	}
 
-	// 3. Fetch from TMDB
-	// Need TMDB ID of the Series to call API.
+	// 3. Fetch the season's episodes
+	// Need the IDs of the Series to call the providers.
 	var series models.Series
 	if err := db.DB.First(&series, seriesID).Error; err != nil {
 		return uuid.Nil, err
 	}
-
-	tmdbIDVal, ok := series.ExternalIDs["tmdb"]
-	if !ok {
-		return uuid.Nil, fmt.Errorf("series has no tmdb id")
-	}
-
-	// Handle float64/int weirdness from JSON
-	var tmdbID int
-	if f, ok := tmdbIDVal.(float64); ok {
-		tmdbID = int(f)
-	} else if i, ok := tmdbIDVal.(int); ok {
-		tmdbID = i
-	} else {
-		return uuid.Nil, fmt.Errorf("invalid tmdb id format")
+	ids := seriesIDs(series.ExternalIDs)
+	if ids.Empty() {
+		return uuid.Nil, fmt.Errorf("series has no external id")
 	}
 
-	epDetails, err := tmdbClient.GetEpisodeDetails(tmdbApiKey, tmdbID, seasonNum, episodeNum)
+	seasonDetails, err := metadata.SeasonEpisodes(ctx, ids, seasonNum)
 	if err != nil {
 		return uuid.Nil, err
 	}
+	epDetails := seasonDetails.Episode(episodeNum)
+	if epDetails == nil {
+		return uuid.Nil, fmt.Errorf("episode %d not found in season %d", episodeNum, seasonNum)
+	}
 
 	// 4. Create Episode
 	newEp := models.Episode{
-		SeasonID:      season.ID,
-		Title:         epDetails.Name,
-		Overview:      epDetails.Overview,
-		EpisodeNumber: epDetails.EpisodeNumber,
-		Runtime:       epDetails.Runtime,
-		AirDate:       nil, // Parse if needed
-		ExternalIDs:   map[string]any{"tmdb": epDetails.ID},
-	}
-	if epDetails.AirDate != "" {
-		// simple parse attempts? skip for now
+		SeriesID:       seriesID,
+		SeasonID:       season.ID,
+		Title:          epDetails.Name,
+		Overview:       epDetails.Overview,
+		EpisodeNumber:  epDetails.EpisodeNumber,
+		Runtime:        epDetails.Runtime,
+		AirDate:        nil, // Parse if needed
+		ExternalIDs:    map[string]any{seasonDetails.Source: epDetails.ID},
+		MetadataSource: seasonDetails.Source,
 	}
 
 	err = db.DB.Transaction(func(tx *gorm.DB) error {
@@ -220,10 +201,9 @@ func EnsureEpisode(tmdbClient *tmdb.Client, tmdbApiKey string, seriesID uuid.UUI
 
 		// Image
 		if epDetails.StillPath != "" {
-			fullUrl := "https://image.tmdb.org/t/p/w500" + epDetails.StillPath
-			path, _ := DownloadImage(fullUrl)
+			path, _ := DownloadImage(epDetails.StillPath)
 			if path != "" {
-				tx.Create(&models.Image{OwnerType: "Episode", OwnerID: newEp.ID, Type: "still", LocalPath: path})
+				tx.Create(&models.Image{OwnerType: "Episode", OwnerID: newEp.ID, Type: "still", LocalPath: path, SourceURL: epDetails.StillPath})
 			}
 		}
 		return nil
@@ -236,6 +216,20 @@ func EnsureEpisode(tmdbClient *tmdb.Client, tmdbApiKey string, seriesID uuid.UUI
 	return newEp.ID, nil
 }
 
+// seriesIDs reads the IDs stored in a record's ExternalIDs.
+// JSON numbers come back as float64.
+func seriesIDs(external map[string]any) providers.MediaIDs {
+	var ids providers.MediaIDs
+	ids.Imdb, _ = external["imdb"].(string)
+	switch v := external["tmdb"].(type) {
+	case float64:
+		ids.Tmdb = int(v)
+	case int:
+		ids.Tmdb = v
+	}
+	return ids
+}
+
 func linkToProfile(mediaID uuid.UUID, mediaType string, profileID uuid.UUID) error {
 	entry := models.LibraryEntry{
 		ProfileID: profileID,
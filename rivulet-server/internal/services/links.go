@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultLinkTTL is how long unrestricted links are reused. RD links live for
+// several hours; every reuse is validated first anyway.
+const DefaultLinkTTL = 3 * time.Hour
+
+// LinkTTL can be overridden at startup
+var LinkTTL = DefaultLinkTTL
+
+// How long the validation request may take before the link is treated as dead
+const linkCheckTimeout = 3 * time.Second
+
+var linkCheckClient = &http.Client{
+	Timeout: linkCheckTimeout,
+	// A redirect is fine, no need to follow it to the CDN
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// CachedLink returns a still-working link for a file, or nil
+func CachedLink(ctx context.Context, accountID uuid.UUID, service, hash string, fileID int) *models.UnrestrictedLink {
+	return firstValidLink(ctx, db.DB.Where("account_id = ? AND service = ? AND hash = ? AND file_id = ?",
+		accountID, service, strings.ToLower(hash), fileID))
+}
+
+// CachedLinkFor returns a still-working link resolved earlier for the same
+// episode (or movie, with season and episode 0) of a torrent, or nil
+func CachedLinkFor(ctx context.Context, accountID uuid.UUID, service, hash string, season, episode int) *models.UnrestrictedLink {
+	return firstValidLink(ctx, db.DB.Where("account_id = ? AND service = ? AND hash = ? AND season = ? AND episode = ?",
+		accountID, service, strings.ToLower(hash), season, episode))
+}
+
+// CachedLinkBySource returns a still-working link unrestricted from a debrid file link, or nil
+func CachedLinkBySource(ctx context.Context, accountID uuid.UUID, service, source string) *models.UnrestrictedLink {
+	return firstValidLink(ctx, db.DB.Where("account_id = ? AND service = ? AND source = ?", accountID, service, source))
+}
+
+// StoreLink caches an unrestricted link for LinkTTL. Links without a hash and
+// file ID are cached by the debrid link they were unrestricted from.
+func StoreLink(entry models.UnrestrictedLink) {
+	if entry.Download == "" || LinkTTL <= 0 {
+		return
+	}
+	entry.Hash = strings.ToLower(entry.Hash)
+	entry.ExpiresAt = time.Now().Add(LinkTTL)
+
+	key := fmt.Sprintf("%s file %d", entry.Hash, entry.FileID)
+	conflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: "account_id"}, {Name: "service"}, {Name: "hash"}, {Name: "file_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "hash <> ''"}}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"torrent_id", "source", "season", "episode", "path", "filename", "bytes", "download", "expires_at", "updated_at",
+		}),
+	}
+	if entry.Hash == "" || entry.FileID == 0 {
+		if entry.Source == "" {
+			return
+		}
+		entry.Hash, entry.FileID = "", 0
+		key = entry.Source
+		conflict = clause.OnConflict{
+			Columns:     []clause.Column{{Name: "account_id"}, {Name: "service"}, {Name: "source"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "hash = ''"}}},
+			DoUpdates:   clause.AssignmentColumns([]string{"filename", "bytes", "download", "expires_at", "updated_at"}),
+		}
+	}
+
+	if err := db.DB.Clauses(conflict).Create(&entry).Error; err != nil {
+		log.Printf("⚠️ Failed to cache link for %s: %v", key, err)
+		return
+	}
+
+	// Expired links are useless; drop the account's old ones while we're here
+	db.DB.Where("account_id = ? AND expires_at < ?", entry.AccountID, time.Now()).Delete(&models.UnrestrictedLink{})
+}
+
+// ForgetLinks drops an account's cached links for a torrent
+func ForgetLinks(accountID uuid.UUID, service, hash string) {
+	db.DB.Where("account_id = ? AND service = ? AND hash = ?", accountID, service, strings.ToLower(hash)).
+		Delete(&models.UnrestrictedLink{})
+}
+
+// firstValidLink returns the newest unexpired link matching q that still answers,
+// deleting any that don't
+func firstValidLink(ctx context.Context, q *gorm.DB) *models.UnrestrictedLink {
+	var links []models.UnrestrictedLink
+	q.Where("expires_at > ?", time.Now()).Order("updated_at DESC").Limit(3).Find(&links)
+
+	return firstAlive(ctx, links, func(link *models.UnrestrictedLink) {
+		db.DB.Delete(link)
+	})
+}
+
+// firstAlive returns the first link that still answers, passing the dead ones before it to drop
+func firstAlive(ctx context.Context, links []models.UnrestrictedLink, drop func(*models.UnrestrictedLink)) *models.UnrestrictedLink {
+	for _, link := range links {
+		if linkAlive(ctx, link.Download) {
+			return &link
+		}
+		drop(&link)
+	}
+	return nil
+}
+
+// linkAlive checks a download URL without downloading it.
+// Some hosts refuse HEAD, so those get a one-byte range request instead.
+func linkAlive(ctx context.Context, url string) bool {
+	ctx, cancel := context.WithTimeout(ctx, linkCheckTimeout)
+	defer cancel()
+
+	status := probe(ctx, http.MethodHead, url)
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		status = probe(ctx, http.MethodGet, url)
+	}
+	return status >= 200 && status < 400
+}
+
+func probe(ctx context.Context, method, url string) int {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := linkCheckClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
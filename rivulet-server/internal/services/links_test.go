@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"rivulet_server/internal/models"
+	"testing"
+)
+
+// cdnStub serves download URLs in the states linkAlive has to tell apart
+func cdnStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("/ok got %s, want HEAD", r.Method)
+		}
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("fallback GET sent Range %q, want bytes=0-0", r.Header.Get("Range"))
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte("x"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/dead", http.StatusFound)
+	})
+	mux.HandleFunc("/dead", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLinkAlive(t *testing.T) {
+	srv := cdnStub(t)
+	tests := map[string]bool{
+		"/ok":       true,
+		"/no-head":  true,
+		"/redirect": true, // Not followed: the CDN answers for itself
+		"/dead":     false,
+	}
+	for path, want := range tests {
+		if got := linkAlive(context.Background(), srv.URL+path); got != want {
+			t.Errorf("linkAlive(%s) = %v, want %v", path, got, want)
+		}
+	}
+	if linkAlive(context.Background(), "http://127.0.0.1:1/unreachable") {
+		t.Error("unreachable host reported alive")
+	}
+}
+
+func TestFirstAliveDropsDeadLinks(t *testing.T) {
+	srv := cdnStub(t)
+	links := []models.UnrestrictedLink{
+		{Filename: "gone", Download: srv.URL + "/dead"},
+		{Filename: "working", Download: srv.URL + "/no-head"},
+		{Filename: "older", Download: srv.URL + "/ok"},
+	}
+
+	var dropped []string
+	got := firstAlive(context.Background(), links, func(link *models.UnrestrictedLink) {
+		dropped = append(dropped, link.Filename)
+	})
+	if got == nil || got.Filename != "working" {
+		t.Fatalf("got %+v, want the working link", got)
+	}
+	if len(dropped) != 1 || dropped[0] != "gone" {
+		t.Errorf("dropped %v, want [gone]", dropped)
+	}
+
+	dropped = nil
+	if got := firstAlive(context.Background(), links[:1], func(link *models.UnrestrictedLink) {
+		dropped = append(dropped, link.Filename)
+	}); got != nil || len(dropped) != 1 {
+		t.Errorf("all dead: got %+v, dropped %v", got, dropped)
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"log"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// Polling schedule for pending torrents: start fast, back off to a slow check
+const (
+	pendingTick        = 5 * time.Second
+	pendingFirstCheck  = 10 * time.Second
+	pendingMaxBackoff  = 10 * time.Minute
+	pendingGiveUpAfter = 48 * time.Hour
+	// Finished entries stay listed this long so the app can show them
+	pendingRetention = 7 * 24 * time.Hour
+)
+
+// DebridLookup returns the debrid service and key configured for an account
+type DebridLookup func(accountID uuid.UUID) (providers.DebridService, string, error)
+
+// PendingTracker polls torrents that are still downloading and notifies
+// subscribed profiles when they become ready or fail
+type PendingTracker struct {
+	Lookup DebridLookup
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[chan models.PendingTorrent]struct{}
+}
+
+func NewPendingTracker(lookup DebridLookup) *PendingTracker {
+	return &PendingTracker{
+		Lookup:      lookup,
+		subscribers: make(map[uuid.UUID]map[chan models.PendingTorrent]struct{}),
+	}
+}
+
+// Track starts (or restarts) polling a torrent for a profile
+func (t *PendingTracker) Track(entry models.PendingTorrent) {
+	// Restarting resets the clock the give-up check runs against
+	entry.CreatedAt = time.Now()
+	entry.Status = models.PendingDownloading
+	entry.Attempts = 0
+	entry.Error = ""
+	entry.ReadyAt = nil
+	entry.NextCheckAt = time.Now().Add(pendingFirstCheck)
+
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "profile_id"}, {Name: "service"}, {Name: "torrent_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"magnet", "external_id", "title", "season", "episode", "file_index",
+			"status", "progress", "error", "ready_at", "attempts", "next_check_at", "created_at", "updated_at",
+		}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to track pending torrent %s: %v", entry.TorrentID, err)
+		return
+	}
+	t.publish(entry)
+}
+
+// List returns a profile's pending and recently finished torrents, newest first
+func (t *PendingTracker) List(profileID uuid.UUID) []models.PendingTorrent {
+	var entries []models.PendingTorrent
+	db.DB.Where("profile_id = ?", profileID).Order("created_at DESC").Find(&entries)
+	return entries
+}
+
+// Dismiss removes an entry from a profile's list
+func (t *PendingTracker) Dismiss(profileID, id uuid.UUID) bool {
+	res := db.DB.Where("id = ? AND profile_id = ?", id, profileID).Delete(&models.PendingTorrent{})
+	return res.RowsAffected > 0
+}
+
+// Subscribe returns a channel of updates for a profile. Call cancel when done.
+func (t *PendingTracker) Subscribe(profileID uuid.UUID) (<-chan models.PendingTorrent, func()) {
+	ch := make(chan models.PendingTorrent, 16)
+
+	t.mu.Lock()
+	if t.subscribers[profileID] == nil {
+		t.subscribers[profileID] = make(map[chan models.PendingTorrent]struct{})
+	}
+	t.subscribers[profileID][ch] = struct{}{}
+	t.mu.Unlock()
+
+	cancel := func() {
+		t.mu.Lock()
+		delete(t.subscribers[profileID], ch)
+		if len(t.subscribers[profileID]) == 0 {
+			delete(t.subscribers, profileID)
+		}
+		t.mu.Unlock()
+	}
+	return ch, cancel
+}
+
+// publish sends an update to the profile's subscribers, dropping it for slow ones
+func (t *PendingTracker) publish(entry models.PendingTorrent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ch := range t.subscribers[entry.ProfileID] {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// Run polls due torrents until ctx is cancelled
+func (t *PendingTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(pendingTick)
+	defer ticker.Stop()
+
+	lastPrune := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		t.pollDue(ctx)
+
+		if time.Since(lastPrune) > time.Hour {
+			db.DB.Where("status <> ? AND updated_at < ?", models.PendingDownloading, time.Now().Add(-pendingRetention)).
+				Delete(&models.PendingTorrent{})
+			lastPrune = time.Now()
+		}
+	}
+}
+
+func (t *PendingTracker) pollDue(ctx context.Context) {
+	var due []models.PendingTorrent
+	db.DB.Where("status = ? AND next_check_at <= ?", models.PendingDownloading, time.Now()).
+		Order("next_check_at").Limit(50).Find(&due)
+
+	for _, entry := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		t.check(ctx, entry)
+	}
+}
+
+// check polls one torrent and records the outcome
+func (t *PendingTracker) check(ctx context.Context, entry models.PendingTorrent) {
+	changed := false
+	defer func() {
+		db.DB.Save(&entry)
+		if changed {
+			t.publish(entry)
+		}
+	}()
+
+	entry.Attempts++
+	entry.NextCheckAt = time.Now().Add(pendingBackoff(entry.Attempts))
+
+	if time.Since(entry.CreatedAt) > pendingGiveUpAfter {
+		entry.Status = models.PendingError
+		entry.Error = "download did not finish in time"
+		changed = true
+		return
+	}
+
+	debrid, token, err := t.Lookup(entry.AccountID)
+	if err != nil || debrid.Name() != entry.Service {
+		// The account removed or switched its debrid key
+		entry.Status = models.PendingError
+		entry.Error = "debrid service no longer configured"
+		changed = true
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	info, err := debrid.TorrentStatus(checkCtx, token, entry.TorrentID)
+	if err != nil {
+		// Transient: keep backing off
+		log.Printf("⚠️ Pending torrent %s check failed: %v", entry.TorrentID, err)
+		return
+	}
+
+	if info.Progress != entry.Progress {
+		entry.Progress = info.Progress
+		changed = true
+	}
+
+	switch info.Status {
+	case providers.DebridStatusDownloaded:
+		now := time.Now()
+		entry.Status = models.PendingReady
+		entry.Progress = 100
+		entry.ReadyAt = &now
+		changed = true
+		RecordCachedHash(debrid.Name(), info.Hash, info.Files)
+	case providers.DebridStatusError:
+		entry.Status = models.PendingError
+		entry.Error = "the debrid service failed to download this torrent"
+		changed = true
+	}
+}
+
+// pendingBackoff doubles the interval per attempt, capped at pendingMaxBackoff
+func pendingBackoff(attempts int) time.Duration {
+	d := pendingFirstCheck
+	for i := 1; i < attempts && d < pendingMaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, pendingMaxBackoff)
+}
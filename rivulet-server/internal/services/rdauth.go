@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers/realdebrid"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrNotLinked is returned for accounts that pasted an API token instead of linking through OAuth
+var ErrNotLinked = errors.New("real-debrid account is not linked through oauth")
+
+// Access tokens this close to expiry are refreshed before use
+const rdRefreshMargin = time.Minute
+
+// RealDebridAuth keeps accounts' Real-Debrid OAuth tokens fresh
+type RealDebridAuth struct {
+	Client *realdebrid.Client
+
+	// Serializes refreshes so concurrent requests don't each spend the refresh token
+	mu sync.Mutex
+}
+
+func NewRealDebridAuth(client *realdebrid.Client) *RealDebridAuth {
+	return &RealDebridAuth{Client: client}
+}
+
+// NeedsRefresh reports whether the account's access token is about to expire
+func NeedsRefresh(acc *models.Account) bool {
+	return acc.RDRefreshToken != "" && time.Until(acc.RDTokenExpiresAt) < rdRefreshMargin
+}
+
+// RefreshExpired is a realdebrid.TokenRefresher: it finds the account using the
+// rejected token and refreshes it. A token that was already replaced still
+// finds its account, and gets the current token back.
+func (a *RealDebridAuth) RefreshExpired(ctx context.Context, expired string) (string, error) {
+	var acc models.Account
+	err := db.DB.Select("id").Where("real_debrid_key = ? OR rd_previous_key = ?", expired, expired).First(&acc).Error
+	if err != nil {
+		return "", err
+	}
+	return a.Refresh(ctx, acc.ID, expired)
+}
+
+// Refresh replaces the account's access token. If another request already
+// refreshed it, the current token is returned instead.
+func (a *RealDebridAuth) Refresh(ctx context.Context, accountID uuid.UUID, expired string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var acc models.Account
+	err := db.DB.Select("id, real_debrid_key, rd_client_id, rd_client_secret, rd_refresh_token, rd_token_expires_at").
+		First(&acc, accountID).Error
+	if err != nil {
+		return "", err
+	}
+	if acc.RDRefreshToken == "" {
+		return "", ErrNotLinked
+	}
+	if acc.RealDebridKey != expired && !NeedsRefresh(&acc) {
+		return acc.RealDebridKey, nil
+	}
+
+	creds := realdebrid.DeviceCredentials{ClientID: acc.RDClientID, ClientSecret: acc.RDClientSecret}
+	token, err := a.Client.RefreshToken(ctx, creds, acc.RDRefreshToken)
+	if err != nil {
+		return "", err
+	}
+	if err := SaveRealDebridToken(accountID, creds, token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// SaveRealDebridToken stores OAuth credentials and tokens on the account
+func SaveRealDebridToken(accountID uuid.UUID, creds realdebrid.DeviceCredentials, token *realdebrid.Token) error {
+	updates := map[string]interface{}{
+		"real_debrid_key":     token.AccessToken,
+		"rd_client_id":        creds.ClientID,
+		"rd_client_secret":    creds.ClientSecret,
+		"rd_token_expires_at": token.ExpiresAt(),
+		// Evaluated against the row before the update, so this is the token being replaced
+		"rd_previous_key": gorm.Expr("real_debrid_key"),
+	}
+	// RD doesn't always send a new refresh token; the old one stays valid then
+	if token.RefreshToken != "" {
+		updates["rd_refresh_token"] = token.RefreshToken
+	}
+	return db.DB.Model(&models.Account{}).Where("id = ?", accountID).Updates(updates).Error
+}
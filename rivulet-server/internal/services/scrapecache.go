@@ -0,0 +1,68 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// PostgresScrapeCache is a providers.CacheStore that survives restarts and is
+// shared between server instances
+type PostgresScrapeCache struct {
+	// MaxAge is how long entries are kept at all (fresh + stale window)
+	MaxAge time.Duration
+}
+
+func NewPostgresScrapeCache(maxAge time.Duration) *PostgresScrapeCache {
+	return &PostgresScrapeCache{MaxAge: maxAge}
+}
+
+func (c *PostgresScrapeCache) Get(key string) (*providers.CacheEntry, bool) {
+	var row models.ScrapeCacheEntry
+	if err := db.DB.Where("key = ?", key).First(&row).Error; err != nil {
+		return nil, false
+	}
+	if c.MaxAge > 0 && time.Since(row.StoredAt) > c.MaxAge {
+		db.DB.Delete(&row)
+		return nil, false
+	}
+
+	var streams []*providers.Stream
+	if err := json.Unmarshal(row.Streams, &streams); err != nil {
+		log.Printf("⚠️ Corrupt scrape cache entry %s: %v", key, err)
+		return nil, false
+	}
+	return &providers.CacheEntry{Streams: streams, StoredAt: row.StoredAt}, true
+}
+
+func (c *PostgresScrapeCache) Set(key string, entry *providers.CacheEntry) {
+	payload, err := json.Marshal(entry.Streams)
+	if err != nil {
+		log.Printf("⚠️ Failed to encode scrape cache entry %s: %v", key, err)
+		return
+	}
+
+	row := models.ScrapeCacheEntry{Key: key, Streams: payload, StoredAt: entry.StoredAt}
+	err = db.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"streams", "stored_at", "updated_at"}),
+	}).Create(&row).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to store scrape cache entry %s: %v", key, err)
+	}
+}
+
+// PruneScrapeCache deletes entries older than maxAge
+func PruneScrapeCache(maxAge time.Duration) {
+	res := db.DB.Where("stored_at < ?", time.Now().Add(-maxAge)).Delete(&models.ScrapeCacheEntry{})
+	if res.Error != nil {
+		log.Printf("⚠️ Scrape cache prune failed: %v", res.Error)
+	} else if res.RowsAffected > 0 {
+		log.Printf("🧹 Pruned %d scrape cache entries", res.RowsAffected)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"rivulet_server/internal/providers"
+	"rivulet_server/internal/subtitles"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Downloaded subtitles are kept in AssetsDir under this folder, one file per format
+const SubtitlesDir = "subtitles"
+
+// Provider file IDs become file names, so they're restricted to safe characters
+var safeSubtitleID = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// SearchSubtitles queries every provider concurrently and ranks the results:
+// hash matches first, then language preference, same release, human translations
+// and popularity. A failing provider is logged and skipped.
+func SearchSubtitles(ctx context.Context, sources []providers.SubtitleProvider, q providers.SubtitleQuery) []providers.Subtitle {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		all []providers.Subtitle
+	)
+	for _, p := range sources {
+		wg.Add(1)
+		go func(p providers.SubtitleProvider) {
+			defer wg.Done()
+			subs, err := p.Search(ctx, q)
+			if err != nil {
+				log.Printf("⚠️ %s subtitle search failed: %v", p.Name(), err)
+				return
+			}
+			mu.Lock()
+			all = append(all, subs...)
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	// Unknown languages sort after every preferred one
+	langRank := func(lang string) int {
+		if i := slices.Index(q.Languages, strings.ToLower(lang)); i >= 0 {
+			return i
+		}
+		return len(q.Languages)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.HashMatch != b.HashMatch {
+			return a.HashMatch
+		}
+		if la, lb := langRank(a.Language), langRank(b.Language); la != lb {
+			return la < lb
+		}
+		if a.ReleaseMatch != b.ReleaseMatch {
+			return a.ReleaseMatch
+		}
+		if a.MachineTranslated != b.MachineTranslated {
+			return !a.MachineTranslated
+		}
+		return a.Downloads > b.Downloads
+	})
+	return all
+}
+
+// SubtitleFile returns the path of a cached subtitle in the given format ("vtt" or
+// the provider's own, e.g. "srt"), downloading and converting it on first use
+func SubtitleFile(ctx context.Context, provider providers.SubtitleProvider, id, format string) (string, error) {
+	if !safeSubtitleID.MatchString(id) {
+		return "", providers.ErrSubtitleNotFound
+	}
+	dir := filepath.Join(AssetsDir, SubtitlesDir)
+	base := filepath.Join(dir, provider.Name()+"-"+id)
+
+	path := base + "." + format
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	// The original is kept too, so other formats don't cost another download
+	var original []byte
+	matches, _ := filepath.Glob(base + ".*")
+	for _, m := range matches {
+		if !strings.HasSuffix(m, ".vtt") {
+			original, _ = os.ReadFile(m)
+			break
+		}
+	}
+	if original == nil && slices.Contains(matches, base+".vtt") {
+		// A lone .vtt is a WebVTT original, which isn't converted from
+		return "", fmt.Errorf("%w in %s format", providers.ErrSubtitleNotFound, format)
+	}
+	if original == nil {
+		data, err := provider.Download(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+		ext := "srt"
+		if subtitles.IsWebVTT(data) {
+			ext = "vtt"
+		}
+		if err := os.WriteFile(base+"."+ext, data, 0644); err != nil {
+			return "", err
+		}
+		original = data
+		if ext == format {
+			return path, nil
+		}
+	}
+
+	if format != "vtt" {
+		// Only WebVTT is converted to; other formats are served as downloaded
+		return "", fmt.Errorf("%w in %s format", providers.ErrSubtitleNotFound, format)
+	}
+	if err := os.WriteFile(path, subtitles.ToWebVTT(original), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"rivulet_server/internal/providers"
+	"testing"
+)
+
+// countingSubtitles serves one fixed subtitle and counts downloads
+type countingSubtitles struct {
+	data      string
+	downloads int
+}
+
+func (p *countingSubtitles) Name() string { return "stub" }
+
+func (p *countingSubtitles) Search(ctx context.Context, q providers.SubtitleQuery) ([]providers.Subtitle, error) {
+	return nil, nil
+}
+
+func (p *countingSubtitles) Download(ctx context.Context, id string) ([]byte, error) {
+	p.downloads++
+	return []byte(p.data), nil
+}
+
+func TestSubtitleFileDownloadsOnce(t *testing.T) {
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+
+	srt := &countingSubtitles{data: "1\n00:00:01,000 --> 00:00:02,000\nHello\n"}
+	for _, format := range []string{"vtt", "srt", "vtt"} {
+		if _, err := SubtitleFile(ctx, srt, "1", format); err != nil {
+			t.Errorf("SRT original as %s: %v", format, err)
+		}
+	}
+	if srt.downloads != 1 {
+		t.Errorf("SRT original downloaded %d times, want once", srt.downloads)
+	}
+
+	vtt := &countingSubtitles{data: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n"}
+	if _, err := SubtitleFile(ctx, vtt, "2", "vtt"); err != nil {
+		t.Fatalf("WebVTT original: %v", err)
+	}
+	for range 2 {
+		if _, err := SubtitleFile(ctx, vtt, "2", "srt"); !errors.Is(err, providers.ErrSubtitleNotFound) {
+			t.Errorf("WebVTT original as srt: err = %v, want ErrSubtitleNotFound", err)
+		}
+	}
+	if vtt.downloads != 1 {
+		t.Errorf("WebVTT original downloaded %d times, want once", vtt.downloads)
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"rivulet_server/internal/providers"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+var btihRegex = regexp.MustCompile(`(?i)btih:([a-z0-9]+)`)
+
+// MagnetHash extracts the lowercase info hash from a magnet link
+func MagnetHash(magnet string) string {
+	m := btihRegex.FindStringSubmatch(magnet)
+	if len(m) < 2 {
+		return ""
+	}
+	return strings.ToLower(m[1])
+}
+
+// ExistingTorrent offers the torrents already on the account for a hash to use,
+// one at a time, until it accepts one. Torrents Rivulet added are offered first;
+// the service's torrent list is only searched when none of those will do, since
+// on RD that takes several API calls.
+func ExistingTorrent(ctx context.Context, debrid providers.DebridService, accountID uuid.UUID, token, hash string, use func(id string) bool) bool {
+	if hash == "" {
+		return false
+	}
+
+	var known []models.DebridTorrent
+	err := db.DB.Where("account_id = ? AND service = ? AND hash = ?", accountID, debrid.Name(), hash).
+		Order("created_at DESC").Find(&known).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to load known torrents for %s: %v", hash, err)
+	}
+	tried := make([]string, 0, len(known))
+	for _, t := range known {
+		if use(t.TorrentID) {
+			return true
+		}
+		tried = append(tried, t.TorrentID)
+	}
+
+	finder, ok := debrid.(providers.DebridTorrentFinder)
+	if !ok {
+		return false
+	}
+	found, err := finder.FindTorrents(ctx, token, hash)
+	if err != nil {
+		log.Printf("⚠️ %s torrent lookup failed: %v", debrid.Name(), err)
+	}
+	for _, id := range found {
+		if !slices.Contains(tried, id) && use(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// RememberTorrent records a torrent Rivulet just added to the account
+func RememberTorrent(accountID uuid.UUID, service, hash, torrentID string) {
+	if hash == "" || torrentID == "" {
+		return
+	}
+	entry := models.DebridTorrent{
+		AccountID: accountID,
+		Service:   service,
+		TorrentID: torrentID,
+		Hash:      strings.ToLower(hash),
+	}
+	err := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to remember torrent %s: %v", torrentID, err)
+	}
+}
+
+// ForgetTorrent drops a torrent that no longer exists or failed on the service
+func ForgetTorrent(accountID uuid.UUID, service, torrentID string) {
+	db.DB.Where("account_id = ? AND service = ? AND torrent_id = ?", accountID, service, torrentID).
+		Delete(&models.DebridTorrent{})
+}
+
+// TouchTorrent records that a torrent Rivulet added was just played from.
+// Torrents the user added themselves have no row and are left alone.
+func TouchTorrent(accountID uuid.UUID, service, torrentID, imdbID string, season, episode int) {
+	var entry models.DebridTorrent
+	err := db.DB.Where("account_id = ? AND service = ? AND torrent_id = ?", accountID, service, torrentID).
+		First(&entry).Error
+	if err != nil {
+		return
+	}
+
+	entry.LastUsedAt = time.Now()
+	if imdbID != "" {
+		entry.ImdbID = imdbID
+	}
+	if episode > 0 {
+		ref := models.EpisodeRef{Season: season, Episode: episode}
+		if !slices.Contains(entry.Episodes, ref) {
+			entry.Episodes = append(entry.Episodes, ref)
+		}
+	}
+	db.DB.Save(&entry)
+}
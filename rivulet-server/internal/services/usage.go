@@ -0,0 +1,42 @@
+package services
+
+import (
+	"log"
+	"rivulet_server/internal/db"
+	"rivulet_server/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecordStreamBytes adds proxied bytes to the profile's total for today
+func RecordStreamBytes(profileID uuid.UUID, bytes int64) {
+	if profileID == uuid.Nil || bytes <= 0 {
+		return
+	}
+	entry := models.StreamUsage{
+		ProfileID: profileID,
+		Day:       time.Now().UTC().Truncate(24 * time.Hour),
+		Bytes:     bytes,
+	}
+	err := db.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "profile_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"bytes":      gorm.Expr("stream_usages.bytes + EXCLUDED.bytes"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to record stream usage for %s: %v", profileID, err)
+	}
+}
+
+// StreamUsageSince returns the profile's daily totals from the given day on, oldest first
+func StreamUsageSince(profileID uuid.UUID, since time.Time) []models.StreamUsage {
+	var usage []models.StreamUsage
+	db.DB.Where("profile_id = ? AND day >= ?", profileID, since.UTC().Truncate(24*time.Hour)).
+		Order("day").Find(&usage)
+	return usage
+}
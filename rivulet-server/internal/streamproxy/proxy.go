@@ -0,0 +1,153 @@
+package streamproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrTooManyStreams is returned when an account already has MaxStreams open
+	ErrTooManyStreams = errors.New("too many concurrent streams")
+	// ErrUpstream is returned when the debrid host can't be reached or refuses the request
+	ErrUpstream = errors.New("upstream failed")
+)
+
+// DefaultMaxStreams is the per-account limit used when none is configured
+const DefaultMaxStreams = 2
+
+// Request headers passed on to the upstream host
+var forwardRequestHeaders = []string{"Range", "If-Range", "If-Modified-Since", "If-None-Match"}
+
+// Response headers passed back to the player
+var forwardResponseHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges",
+	"Content-Disposition", "ETag", "Last-Modified",
+}
+
+// Proxy streams debrid download links through the server
+type Proxy struct {
+	Client *http.Client
+	// MaxStreams is how many streams one account may have open; 0 means unlimited
+	MaxStreams int
+	// OnBytes, if set, is called when a stream ends with the bytes sent to the player
+	OnBytes func(profileID uuid.UUID, bytes int64)
+
+	mu     sync.Mutex
+	active map[uuid.UUID]int
+}
+
+func New(maxStreams int) *Proxy {
+	return &Proxy{
+		// No overall timeout: a stream lasts as long as the movie
+		Client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: 15 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+				// Transparent gzip would drop Content-Length and break byte ranges
+				DisableCompression: true,
+			},
+		},
+		MaxStreams: maxStreams,
+		active:     make(map[uuid.UUID]int),
+	}
+}
+
+// Active returns how many streams an account has open
+func (p *Proxy) Active(accountID uuid.UUID) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active[accountID]
+}
+
+// acquire reserves a stream slot for the account
+func (p *Proxy) acquire(accountID uuid.UUID) (func(), error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.MaxStreams > 0 && p.active[accountID] >= p.MaxStreams {
+		return nil, ErrTooManyStreams
+	}
+	p.active[accountID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.active[accountID]--
+			if p.active[accountID] <= 0 {
+				delete(p.active, accountID)
+			}
+		})
+	}, nil
+}
+
+// Serve streams upstream to w, forwarding Range and conditional headers from r.
+// The upstream is read only as fast as the player consumes it.
+// Errors are only returned before anything was written to w.
+func (p *Proxy) Serve(w http.ResponseWriter, r *http.Request, upstream string, accountID, profileID uuid.UUID) error {
+	release, err := p.acquire(accountID)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	method := http.MethodGet
+	if r.Method == http.MethodHead {
+		method = http.MethodHead
+	}
+	req, err := http.NewRequestWithContext(r.Context(), method, upstream, nil)
+	if err != nil {
+		return err
+	}
+	for _, h := range forwardRequestHeaders {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusPartialContent, http.StatusNotModified, http.StatusRequestedRangeNotSatisfiable:
+	default:
+		return fmt.Errorf("%w: %s", ErrUpstream, resp.Status)
+	}
+
+	for _, h := range forwardResponseHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	if w.Header().Get("Accept-Ranges") == "" && resp.StatusCode == http.StatusPartialContent {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	if method == http.MethodHead {
+		return nil
+	}
+
+	sent, err := io.CopyBuffer(w, resp.Body, make([]byte, 64<<10))
+	// Only media counts, not error pages
+	if p.OnBytes != nil && sent > 0 && resp.StatusCode < 300 {
+		p.OnBytes(profileID, sent)
+	}
+	// The player closing the connection mid-stream is normal (seeking, stopping)
+	if err != nil && !errors.Is(err, context.Canceled) && r.Context().Err() == nil {
+		log.Printf("⚠️ Proxied stream interrupted after %d bytes: %v", sent, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package streamproxy
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fileStub serves a fixed video file with Range support, like a debrid CDN
+func fileStub(t *testing.T, content []byte) *httptest.Server {
+	t.Helper()
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "video/x-matroska")
+		http.ServeContent(w, r, "movie.mkv", modTime, bytes.NewReader(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testContent() []byte {
+	content := make([]byte, 1<<20)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	return content
+}
+
+func TestServe(t *testing.T) {
+	content := testContent()
+	upstream := fileStub(t, content)
+
+	tests := []struct {
+		name        string
+		method      string
+		rangeHeader string
+		wantStatus  int
+		wantBody    []byte
+		wantRange   string
+		wantLength  string
+		wantCounted int64
+	}{
+		{
+			name:        "full file",
+			method:      http.MethodGet,
+			wantStatus:  http.StatusOK,
+			wantBody:    content,
+			wantLength:  "1048576",
+			wantCounted: 1 << 20,
+		},
+		{
+			name:        "range",
+			method:      http.MethodGet,
+			rangeHeader: "bytes=100-199",
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    content[100:200],
+			wantRange:   "bytes 100-199/1048576",
+			wantLength:  "100",
+			wantCounted: 100,
+		},
+		{
+			name:        "open-ended range for seeking",
+			method:      http.MethodGet,
+			rangeHeader: "bytes=1048000-",
+			wantStatus:  http.StatusPartialContent,
+			wantBody:    content[1048000:],
+			wantRange:   "bytes 1048000-1048575/1048576",
+			wantLength:  "576",
+			wantCounted: 576,
+		},
+		{
+			name:        "range past the end",
+			method:      http.MethodGet,
+			rangeHeader: "bytes=2000000-",
+			wantStatus:  http.StatusRequestedRangeNotSatisfiable,
+			wantRange:   "bytes */1048576",
+		},
+		{
+			name:       "head",
+			method:     http.MethodHead,
+			wantStatus: http.StatusOK,
+			wantLength: "1048576",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var counted int64
+			p := New(1)
+			p.OnBytes = func(_ uuid.UUID, n int64) { counted += n }
+
+			req := httptest.NewRequest(tt.method, "/stream", nil)
+			if tt.rangeHeader != "" {
+				req.Header.Set("Range", tt.rangeHeader)
+			}
+			rec := httptest.NewRecorder()
+			if err := p.Serve(rec, req, upstream.URL, uuid.New(), uuid.New()); err != nil {
+				t.Fatalf("Serve: %v", err)
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != nil && !bytes.Equal(rec.Body.Bytes(), tt.wantBody) {
+				t.Errorf("body: got %d bytes, want %d", rec.Body.Len(), len(tt.wantBody))
+			}
+			if got := rec.Header().Get("Content-Range"); got != tt.wantRange {
+				t.Errorf("Content-Range = %q, want %q", got, tt.wantRange)
+			}
+			if tt.wantLength != "" && rec.Header().Get("Content-Length") != tt.wantLength {
+				t.Errorf("Content-Length = %q, want %q", rec.Header().Get("Content-Length"), tt.wantLength)
+			}
+			if tt.wantStatus == http.StatusOK && rec.Header().Get("Content-Type") != "video/x-matroska" {
+				t.Errorf("Content-Type = %q", rec.Header().Get("Content-Type"))
+			}
+			if counted != tt.wantCounted {
+				t.Errorf("counted %d bytes, want %d", counted, tt.wantCounted)
+			}
+		})
+	}
+}
+
+func TestUpstreamNotAskedForGzip(t *testing.T) {
+	// A CDN that compresses whenever asked would cost the player its Content-Length
+	var acceptEncoding string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Length", "5")
+		w.Write([]byte("video"))
+	}))
+	defer upstream.Close()
+
+	rec := httptest.NewRecorder()
+	if err := New(1).Serve(rec, httptest.NewRequest(http.MethodGet, "/stream", nil), upstream.URL, uuid.New(), uuid.New()); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if acceptEncoding != "" {
+		t.Errorf("upstream got Accept-Encoding %q", acceptEncoding)
+	}
+	if rec.Header().Get("Content-Length") != "5" || rec.Body.String() != "video" {
+		t.Errorf("Content-Length %q, body %q", rec.Header().Get("Content-Length"), rec.Body.String())
+	}
+}
+
+func TestServeUpstreamError(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "link expired", http.StatusForbidden)
+	}))
+	defer upstream.Close()
+
+	p := New(1)
+	account := uuid.New()
+	rec := httptest.NewRecorder()
+	err := p.Serve(rec, httptest.NewRequest(http.MethodGet, "/stream", nil), upstream.URL, account, uuid.New())
+	if !errors.Is(err, ErrUpstream) {
+		t.Fatalf("err = %v, want ErrUpstream", err)
+	}
+	if p.Active(account) != 0 {
+		t.Error("slot not released")
+	}
+}
+
+func TestConcurrentStreamLimit(t *testing.T) {
+	// The stub holds each response open until released, so streams stay active
+	hold := make(chan struct{})
+	started := make(chan struct{}, 2)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		started <- struct{}{}
+		<-hold
+	}))
+	defer upstream.Close()
+
+	p := New(2)
+	account := uuid.New()
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil), upstream.URL, account, uuid.New())
+		}()
+	}
+	<-started
+	<-started
+
+	err := p.Serve(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil), upstream.URL, account, uuid.New())
+	if !errors.Is(err, ErrTooManyStreams) {
+		t.Fatalf("third stream: err = %v, want ErrTooManyStreams", err)
+	}
+
+	// Other accounts aren't affected
+	other := httptest.NewRequest(http.MethodHead, "/stream", nil)
+	if err := p.Serve(httptest.NewRecorder(), other, upstream.URL, uuid.New(), uuid.New()); err != nil {
+		t.Fatalf("other account: %v", err)
+	}
+
+	close(hold)
+	wg.Wait()
+	if n := p.Active(account); n != 0 {
+		t.Errorf("%d streams still active after finishing", n)
+	}
+}
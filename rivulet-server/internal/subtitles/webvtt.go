@@ -0,0 +1,132 @@
+package subtitles
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SRT timestamps, e.g. "00:01:02,345"; sloppy files drop leading zeros or use a dot
+var srtTimestamp = regexp.MustCompile(`(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})`)
+
+// Formatting browsers don't understand: ASS overrides like {\an8} and <font> tags
+var (
+	assOverride = regexp.MustCompile(`\{\\[^}]*\}`)
+	fontTag     = regexp.MustCompile(`(?i)</?font[^>]*>`)
+)
+
+// IsWebVTT reports whether data is already a WebVTT file
+func IsWebVTT(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), []byte("WEBVTT"))
+}
+
+// ToWebVTT converts an SRT file to WebVTT. Cue numbers are dropped, timestamps are
+// normalized and formatting browsers can't render is stripped; <i>, <b> and <u> are kept.
+// Files that aren't valid UTF-8 are read as Windows-1252, which most legacy SRTs use.
+func ToWebVTT(srt []byte) []byte {
+	if IsWebVTT(srt) {
+		return srt
+	}
+	text := decode(srt)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var out strings.Builder
+	out.WriteString("WEBVTT\n")
+
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+
+		// Find the timing line; the cue number before it is optional
+		timing := -1
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				timing = i
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+		start, end, ok := parseTiming(lines[timing])
+		if !ok {
+			continue
+		}
+
+		var cue []string
+		for _, line := range lines[timing+1:] {
+			line = assOverride.ReplaceAllString(line, "")
+			line = fontTag.ReplaceAllString(line, "")
+			// "-->" would start a new cue
+			line = strings.ReplaceAll(line, "-->", "->")
+			if strings.TrimSpace(line) != "" {
+				cue = append(cue, line)
+			}
+		}
+		if len(cue) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&out, "\n%s --> %s\n%s\n", start, end, strings.Join(cue, "\n"))
+	}
+	return []byte(out.String())
+}
+
+// parseTiming reads "00:00:01,000 --> 00:00:02,500 X1:..." into WebVTT timestamps,
+// dropping SRT position coordinates
+func parseTiming(line string) (string, string, bool) {
+	from, to, found := strings.Cut(line, "-->")
+	if !found {
+		return "", "", false
+	}
+	start, ok := vttTimestamp(from)
+	if !ok {
+		return "", "", false
+	}
+	end, ok := vttTimestamp(to)
+	if !ok {
+		return "", "", false
+	}
+	return start, end, true
+}
+
+func vttTimestamp(s string) (string, bool) {
+	m := srtTimestamp.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	h, _ := strconv.Atoi(m[1])
+	min, _ := strconv.Atoi(m[2])
+	sec, _ := strconv.Atoi(m[3])
+	// "5" after the comma is 500ms, not 5ms
+	ms, _ := strconv.Atoi((m[4] + "00")[:3])
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, min, sec, ms), true
+}
+
+// decode returns the file as UTF-8 without a byte order mark
+func decode(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var b strings.Builder
+	for _, c := range data {
+		if r, ok := cp1252[c]; ok {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// Windows-1252 characters that differ from Latin-1
+var cp1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ', 0x8e: 'Ž',
+	0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›', 0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
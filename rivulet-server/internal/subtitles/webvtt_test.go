@@ -0,0 +1,61 @@
+package subtitles
+
+import "testing"
+
+func TestToWebVTT(t *testing.T) {
+	tests := []struct {
+		name string
+		srt  string
+		want string
+	}{
+		{
+			name: "basic cues",
+			srt: "1\n00:00:01,000 --> 00:00:02,500\nHello.\n\n" +
+				"2\n00:00:03,000 --> 00:00:04,000\nTwo\nlines\n",
+			want: "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\nHello.\n\n" +
+				"00:00:03.000 --> 00:00:04.000\nTwo\nlines\n",
+		},
+		{
+			name: "windows line endings and bom",
+			srt:  "\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:02,000\r\nHi\r\n\r\n",
+			want: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHi\n",
+		},
+		{
+			name: "sloppy timestamps and positions",
+			srt:  "1\n0:0:1,5 --> 0:00:02.25  X1:100 X2:200 Y1:10 Y2:20\nText\n",
+			want: "WEBVTT\n\n00:00:01.500 --> 00:00:02.250\nText\n",
+		},
+		{
+			name: "formatting",
+			srt:  "1\n00:00:01,000 --> 00:00:02,000\n{\\an8}<font color=\"#ffff00\"><i>Sign</i></font>\n",
+			want: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\n<i>Sign</i>\n",
+		},
+		{
+			name: "missing cue numbers and broken blocks",
+			srt: "00:00:01,000 --> 00:00:02,000\nNo number\n\n" +
+				"garbage\n\n" +
+				"3\n00:00:05,000 --> 00:00:06,000\n\n" +
+				"4\n00:00:07,000 --> 00:00:08,000\nArrow --> text\n",
+			want: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nNo number\n\n" +
+				"00:00:07.000 --> 00:00:08.000\nArrow -> text\n",
+		},
+		{
+			name: "windows-1252",
+			srt:  "1\n00:00:01,000 --> 00:00:02,000\nCaf\xe9 \x93quoted\x94\n",
+			want: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nCafé “quoted”\n",
+		},
+		{
+			name: "already webvtt",
+			srt:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHi\n",
+			want: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHi\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(ToWebVTT([]byte(tt.srt))); got != tt.want {
+				t.Errorf("ToWebVTT() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -258,6 +258,9 @@ class StreamResult {
   final String source;
   final int? fileIndex;
 
+  /// "episode", "multi_episode", "season" or "complete" for show streams
+  final String? packKind;
+
   StreamResult({
     required this.title,
     required this.size,
@@ -267,8 +270,11 @@ class StreamResult {
     required this.quality,
     required this.source,
     this.fileIndex,
+    this.packKind,
   });
 
+  bool get isSeasonPack => packKind == 'season' || packKind == 'complete';
+
   String get formattedSize {
     if (size == 0) return '';
     const suffixes = ["B", "KB", "MB", "GB", "TB"];
@@ -291,6 +297,7 @@ class StreamResult {
       quality: json['quality'] as String? ?? 'Unknown',
       source: json['source'] as String? ?? 'Unknown',
       fileIndex: json['file_index'] as int?,
+      packKind: (json['coverage'] as Map<String, dynamic>?)?['kind'] as String?,
     );
   }
 }
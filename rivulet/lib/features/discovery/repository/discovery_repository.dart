@@ -1,3 +1,5 @@
+import 'dart:convert';
+
 import 'package:dio/dio.dart';
 import 'package:riverpod_annotation/riverpod_annotation.dart';
 import '../../../api/rivulet_api.dart';
@@ -100,9 +102,61 @@ class DiscoveryRepository {
         if (episode != null) 'episode': episode,
       },
     );
-    return (response.data as List)
-        .map((json) => StreamResult.fromJson(json))
-        .toList();
+    // Server wraps streams with a per-provider status report
+    final data = response.data;
+    final streams = data is Map ? data['streams'] : data;
+    if (streams is! List) return [];
+    return streams.map((json) => StreamResult.fromJson(json)).toList();
+  }
+
+  /// Progressive variant of [scrapeStreams] backed by server-sent events.
+  /// Emits the streams collected so far as each provider finishes, then the
+  /// final ranked list once every provider has answered.
+  Stream<List<StreamResult>> scrapeStreamEvents({
+    required String externalId,
+    required String type,
+    int? season,
+    int? episode,
+  }) async* {
+    final response = await _dio.get<ResponseBody>(
+      '/stream/scrape/events',
+      queryParameters: {
+        'external_id': externalId,
+        'type': type,
+        if (season != null) 'season': season,
+        if (episode != null) 'episode': episode,
+      },
+      options: Options(responseType: ResponseType.stream),
+    );
+
+    final lines = response.data!.stream
+        .cast<List<int>>()
+        .transform(utf8.decoder)
+        .transform(const LineSplitter());
+
+    final collected = <StreamResult>[];
+    String? event;
+    final data = StringBuffer();
+
+    await for (final line in lines) {
+      if (line.startsWith('event:')) {
+        event = line.substring(6).trim();
+      } else if (line.startsWith('data:')) {
+        data.write(line.substring(5).trim());
+      } else if (line.isEmpty && event != null) {
+        final payload = jsonDecode(data.toString());
+        if (event == 'provider') {
+          final streams = payload['streams'] as List? ?? [];
+          collected.addAll(streams.map((json) => StreamResult.fromJson(json)));
+          yield List.of(collected);
+        } else if (event == 'done') {
+          final streams = payload['streams'] as List? ?? [];
+          yield streams.map((json) => StreamResult.fromJson(json)).toList();
+        }
+        event = null;
+        data.clear();
+      }
+    }
   }
 
   Future<Map<String, dynamic>> resolveStream({
@@ -111,6 +165,8 @@ class DiscoveryRepository {
     int? season,
     int? episode,
     int? fileIndex,
+    String? externalId,
+    String? title,
   }) async {
     // Backend expects POST JSON
     final response = await _dio.post(
@@ -121,6 +177,9 @@ class DiscoveryRepository {
         if (season != null) 'season': season,
         if (episode != null) 'episode': episode,
         if (fileIndex != null) 'file_index': fileIndex,
+        // Shown in the pending list if the torrent is still downloading
+        if (externalId != null) 'external_id': externalId,
+        if (title != null) 'title': title,
       },
     );
     return response.data as Map<String, dynamic>;
@@ -121,6 +121,8 @@ class _StreamSelectionSheetState extends ConsumerState<StreamSelectionSheet> {
         season: widget.season,
         episode: widget.episode,
         fileIndex: fileIndex,
+        externalId: widget.externalId,
+        title: widget.title,
       );
 
       if (!mounted) return;
@@ -319,6 +321,29 @@ class _StreamSelectionSheetState extends ConsumerState<StreamSelectionSheet> {
                               ),
                             ),
                           ),
+                          if (stream.isSeasonPack) ...[
+                            const SizedBox(width: 8),
+                            Container(
+                              padding: const EdgeInsets.symmetric(
+                                horizontal: 6,
+                                vertical: 2,
+                              ),
+                              decoration: BoxDecoration(
+                                color: Theme.of(context).colorScheme.secondary,
+                                borderRadius: BorderRadius.circular(4),
+                              ),
+                              child: Text(
+                                stream.packKind == 'complete'
+                                    ? 'Complete'
+                                    : 'Season pack',
+                                style: const TextStyle(
+                                  fontSize: 12,
+                                  color: Colors.white,
+                                  fontWeight: FontWeight.bold,
+                                ),
+                              ),
+                            ),
+                          ],
                           const SizedBox(width: 8),
                           Text(stream.formattedSize),
                           const SizedBox(width: 8),